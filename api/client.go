@@ -84,7 +84,13 @@ func NewAPIClient(cfg *Configuration) (*APIClient, error) {
 		client *paifeaturestore.Client
 		err    error
 	)
-	if cfg.AccessKeyId == "" || cfg.AccessKeySecret == "" {
+	if cfg.Credentials != nil {
+		config.AccessKeyId = nil
+		config.AccessKeySecret = nil
+		config.SecurityToken = nil
+		config.Credential = cfg.Credentials.Credential()
+		client, err = paifeaturestore.NewClient(config)
+	} else if cfg.AccessKeyId == "" || cfg.AccessKeySecret == "" {
 		credential, err1 := credentials.NewCredential(nil)
 		if err1 != nil {
 			return nil, err1
@@ -10,6 +10,9 @@ type Configuration struct {
 	ProjectName     string
 	UserAgent       string
 	domain          string
+
+	// Credentials to sign the requests instead of the access key, they can be rotated
+	Credentials *CredentialsHolder
 }
 
 func NewConfiguration(regionId, accessKeyId, accessKeySecret, token, projectName string) *Configuration {
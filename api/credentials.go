@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliyun/aliyun-tablestore-go-sdk/common"
+	"github.com/aliyun/credentials-go/credentials"
+)
+
+// Credentials is an access key with an optional sts token
+type Credentials struct {
+	AccessKeyId     string
+	AccessKeySecret string
+	SecurityToken   string
+
+	// Expiration of the credentials, zero if unknown or they never expire
+	Expiration time.Time
+}
+
+// CredentialsProvider provides the credentials of the client, it is called again before the credentials expire
+type CredentialsProvider interface {
+	GetCredentials(ctx context.Context) (*Credentials, error)
+}
+
+type staticCredentialsProvider struct {
+	credentials Credentials
+}
+
+// NewStaticCredentialsProvider returns a provider of fixed credentials
+func NewStaticCredentialsProvider(accessKeyId, accessKeySecret, securityToken string) CredentialsProvider {
+	return &staticCredentialsProvider{
+		credentials: Credentials{
+			AccessKeyId:     accessKeyId,
+			AccessKeySecret: accessKeySecret,
+			SecurityToken:   securityToken,
+		},
+	}
+}
+
+func (p *staticCredentialsProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	credentials := p.credentials
+	return &credentials, nil
+}
+
+// CredentialsHolder keeps the current credentials. It is shared by the clients that sign every request,
+// so they use the rotated credentials without being rebuilt
+type CredentialsHolder struct {
+	current atomic.Pointer[Credentials]
+}
+
+func NewCredentialsHolder(credentials *Credentials) *CredentialsHolder {
+	holder := &CredentialsHolder{}
+	holder.current.Store(credentials)
+	return holder
+}
+
+func (h *CredentialsHolder) Get() *Credentials {
+	return h.current.Load()
+}
+
+func (h *CredentialsHolder) Set(credentials *Credentials) {
+	h.current.Store(credentials)
+}
+
+// Ak returns the current credentials as Ak
+func (h *CredentialsHolder) Ak() Ak {
+	c := h.Get()
+	return Ak{
+		AccesskeyId:     c.AccessKeyId,
+		AccesskeySecret: c.AccessKeySecret,
+		SecurityToken:   c.SecurityToken,
+	}
+}
+
+// Credential adapts the holder to the credentials of the featurestore server api
+func (h *CredentialsHolder) Credential() credentials.Credential {
+	return &holderCredential{holder: h}
+}
+
+// TableStoreCredentialsProvider adapts the holder to the credentials provider of the tablestore sdk
+func (h *CredentialsHolder) TableStoreCredentialsProvider() common.CredentialsProvider {
+	return &holderTableStoreCredentials{holder: h}
+}
+
+type holderCredential struct {
+	holder *CredentialsHolder
+}
+
+func (c *holderCredential) GetAccessKeyId() (*string, error) {
+	return &c.holder.Get().AccessKeyId, nil
+}
+
+func (c *holderCredential) GetAccessKeySecret() (*string, error) {
+	return &c.holder.Get().AccessKeySecret, nil
+}
+
+func (c *holderCredential) GetSecurityToken() (*string, error) {
+	return &c.holder.Get().SecurityToken, nil
+}
+
+func (c *holderCredential) GetBearerToken() *string {
+	bearerToken := ""
+	return &bearerToken
+}
+
+func (c *holderCredential) GetType() *string {
+	credentialType := "access_key"
+	if c.holder.Get().SecurityToken != "" {
+		credentialType = "sts"
+	}
+	return &credentialType
+}
+
+func (c *holderCredential) GetCredential() (*credentials.CredentialModel, error) {
+	current := c.holder.Get()
+	return &credentials.CredentialModel{
+		AccessKeyId:     &current.AccessKeyId,
+		AccessKeySecret: &current.AccessKeySecret,
+		SecurityToken:   &current.SecurityToken,
+		Type:            c.GetType(),
+	}, nil
+}
+
+type holderTableStoreCredentials struct {
+	holder *CredentialsHolder
+}
+
+func (p *holderTableStoreCredentials) GetCredentials() common.Credentials {
+	current := p.holder.Get()
+	return &common.DefaultCredentials{
+		AccessKeyID:     current.AccessKeyId,
+		AccessKeySecret: current.AccessKeySecret,
+		SecurityToken:   current.SecurityToken,
+	}
+}
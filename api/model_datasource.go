@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
@@ -30,6 +31,9 @@ type Datasource struct {
 
 	HologresPrefix string `json:"-"`
 	HologresAuth   string `json:"-"`
+
+	// Credentials of the tablestore client instead of Ak, they can be rotated
+	Credentials *CredentialsHolder `json:"-"`
 }
 
 func (d *Datasource) GenerateDSN(datasourceType string) (DSN string) {
@@ -55,17 +59,32 @@ func (d *Datasource) GenerateDSN(datasourceType string) (DSN string) {
 }
 
 func (d *Datasource) NewTableStoreClient() (client *tablestore.TableStoreClient) {
+	return d.NewTableStoreClientWithTransport(nil)
+}
+
+// NewTableStoreClientWithTransport creates the client with the transport, the sdk creates one if it is nil
+func (d *Datasource) NewTableStoreClientWithTransport(transport http.RoundTripper) (client *tablestore.TableStoreClient) {
+	var config *tablestore.TableStoreConfig
+	if transport != nil {
+		config = tablestore.NewDefaultTableStoreConfig()
+		config.Transport = transport
+	}
+	var options []tablestore.ClientOption
+	if d.Credentials != nil {
+		options = append(options, tablestore.SetCredentialsProvider(d.Credentials.TableStoreCredentialsProvider()))
+	}
+
 	if d.TestMode {
 		if d.Ak.SecurityToken != "" {
-			client = tablestore.NewClientWithConfig(d.PublicAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, d.Ak.SecurityToken, nil)
+			client = tablestore.NewClientWithConfig(d.PublicAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, d.Ak.SecurityToken, config, options...)
 		} else {
-			client = tablestore.NewClient(d.PublicAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret)
+			client = tablestore.NewClientWithConfig(d.PublicAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, "", config, options...)
 		}
 	} else {
 		if d.Ak.SecurityToken != "" {
-			client = tablestore.NewClientWithConfig(d.VpcAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, d.Ak.SecurityToken, nil)
+			client = tablestore.NewClientWithConfig(d.VpcAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, d.Ak.SecurityToken, config, options...)
 		} else {
-			client = tablestore.NewClient(d.VpcAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret)
+			client = tablestore.NewClientWithConfig(d.VpcAddress, d.RdsInstanceId, d.Ak.AccesskeyId, d.Ak.AccesskeySecret, "", config, options...)
 		}
 	}
 	return
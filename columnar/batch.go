@@ -0,0 +1,222 @@
+package columnar
+
+import (
+	"fmt"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+// FeatureBatch is the features of a batch of rows stored as typed columns keyed by the feature name
+type FeatureBatch struct {
+	numRows int
+	columns []Column
+	indexes map[string]int
+}
+
+// NewFeatureBatch returns an empty batch with the columns of the feature names and types
+func NewFeatureBatch(names []string, types []constants.FSType) *FeatureBatch {
+	b := &FeatureBatch{indexes: make(map[string]int, len(names))}
+	for i, name := range names {
+		b.AddColumn(NewColumn(name, types[i]))
+	}
+
+	return b
+}
+
+// NumRows returns the number of rows of the batch
+func (b *FeatureBatch) NumRows() int {
+	return b.numRows
+}
+
+// Columns returns the columns of the batch in the order they are added
+func (b *FeatureBatch) Columns() []Column {
+	return b.columns
+}
+
+// Column returns the column of the feature name, nil if the batch has no such column
+func (b *FeatureBatch) Column(name string) Column {
+	i, ok := b.indexes[name]
+	if !ok {
+		return nil
+	}
+
+	return b.columns[i]
+}
+
+// AddColumn adds the column, it replaces the column of the same name. The column is padded with nulls to the rows of the batch
+func (b *FeatureBatch) AddColumn(column Column) {
+	if b.indexes == nil {
+		b.indexes = make(map[string]int)
+	}
+	for column.Len() < b.numRows {
+		column.AppendNull()
+	}
+	if column.Len() > b.numRows {
+		b.numRows = column.Len()
+		for _, c := range b.columns {
+			for c.Len() < b.numRows {
+				c.AppendNull()
+			}
+		}
+	}
+
+	if i, ok := b.indexes[column.Name()]; ok {
+		b.columns[i] = column
+		return
+	}
+	b.indexes[column.Name()] = len(b.columns)
+	b.columns = append(b.columns, column)
+}
+
+// EndRow ends the current row, the columns without a value of the row get a null
+func (b *FeatureBatch) EndRow() {
+	b.numRows++
+	for _, c := range b.columns {
+		for c.Len() < b.numRows {
+			c.AppendNull()
+		}
+	}
+}
+
+// RenameColumn renames the column of the feature name, it does nothing if the batch has no such column
+func (b *FeatureBatch) RenameColumn(name, newName string) {
+	i, ok := b.indexes[name]
+	if !ok || name == newName {
+		return
+	}
+
+	delete(b.indexes, name)
+	if j, ok := b.indexes[newName]; ok {
+		// the renamed column replaces the one of the new name
+		b.columns = append(b.columns[:j], b.columns[j+1:]...)
+		b.reindex()
+		i = b.indexes[name]
+	}
+	b.columns[i] = b.columns[i].rename(newName)
+	b.reindex()
+}
+
+func (b *FeatureBatch) reindex() {
+	b.indexes = make(map[string]int, len(b.columns))
+	for i, c := range b.columns {
+		b.indexes[c.Name()] = i
+	}
+}
+
+// Append appends the rows of other, the columns missing in one of the batches get nulls
+func (b *FeatureBatch) Append(other *FeatureBatch) error {
+	if other == nil {
+		return nil
+	}
+
+	for _, c := range other.columns {
+		if b.Column(c.Name()) == nil {
+			b.AddColumn(NewColumn(c.Name(), c.Type()))
+		}
+	}
+
+	for _, c := range b.columns {
+		otherColumn := other.Column(c.Name())
+		if otherColumn == nil {
+			for i := 0; i < other.numRows; i++ {
+				c.AppendNull()
+			}
+			continue
+		}
+		if err := c.appendColumn(otherColumn); err != nil {
+			return err
+		}
+	}
+	b.numRows += other.numRows
+
+	return nil
+}
+
+// Take returns a batch of the rows of the indexes, an index of -1 is a row of nulls
+func (b *FeatureBatch) Take(indexes []int) *FeatureBatch {
+	taken := &FeatureBatch{
+		numRows: len(indexes),
+		columns: make([]Column, len(b.columns)),
+		indexes: make(map[string]int, len(b.columns)),
+	}
+	for i, c := range b.columns {
+		taken.columns[i] = c.take(indexes)
+		taken.indexes[c.Name()] = i
+	}
+
+	return taken
+}
+
+// Merge adds the columns of other with the same number of rows to the batch, they replace the columns of the same name
+func (b *FeatureBatch) Merge(other *FeatureBatch) error {
+	if other == nil {
+		return nil
+	}
+	if b.numRows != other.numRows && len(b.columns) > 0 {
+		return fmt.Errorf("merge batch of %d rows into batch of %d rows", other.numRows, b.numRows)
+	}
+
+	for _, c := range other.columns {
+		b.AddColumn(c)
+	}
+
+	return nil
+}
+
+// Row returns the features of the i-th row, the null features are not in it
+func (b *FeatureBatch) Row(i int) map[string]interface{} {
+	row := make(map[string]interface{}, len(b.columns))
+	for _, c := range b.columns {
+		if !c.IsNull(i) {
+			row[c.Name()] = c.Value(i)
+		}
+	}
+
+	return row
+}
+
+// Rows returns the features of every row, see Row
+func (b *FeatureBatch) Rows() []map[string]interface{} {
+	rows := make([]map[string]interface{}, b.numRows)
+	for i := range rows {
+		rows[i] = b.Row(i)
+	}
+
+	return rows
+}
+
+// FromRows returns the batch of the rows with the columns of the feature names and types, a missing or nil feature
+// of a row is null. The numbers of other go types are converted to the type of a scalar column, a column with
+// values of other go types is a *TypedColumn[interface{}] of the values as they are
+func FromRows(rows []map[string]interface{}, names []string, types []constants.FSType) *FeatureBatch {
+	b := NewFeatureBatch(names, types)
+	for _, row := range rows {
+		for i, c := range b.columns {
+			b.columns[i] = appendRowValue(c, row[c.Name()])
+		}
+		b.numRows++
+	}
+
+	return b
+}
+
+// ColumnFromValues returns the column of the feature type with the values, they are converted as the features of FromRows
+func ColumnFromValues(name string, fsType constants.FSType, values []interface{}) Column {
+	column := NewColumn(name, fsType)
+	for _, v := range values {
+		column = appendRowValue(column, v)
+	}
+
+	return column
+}
+
+// Values returns the values and the column of the feature name when it is a column of T,
+// such as Values[int64](batch, "age"). The value of a null row is the zero value of T, see Column.IsNull
+func Values[T any](b *FeatureBatch, name string) ([]T, Column, bool) {
+	column, ok := b.Column(name).(*TypedColumn[T])
+	if !ok {
+		return nil, nil, false
+	}
+
+	return column.Values, column, true
+}
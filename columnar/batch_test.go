@@ -0,0 +1,67 @@
+package columnar
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+func TestFeatureBatch(t *testing.T) {
+	batch := NewFeatureBatch([]string{"age", "tags"}, []constants.FSType{constants.FS_INT64, constants.FS_ARRAY_STRING})
+	ages := batch.Column("age").(*TypedColumn[int64])
+	ages.Append(30)
+	batch.Column("tags").AppendValue([]string{"a"})
+	batch.EndRow()
+	batch.EndRow()
+	if err := batch.Column("age").AppendValue("31"); err == nil {
+		t.Fatal("a string should not be appended to an int64 column")
+	}
+
+	if batch.NumRows() != 2 || !ages.IsNull(1) || ages.NullCount() != 1 || batch.Column("tags").Value(1) != nil {
+		t.Fatalf("unexpected batch %v", batch.Rows())
+	}
+
+	other := FromRows([]map[string]interface{}{{"age": 40, "city": "hangzhou"}}, []string{"age", "city"}, []constants.FSType{constants.FS_INT64, constants.FS_STRING})
+	if err := batch.Append(other); err != nil {
+		t.Fatal(err)
+	}
+	batch.RenameColumn("age", "user_age")
+	values, _, ok := Values[int64](batch, "user_age")
+	if !ok || !reflect.DeepEqual(values, []int64{30, 0, 40}) {
+		t.Fatalf("unexpected ages %v", values)
+	}
+	if city := batch.Column("city"); city.NullCount() != 2 || city.Value(2) != "hangzhou" {
+		t.Fatalf("unexpected cities %v", batch.Rows())
+	}
+
+	taken := batch.Take([]int{2, -1, 0})
+	expected := []map[string]interface{}{
+		{"user_age": int64(40), "city": "hangzhou"},
+		{},
+		{"user_age": int64(30), "tags": []string{"a"}},
+	}
+	if !reflect.DeepEqual(taken.Rows(), expected) {
+		t.Fatalf("expect %v, got %v", expected, taken.Rows())
+	}
+}
+
+func TestFromRows(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"price": int32(3), "score": "high"},
+		{"price": 2.5, "score": []interface{}{"x"}},
+		nil,
+	}
+	batch := FromRows(rows, []string{"price", "score"}, []constants.FSType{constants.FS_DOUBLE, constants.FS_STRING})
+
+	prices, _, ok := Values[float64](batch, "price")
+	if !ok || !reflect.DeepEqual(prices, []float64{3, 2.5, 0}) || !batch.Column("price").IsNull(2) {
+		t.Fatalf("the numbers should be converted, got %v", prices)
+	}
+	if _, _, ok := Values[string](batch, "score"); ok {
+		t.Fatal("a column with values of other types should not be a string column")
+	}
+	if score := batch.Column("score"); score.Value(0) != "high" || score.Type() != constants.FS_STRING || !score.IsNull(2) {
+		t.Fatalf("unexpected scores %v", batch.Rows())
+	}
+}
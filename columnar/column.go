@@ -0,0 +1,223 @@
+package columnar
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+// Column is a typed column of a FeatureBatch, see TypedColumn
+type Column interface {
+	Name() string
+	Type() constants.FSType
+	Len() int
+	IsNull(i int) bool
+	NullCount() int
+
+	// Value returns the value of the i-th row, nil if it is null
+	Value(i int) interface{}
+
+	AppendNull()
+	// AppendValue appends v, it must be of the go type of the column. A nil v appends a null
+	AppendValue(v interface{}) error
+
+	rename(name string) Column
+	take(indexes []int) Column
+	appendColumn(other Column) error
+	goType() reflect.Type
+}
+
+// TypedColumn is a column of the values of type T, the value of a null row is the zero value of T.
+// The go types of the feature types are the ones the daos read them as, see NewColumn
+type TypedColumn[T any] struct {
+	name   string
+	fsType constants.FSType
+
+	Values []T
+
+	// nulls is the bitmap of the null rows
+	nulls     []uint64
+	nullCount int
+}
+
+// GoType returns the go type of the values of the feature type, see NewColumn. It is nil for an unknown type
+func GoType(fsType constants.FSType) reflect.Type {
+	goType := NewColumn("", fsType).goType()
+	if goType.Kind() == reflect.Interface {
+		return nil
+	}
+
+	return goType
+}
+
+func newTypedColumn[T any](name string, fsType constants.FSType) *TypedColumn[T] {
+	return &TypedColumn[T]{name: name, fsType: fsType}
+}
+
+// NewColumn returns the empty column of the feature type, such as a *TypedColumn[int64] of FS_INT64,
+// a *TypedColumn[[]string] of FS_ARRAY_STRING and a *TypedColumn[map[string]float64] of FS_MAP_STRING_DOUBLE.
+// The column of an unknown type is a *TypedColumn[interface{}]
+func NewColumn(name string, fsType constants.FSType) Column {
+	switch fsType {
+	case constants.FS_INT32:
+		return newTypedColumn[int32](name, fsType)
+	case constants.FS_INT64:
+		return newTypedColumn[int64](name, fsType)
+	case constants.FS_FLOAT:
+		return newTypedColumn[float32](name, fsType)
+	case constants.FS_DOUBLE:
+		return newTypedColumn[float64](name, fsType)
+	case constants.FS_STRING:
+		return newTypedColumn[string](name, fsType)
+	case constants.FS_BOOLEAN:
+		return newTypedColumn[bool](name, fsType)
+	case constants.FS_TIMESTAMP:
+		return newTypedColumn[time.Time](name, fsType)
+	case constants.FS_ARRAY_INT32:
+		return newTypedColumn[[]int32](name, fsType)
+	case constants.FS_ARRAY_INT64:
+		return newTypedColumn[[]int64](name, fsType)
+	case constants.FS_ARRAY_FLOAT:
+		return newTypedColumn[[]float32](name, fsType)
+	case constants.FS_ARRAY_DOUBLE:
+		return newTypedColumn[[]float64](name, fsType)
+	case constants.FS_ARRAY_STRING:
+		return newTypedColumn[[]string](name, fsType)
+	case constants.FS_ARRAY_ARRAY_FLOAT:
+		return newTypedColumn[[][]float32](name, fsType)
+	case constants.FS_MAP_INT32_INT32:
+		return newTypedColumn[map[int32]int32](name, fsType)
+	case constants.FS_MAP_INT32_INT64:
+		return newTypedColumn[map[int32]int64](name, fsType)
+	case constants.FS_MAP_INT32_FLOAT:
+		return newTypedColumn[map[int32]float32](name, fsType)
+	case constants.FS_MAP_INT32_DOUBLE:
+		return newTypedColumn[map[int32]float64](name, fsType)
+	case constants.FS_MAP_INT32_STRING:
+		return newTypedColumn[map[int32]string](name, fsType)
+	case constants.FS_MAP_INT64_INT32:
+		return newTypedColumn[map[int64]int32](name, fsType)
+	case constants.FS_MAP_INT64_INT64:
+		return newTypedColumn[map[int64]int64](name, fsType)
+	case constants.FS_MAP_INT64_FLOAT:
+		return newTypedColumn[map[int64]float32](name, fsType)
+	case constants.FS_MAP_INT64_DOUBLE:
+		return newTypedColumn[map[int64]float64](name, fsType)
+	case constants.FS_MAP_INT64_STRING:
+		return newTypedColumn[map[int64]string](name, fsType)
+	case constants.FS_MAP_STRING_INT32:
+		return newTypedColumn[map[string]int32](name, fsType)
+	case constants.FS_MAP_STRING_INT64:
+		return newTypedColumn[map[string]int64](name, fsType)
+	case constants.FS_MAP_STRING_FLOAT:
+		return newTypedColumn[map[string]float32](name, fsType)
+	case constants.FS_MAP_STRING_DOUBLE:
+		return newTypedColumn[map[string]float64](name, fsType)
+	case constants.FS_MAP_STRING_STRING:
+		return newTypedColumn[map[string]string](name, fsType)
+	default:
+		return newTypedColumn[interface{}](name, fsType)
+	}
+}
+
+func (c *TypedColumn[T]) Name() string {
+	return c.name
+}
+
+func (c *TypedColumn[T]) Type() constants.FSType {
+	return c.fsType
+}
+
+func (c *TypedColumn[T]) Len() int {
+	return len(c.Values)
+}
+
+func (c *TypedColumn[T]) IsNull(i int) bool {
+	word := i / 64
+	return word < len(c.nulls) && c.nulls[word]&(1<<(uint(i)%64)) != 0
+}
+
+func (c *TypedColumn[T]) NullCount() int {
+	return c.nullCount
+}
+
+func (c *TypedColumn[T]) Value(i int) interface{} {
+	if c.IsNull(i) {
+		return nil
+	}
+
+	return c.Values[i]
+}
+
+// Append appends the value v
+func (c *TypedColumn[T]) Append(v T) {
+	c.Values = append(c.Values, v)
+}
+
+func (c *TypedColumn[T]) AppendNull() {
+	var zero T
+	i := len(c.Values)
+	c.Values = append(c.Values, zero)
+	for len(c.nulls) <= i/64 {
+		c.nulls = append(c.nulls, 0)
+	}
+	c.nulls[i/64] |= 1 << (uint(i) % 64)
+	c.nullCount++
+}
+
+func (c *TypedColumn[T]) AppendValue(v interface{}) error {
+	if v == nil {
+		c.AppendNull()
+		return nil
+	}
+
+	value, ok := v.(T)
+	if !ok {
+		return fmt.Errorf("column %s of type %T can not append value of type %T", c.name, c.Values, v)
+	}
+	c.Append(value)
+
+	return nil
+}
+
+func (c *TypedColumn[T]) goType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (c *TypedColumn[T]) rename(name string) Column {
+	renamed := *c
+	renamed.name = name
+	return &renamed
+}
+
+func (c *TypedColumn[T]) take(indexes []int) Column {
+	taken := &TypedColumn[T]{name: c.name, fsType: c.fsType, Values: make([]T, 0, len(indexes))}
+	for _, i := range indexes {
+		if i < 0 || c.IsNull(i) {
+			taken.AppendNull()
+		} else {
+			taken.Append(c.Values[i])
+		}
+	}
+
+	return taken
+}
+
+func (c *TypedColumn[T]) appendColumn(other Column) error {
+	typed, ok := other.(*TypedColumn[T])
+	if !ok {
+		return fmt.Errorf("column %s of type %T can not append column of type %T", c.name, c, other)
+	}
+
+	for i, v := range typed.Values {
+		if typed.IsNull(i) {
+			c.AppendNull()
+		} else {
+			c.Append(v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package columnar
+
+import (
+	"reflect"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
+)
+
+// appendRowValue appends the feature value of a row to the column, the numbers of other go types are converted to the
+// type of a scalar column. The column becomes a *TypedColumn[interface{}] of the values as they are when a value
+// can not be converted, so the rows of any dao fit in a batch
+func appendRowValue(column Column, v interface{}) Column {
+	if column.AppendValue(v) == nil {
+		return column
+	}
+	if converted, ok := convertScalar(column, v); ok && column.AppendValue(converted) == nil {
+		return column
+	}
+
+	values := newTypedColumn[interface{}](column.Name(), column.Type())
+	for i := 0; i < column.Len(); i++ {
+		values.AppendValue(column.Value(i))
+	}
+	values.Append(v)
+
+	return values
+}
+
+// convertScalar converts v to the go type of a numeric or string column
+func convertScalar(column Column, v interface{}) (interface{}, bool) {
+	value := reflect.ValueOf(v)
+	isNumber := value.CanInt() || value.CanUint() || value.CanFloat()
+
+	switch column.(type) {
+	case *TypedColumn[string]:
+		if isNumber {
+			return utils.ToString(v, ""), true
+		}
+	case *TypedColumn[int32], *TypedColumn[int64], *TypedColumn[float32], *TypedColumn[float64]:
+		if !isNumber {
+			return nil, false
+		}
+		var f float64
+		var i int64
+		switch {
+		case value.CanInt():
+			i, f = value.Int(), float64(value.Int())
+		case value.CanUint():
+			i, f = int64(value.Uint()), float64(value.Uint())
+		default:
+			i, f = int64(value.Float()), value.Float()
+		}
+
+		switch column.(type) {
+		case *TypedColumn[int32]:
+			return int32(i), true
+		case *TypedColumn[int64]:
+			return i, true
+		case *TypedColumn[float32]:
+			return float32(f), true
+		default:
+			return f, true
+		}
+	}
+
+	return nil, false
+}
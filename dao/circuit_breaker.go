@@ -0,0 +1,272 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCircuitErrorPercent   = 50
+	DefaultCircuitMinRequests    = 20
+	DefaultCircuitWindow         = 10 * time.Second
+	DefaultCircuitOpenDuration   = 5 * time.Second
+	DefaultCircuitHalfOpenProbes = 1
+)
+
+// ErrCircuitOpen is wrapped by the *CircuitOpenError of the reads rejected by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is the error of a read rejected without calling the online store, Name is the circuit breaker
+// that is open
+type CircuitOpenError struct {
+	Name string
+	// RetryAt is when the circuit breaker lets the probes through
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open until %s", e.Name, e.RetryAt.Format(time.RFC3339Nano))
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// CircuitBreakerConfig configures a circuit breaker of the reads of a datasource or a feature view
+type CircuitBreakerConfig struct {
+	// ErrorPercent opens the circuit when the percentage of the failed reads in the window reaches it,
+	// default is DefaultCircuitErrorPercent
+	ErrorPercent float64
+	// MinRequests is the number of the reads in the window before the circuit may open, default is DefaultCircuitMinRequests
+	MinRequests int
+	// Window is the time the reads are counted in, default is DefaultCircuitWindow
+	Window time.Duration
+	// OpenDuration is how long the open circuit rejects the reads before it lets the probes through,
+	// default is DefaultCircuitOpenDuration
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of the probes that must succeed to close the circuit, a failed probe opens it
+	// again. Default is DefaultCircuitHalfOpenProbes
+	HalfOpenProbes int
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.ErrorPercent <= 0 {
+		c.ErrorPercent = DefaultCircuitErrorPercent
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = DefaultCircuitMinRequests
+	}
+	if c.Window <= 0 {
+		c.Window = DefaultCircuitWindow
+	}
+	if c.OpenDuration <= 0 {
+		c.OpenDuration = DefaultCircuitOpenDuration
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = DefaultCircuitHalfOpenProbes
+	}
+
+	return c
+}
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	}
+
+	return "unknown"
+}
+
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// CircuitBreakerStats is the state of a circuit breaker and the reads counted in its current window
+type CircuitBreakerStats struct {
+	Name     string       `json:"name"`
+	State    CircuitState `json:"state"`
+	Requests int          `json:"requests"`
+	Failures int          `json:"failures"`
+	// OpenedAt is when the circuit opened last, zero if it never opened
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+// CircuitBreaker counts the failed reads in a window and opens when their percentage reaches the threshold.
+// The open circuit rejects the reads with a *CircuitOpenError until OpenDuration passes, then it is half-open
+// and lets HalfOpenProbes reads through at a time to test whether the online store recovered
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:        name,
+		config:      config.withDefaults(),
+		windowStart: time.Now(),
+	}
+}
+
+// Allow returns a *CircuitOpenError when the read is rejected, otherwise done must be called with the error of the read
+func (b *CircuitBreaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.state == CircuitOpen {
+		retryAt := b.openedAt.Add(b.config.OpenDuration)
+		if now.Before(retryAt) {
+			return nil, &CircuitOpenError{Name: b.name, RetryAt: retryAt}
+		}
+		b.state = CircuitHalfOpen
+		b.probes, b.successes = 0, 0
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.config.HalfOpenProbes {
+			return nil, &CircuitOpenError{Name: b.name, RetryAt: now}
+		}
+		b.probes++
+		return func(err error) { b.doneProbe(err) }, nil
+	}
+
+	return func(err error) { b.done(err) }, nil
+}
+
+func (b *CircuitBreaker) done(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitClosed {
+		return
+	}
+	now := time.Now()
+	if now.Sub(b.windowStart) > b.config.Window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	b.requests++
+	if err != nil {
+		b.failures++
+	}
+	if b.requests >= b.config.MinRequests && float64(b.failures)*100/float64(b.requests) >= b.config.ErrorPercent {
+		b.open(now)
+	}
+}
+
+func (b *CircuitBreaker) doneProbe(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitHalfOpen {
+		return
+	}
+	b.probes--
+	switch {
+	case errors.Is(err, context.Canceled):
+	case err != nil:
+		b.open(time.Now())
+	default:
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			b.state = CircuitClosed
+			b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+		}
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+}
+
+// Stats returns the state of the circuit breaker
+func (b *CircuitBreaker) Stats() CircuitBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == CircuitOpen && !time.Now().Before(b.openedAt.Add(b.config.OpenDuration)) {
+		state = CircuitHalfOpen
+	}
+
+	return CircuitBreakerStats{
+		Name:     b.name,
+		State:    state,
+		Requests: b.requests,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
+
+// CircuitBreakers are the circuit breakers of the datasources and the feature views, they are kept across the
+// refreshes of the projects. The name of the circuit breaker of a datasource is its type and name such as
+// hologres/holo_ds, featuredb has one, the name of the one of a feature view is its project and name such as
+// p1/user_fea
+type CircuitBreakers struct {
+	config  CircuitBreakerConfig
+	configs map[string]CircuitBreakerConfig
+
+	breakers sync.Map
+}
+
+// NewCircuitBreakers returns the circuit breakers with config, configs replaces it for the circuit breakers by name
+func NewCircuitBreakers(config CircuitBreakerConfig, configs map[string]CircuitBreakerConfig) *CircuitBreakers {
+	return &CircuitBreakers{config: config, configs: configs}
+}
+
+// Get returns the circuit breaker of the name, it is created on the first call
+func (c *CircuitBreakers) Get(name string) *CircuitBreaker {
+	if breaker, ok := c.breakers.Load(name); ok {
+		return breaker.(*CircuitBreaker)
+	}
+
+	config, ok := c.configs[name]
+	if !ok {
+		config = c.config
+	}
+	breaker, _ := c.breakers.LoadOrStore(name, NewCircuitBreaker(name, config))
+
+	return breaker.(*CircuitBreaker)
+}
+
+// Stats returns the states of the circuit breakers ordered by name
+func (c *CircuitBreakers) Stats() []CircuitBreakerStats {
+	var stats []CircuitBreakerStats
+	c.breakers.Range(func(key, value any) bool {
+		stats = append(stats, value.(*CircuitBreaker).Stats())
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	return stats
+}
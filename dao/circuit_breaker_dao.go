@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+)
+
+// circuitBreakerFeatureViewDao rejects the reads with a *CircuitOpenError while the circuit breaker of the datasource
+// or the one of the feature view is open, the other reads are counted by both. The reads cancelled by the caller are
+// not counted, the ones past the deadline of the caller are failures, and so are the reads with a failed response
+// tolerated by the online store such as a FeatureDB 503
+type circuitBreakerFeatureViewDao struct {
+	FeatureViewDao
+	breakers []*CircuitBreaker
+}
+
+func newCircuitBreakerFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *circuitBreakerFeatureViewDao {
+	name := config.CircuitBreakerName
+	if name == "" {
+		name = config.ProjectName + "/" + config.FeatureViewName
+	}
+
+	return &circuitBreakerFeatureViewDao{
+		FeatureViewDao: featureViewDao,
+		breakers: []*CircuitBreaker{
+			config.CircuitBreakers.Get(config.DatasourceName()),
+			config.CircuitBreakers.Get(name),
+		},
+	}
+}
+
+// allow returns the func to call with the error of the read, the probes let through by the circuit breakers before
+// the one that rejects the read are released without being counted
+func (d *circuitBreakerFeatureViewDao) allow() (func(err error), error) {
+	dones := make([]func(err error), 0, len(d.breakers))
+	for _, breaker := range d.breakers {
+		done, err := breaker.Allow()
+		if err != nil {
+			for _, done := range dones {
+				done(context.Canceled)
+			}
+			return nil, err
+		}
+		dones = append(dones, done)
+	}
+
+	return func(err error) {
+		for _, done := range dones {
+			done(err)
+		}
+	}, nil
+}
+
+func (d *circuitBreakerFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+func (d *circuitBreakerFeatureViewDao) GetUserSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *circuitBreakerFeatureViewDao) GetUserAggregatedSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	return d.GetUserAggregatedSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *circuitBreakerFeatureViewDao) GetUserBehaviorFeature(userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserBehaviorFeatureWithContext(context.Background(), userIds, events, selectFields, sequenceConfig)
+}
+
+func (d *circuitBreakerFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	ctx, statusErrs := withStatusErrors(ctx)
+	result, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	done(statusErrs.orErr(err))
+
+	return result, err
+}
+func (d *circuitBreakerFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if _, ok := d.FeatureViewDao.(FeatureBatchDao); !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	ctx, statusErrs := withStatusErrors(ctx)
+	batch, err := GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	done(statusErrs.orErr(err))
+
+	return batch, err
+}
+
+// GetFeaturesPartialWithContext counts the read as failed when a batch of keys failed
+func (d *circuitBreakerFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	partialResult, err := GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+	done(partialResult.Err())
+
+	return partialResult, nil
+}
+func (d *circuitBreakerFeatureViewDao) GetUserSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.FeatureViewDao.GetUserSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	done(err)
+
+	return result, err
+}
+func (d *circuitBreakerFeatureViewDao) GetUserAggregatedSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.FeatureViewDao.GetUserAggregatedSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	done(err)
+
+	return result, err
+}
+func (d *circuitBreakerFeatureViewDao) GetUserBehaviorFeatureWithContext(ctx context.Context, userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	done, err := d.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.FeatureViewDao.GetUserBehaviorFeatureWithContext(ctx, userIds, events, selectFields, sequenceConfig)
+	done(err)
+
+	return result, err
+}
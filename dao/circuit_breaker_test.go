@@ -0,0 +1,110 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+)
+
+func TestCircuitBreakerFeatureViewDao(t *testing.T) {
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{ErrorPercent: 50, MinRequests: 4, OpenDuration: 50 * time.Millisecond}, nil)
+	config := DaoConfig{DatasourceType: constants.Datasource_Type_Hologres, HologresName: "holo", ProjectName: "p1",
+		FeatureViewName: "item_fea", CircuitBreakers: breakers}
+	backend := &fakeFeatureViewDao{}
+	backend.failing.Store(true)
+	featureViewDao := newCircuitBreakerFeatureViewDao(backend, config)
+	keys := []interface{}{"1"}
+
+	// the reads cancelled by the caller are not counted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceledDao := newCircuitBreakerFeatureViewDao(&fakeFeatureViewDao{}, config)
+	for i := 0; i < 4; i++ {
+		canceledDao.GetFeaturesWithContext(ctx, keys, nil, 1)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1); !errors.Is(err, errReadFailed) {
+			t.Fatalf("expect the read error, got %v", err)
+		}
+	}
+
+	// the datasource circuit is open for the other feature views too
+	otherDao := newCircuitBreakerFeatureViewDao(backend, DaoConfig{DatasourceType: constants.Datasource_Type_Hologres,
+		HologresName: "holo", ProjectName: "p1", FeatureViewName: "user_fea", CircuitBreakers: breakers})
+	for _, d := range []*circuitBreakerFeatureViewDao{featureViewDao, otherDao} {
+		_, err := d.GetFeaturesWithContext(context.Background(), keys, nil, 1)
+		var openErr *CircuitOpenError
+		if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || openErr.Name != "hologres/holo" {
+			t.Fatalf("expect the circuit of the datasource open, got %v", err)
+		}
+	}
+	if calls := len(backend.reads()); calls != 4 {
+		t.Fatalf("expect the open circuit to reject the reads, got %d calls", calls)
+	}
+	if stats := breakers.Stats(); len(stats) != 3 || stats[0].Name != "hologres/holo" || stats[0].State != CircuitOpen {
+		t.Fatalf("expect the open datasource circuit, got %+v", stats)
+	}
+
+	// a failed probe opens the circuit again, a successful one closes it
+	time.Sleep(60 * time.Millisecond)
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1); !errors.Is(err, errReadFailed) {
+		t.Fatalf("expect the probe to fail, got %v", err)
+	}
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expect the circuit open again, got %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	backend.failing.Store(false)
+	for _, d := range []*circuitBreakerFeatureViewDao{featureViewDao, otherDao} {
+		if rows, err := d.GetFeaturesWithContext(context.Background(), keys, nil, 1); err != nil || len(rows) != 1 {
+			t.Fatalf("expect the circuit closed, got %v %v", rows, err)
+		}
+	}
+	for _, stats := range breakers.Stats() {
+		if stats.State != CircuitClosed {
+			t.Fatalf("expect the circuits closed, got %+v", stats)
+		}
+	}
+}
+
+func TestCircuitBreakerFeatureDBStatusErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"message":"server is busy"}`))
+	}))
+	defer server.Close()
+
+	registry := datasource.NewRegistry()
+	featureDBClient := registry.InitFeatureDBClient(server.URL, "token", "", false)
+	defer registry.Close()
+
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{ErrorPercent: 50, MinRequests: 4, OpenDuration: time.Minute}, nil)
+	config := DaoConfig{Registry: registry, FeatureDBClient: featureDBClient, DatasourceType: constants.Datasource_Type_FeatureDB, ProjectName: "p1",
+		FeatureViewName: "user_fea", FeatureDBSignature: "signature", PrimaryKeyField: "user_id", Fields: []string{"age"},
+		FieldTypeMap:    map[string]constants.FSType{"user_id": constants.FS_STRING, "age": constants.FS_INT64},
+		CircuitBreakers: breakers}
+	featureViewDao := newCircuitBreakerFeatureViewDao(NewFeatureViewFeatureDBDao(config), config)
+
+	// the failed responses leave the keys missing without an error, they are still counted
+	for i := 0; i < 4; i++ {
+		if rows, err := featureViewDao.GetFeaturesWithContext(context.Background(), []interface{}{"1"}, []string{"user_id", "age"}, 1); err != nil || len(rows) != 0 {
+			t.Fatalf("expect the keys missing, got %v %v", rows, err)
+		}
+	}
+	sent := requests.Load()
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), []interface{}{"1"}, []string{"user_id", "age"}, 1); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expect the circuit open, got %v", err)
+	}
+	if requests.Load() != sent {
+		t.Fatalf("expect the open circuit to reject the reads, got %d requests", requests.Load()-sent)
+	}
+}
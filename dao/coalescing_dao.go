@@ -0,0 +1,331 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	DefaultCoalesceWindow       = 2 * time.Millisecond
+	DefaultCoalesceMaxBatchSize = 200
+	DefaultCoalesceTimeout      = 5 * time.Second
+)
+
+// CoalesceConfig configures the coalescing of the concurrent GetFeatures reads of a feature view
+type CoalesceConfig struct {
+	// Window is how long the keys of the concurrent reads are collected before they are read in one call,
+	// default is DefaultCoalesceWindow
+	Window time.Duration
+
+	// MaxBatchSize reads the collected keys at once when there are so many, default is DefaultCoalesceMaxBatchSize.
+	// The reads of more keys are not coalesced
+	MaxBatchSize int
+
+	// Timeout caps how long each caller keeps the coalesced read running, default is DefaultCoalesceTimeout.
+	// The read runs until the latest deadline of its callers, a caller without a deadline counts as Timeout
+	Timeout time.Duration
+}
+
+// coalescingFeatureViewDao collects the keys of the concurrent GetFeatures or GetFeaturesPartial reads with the same
+// fields, reads the de-duplicated keys in one call and returns the rows of its keys to every caller. The batches of
+// the same keys in flight share one call. The call is not bound to the context of any caller, it has its own deadline and span
+type coalescingFeatureViewDao struct {
+	FeatureViewDao
+	primaryKeyField string
+	window          time.Duration
+	maxBatchSize    int
+	timeout         time.Duration
+
+	tracer tracing.Tracer
+	attrs  []tracing.Attribute
+
+	mu      sync.Mutex
+	pending map[string]*coalescedBatch
+
+	group singleflight.Group
+}
+
+// coalescedBatch is the keys collected for one call, done is closed once rows, failures and err are set
+type coalescedBatch struct {
+	key          string
+	selectFields []string
+	weight       int
+	partial      bool
+	keys         []interface{}
+	seenKeys     map[string]bool
+	timer        *time.Timer
+
+	// ctx of the call, it is cancelled when every caller is gone. The call ends at deadline, the latest one of the
+	// callers
+	ctx      context.Context
+	cancel   context.CancelFunc
+	waiters  int
+	callers  int
+	deadline time.Time
+
+	done     chan struct{}
+	rows     []map[string]interface{}
+	failures []*BatchError
+	err      error
+}
+
+func newCoalescingFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *coalescingFeatureViewDao {
+	d := &coalescingFeatureViewDao{
+		FeatureViewDao:  featureViewDao,
+		primaryKeyField: config.PrimaryKeyField,
+		window:          config.Coalesce.Window,
+		maxBatchSize:    config.Coalesce.MaxBatchSize,
+		timeout:         config.Coalesce.Timeout,
+		tracer:          config.tracer(),
+		attrs: []tracing.Attribute{
+			tracing.String(tracing.AttrProject, config.ProjectName),
+			tracing.String(tracing.AttrFeatureView, config.FeatureViewName),
+			tracing.String(tracing.AttrDatasourceType, config.DatasourceType),
+		},
+		pending: make(map[string]*coalescedBatch),
+	}
+	if d.window <= 0 {
+		d.window = DefaultCoalesceWindow
+	}
+	if d.maxBatchSize <= 0 {
+		d.maxBatchSize = DefaultCoalesceMaxBatchSize
+	}
+	if d.timeout <= 0 {
+		d.timeout = DefaultCoalesceTimeout
+	}
+
+	return d
+}
+
+func (d *coalescingFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+
+func (d *coalescingFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	if len(keys) == 0 || len(keys) >= d.maxBatchSize {
+		return d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	}
+
+	batch, err := d.wait(ctx, keys, selectFields, weight, false)
+	if err != nil {
+		return nil, err
+	}
+	if batch.err != nil {
+		return nil, batch.err
+	}
+
+	return d.callerRows(batch.rows, callerKeys(keys)), nil
+}
+
+// GetFeaturesBatchWithContext reads the batch of the keys as it is, the columnar reads are not coalesced
+func (d *coalescingFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if _, ok := d.FeatureViewDao.(FeatureBatchDao); !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	return GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+}
+
+// GetFeaturesPartialWithContext coalesces the partial reads apart from the GetFeatures ones, every caller gets the
+// rows and the failures of its own keys
+func (d *coalescingFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	if len(keys) == 0 || len(keys) >= d.maxBatchSize {
+		return GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	}
+
+	batch, err := d.wait(ctx, keys, selectFields, weight, true)
+	if err != nil {
+		return nil, err
+	}
+	if batch.err != nil {
+		return nil, batch.err
+	}
+
+	ids := callerKeys(keys)
+	result := &PartialResult{Rows: d.callerRows(batch.rows, ids)}
+	for _, failure := range batch.failures {
+		var failedKeys []interface{}
+		for _, key := range failure.Keys {
+			if ids[fmt.Sprint(key)] {
+				failedKeys = append(failedKeys, key)
+			}
+		}
+		if len(failedKeys) > 0 {
+			result.Failures = append(result.Failures, &BatchError{Keys: failedKeys, Err: failure.Err})
+		}
+	}
+
+	return result, nil
+}
+
+// wait joins the keys to a batch and waits for it to be read, or for ctx to be done
+func (d *coalescingFeatureViewDao) wait(ctx context.Context, keys []interface{}, selectFields []string, weight int, partial bool) (*coalescedBatch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	batch := d.join(ctx, keys, selectFields, weight, partial)
+	select {
+	case <-batch.done:
+		return batch, nil
+	case <-ctx.Done():
+		d.leave(batch)
+		return nil, ctx.Err()
+	}
+}
+
+func callerKeys(keys []interface{}) map[string]bool {
+	ids := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		ids[fmt.Sprint(key)] = true
+	}
+
+	return ids
+}
+
+// callerRows returns the copies of the rows of the caller keys, the rows are modified by the callers
+func (d *coalescingFeatureViewDao) callerRows(rows []map[string]interface{}, ids map[string]bool) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, row := range rows {
+		if !ids[fmt.Sprint(row[d.primaryKeyField])] {
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			copied[k] = v
+		}
+		result = append(result, copied)
+	}
+
+	return result
+}
+
+// join adds the keys to the pending batch of the fields, the batch is read when the window ends or it is full.
+// The callers overriding the ttl differently and the partial reads do not share a batch with the others
+func (d *coalescingFeatureViewDao) join(ctx context.Context, keys []interface{}, selectFields []string, weight int, partial bool) *coalescedBatch {
+	batchKey := fmt.Sprintf("%d:%s", weight, strings.Join(selectFields, ","))
+	if partial {
+		batchKey += ":partial"
+	}
+	ttl, overridesTTL := ctx.Value(ttlKey{}).(time.Duration)
+	if overridesTTL {
+		batchKey = fmt.Sprintf("%s:ttl=%s", batchKey, ttl)
+	}
+
+	deadline := time.Now().Add(d.timeout)
+	if callerDeadline, ok := ctx.Deadline(); ok && callerDeadline.Before(deadline) {
+		deadline = callerDeadline
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	batch := d.pending[batchKey]
+	if batch == nil || batch.ctx.Err() != nil {
+		batchCtx := context.Background()
+		if overridesTTL {
+			batchCtx = WithTTL(batchCtx, ttl)
+		}
+		batchCtx, cancel := context.WithCancel(batchCtx)
+		batch = &coalescedBatch{
+			key:          batchKey,
+			selectFields: selectFields,
+			weight:       weight,
+			partial:      partial,
+			seenKeys:     make(map[string]bool),
+			ctx:          batchCtx,
+			cancel:       cancel,
+			done:         make(chan struct{}),
+		}
+		d.pending[batchKey] = batch
+		batch.timer = time.AfterFunc(d.window, func() {
+			d.mu.Lock()
+			if d.pending[batchKey] != batch {
+				d.mu.Unlock()
+				return
+			}
+			delete(d.pending, batchKey)
+			d.mu.Unlock()
+
+			d.read(batch)
+		})
+	}
+
+	batch.waiters++
+	batch.callers++
+	if deadline.After(batch.deadline) {
+		batch.deadline = deadline
+	}
+	for _, key := range keys {
+		id := fmt.Sprint(key)
+		if !batch.seenKeys[id] {
+			batch.seenKeys[id] = true
+			batch.keys = append(batch.keys, key)
+		}
+	}
+
+	if len(batch.keys) >= d.maxBatchSize {
+		delete(d.pending, batchKey)
+		batch.timer.Stop()
+		go d.read(batch)
+	}
+
+	return batch
+}
+
+// leave cancels the read of the batch when its last caller is gone
+func (d *coalescingFeatureViewDao) leave(batch *coalescedBatch) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	batch.waiters--
+	if batch.waiters == 0 {
+		batch.cancel()
+	}
+}
+
+// read reads the keys of the batch until the deadline of the batch, in a span of the batch
+func (d *coalescingFeatureViewDao) read(batch *coalescedBatch) {
+	defer batch.cancel()
+
+	ctx, cancel := context.WithDeadline(batch.ctx, batch.deadline)
+	defer cancel()
+	attrs := make([]tracing.Attribute, 0, len(d.attrs)+2)
+	attrs = append(attrs, d.attrs...)
+	attrs = append(attrs, tracing.Int(tracing.AttrKeyCount, len(batch.keys)), tracing.Int(tracing.AttrCallerCount, batch.callers))
+	ctx, span := d.tracer.Start(ctx, "FeatureViewDao.CoalescedGetFeatures", attrs...)
+
+	ids := make([]string, 0, len(batch.seenKeys))
+	for id := range batch.seenKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result, err, _ := d.group.Do(batch.key+"|"+strings.Join(ids, ","), func() (interface{}, error) {
+		if batch.partial {
+			return GetFeaturesPartial(ctx, d.FeatureViewDao, batch.keys, batch.selectFields, batch.weight)
+		}
+		rows, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, batch.keys, batch.selectFields, batch.weight)
+		return &PartialResult{Rows: rows}, err
+	})
+	if err != nil {
+		batch.err = err
+	} else {
+		partialResult := result.(*PartialResult)
+		batch.rows, batch.failures = partialResult.Rows, partialResult.Failures
+		span.SetAttributes(tracing.Int(tracing.AttrResultCount, len(batch.rows)))
+		err = partialResult.Err()
+	}
+	tracing.End(span, err)
+
+	close(batch.done)
+}
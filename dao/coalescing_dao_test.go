@@ -0,0 +1,149 @@
+package dao
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescingFeatureViewDao(t *testing.T) {
+	backend := &fakeFeatureViewDao{}
+	featureViewDao := newCoalescingFeatureViewDao(backend, DaoConfig{
+		PrimaryKeyField: "item_id",
+		Coalesce:        &CoalesceConfig{Window: 20 * time.Millisecond, MaxBatchSize: 10},
+	})
+
+	requests := [][]interface{}{{"1", "2"}, {"2", "3"}, {"3", "1", "4"}}
+	results := make([][]map[string]interface{}, len(requests))
+	var wg sync.WaitGroup
+	for i, keys := range requests {
+		wg.Add(1)
+		go func(i int, keys []interface{}) {
+			defer wg.Done()
+			result, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, []string{"item_id", "price"}, 1)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = result
+		}(i, keys)
+	}
+
+	wg.Wait()
+
+	if calls := backend.reads(); len(calls) != 1 || len(calls[0].keys) != 4 {
+		t.Fatalf("expect one call of the de-duplicated keys, %v", calls)
+	}
+	for i, keys := range requests {
+		var ids []string
+		for _, row := range results[i] {
+			ids = append(ids, row["item_id"].(string))
+		}
+		sort.Strings(ids)
+		var expected []string
+		for _, key := range keys {
+			expected = append(expected, key.(string))
+		}
+		sort.Strings(expected)
+		if len(ids) != len(expected) {
+			t.Fatalf("unexpected rows of %v, %v", keys, results[i])
+		}
+		for j := range ids {
+			if ids[j] != expected[j] {
+				t.Fatalf("unexpected rows of %v, %v", keys, results[i])
+			}
+		}
+	}
+
+	// the failed partial reads are coalesced too, every caller gets the failures of its own keys
+	backend.failing.Store(true)
+	calls := len(backend.reads())
+	failedKeys := make([][]interface{}, len(requests))
+	for i, keys := range requests {
+		wg.Add(1)
+		go func(i int, keys []interface{}) {
+			defer wg.Done()
+			partialResult, err := featureViewDao.GetFeaturesPartialWithContext(context.Background(), keys, []string{"item_id", "price"}, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			failedKeys[i] = partialResult.FailedKeys()
+		}(i, keys)
+	}
+	wg.Wait()
+	if len(backend.reads()) != calls+1 {
+		t.Fatalf("expect one call of the partial reads, %v", backend.reads()[calls:])
+	}
+	for i, keys := range requests {
+		if len(failedKeys[i]) != len(keys) {
+			t.Fatalf("expect the failures of %v, got %v", keys, failedKeys[i])
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := featureViewDao.GetFeaturesWithContext(ctx, []interface{}{"5"}, []string{"item_id", "price"}, 1); err != context.DeadlineExceeded {
+		t.Fatalf("expect deadline exceeded, err=%v", err)
+	}
+
+	columnarDao := newCoalescingFeatureViewDao(struct{ FeatureViewDao }{backend}, DaoConfig{PrimaryKeyField: "item_id", Coalesce: &CoalesceConfig{}})
+	if _, err := columnarDao.GetFeaturesBatchWithContext(context.Background(), []interface{}{"1"}, []string{"price"}, 1); err != ErrFeatureBatchNotSupported {
+		t.Fatalf("expect the columnar read not supported, err=%v", err)
+	}
+}
+
+func TestCoalescingDeadline(t *testing.T) {
+	backend := &fakeFeatureViewDao{}
+	featureViewDao := newCoalescingFeatureViewDao(backend, DaoConfig{
+		PrimaryKeyField: "item_id",
+		Tracer:          spanTracer{},
+		Coalesce:        &CoalesceConfig{Window: 20 * time.Millisecond, Timeout: 2 * time.Second},
+	})
+	read := func(ctxs ...context.Context) {
+		var wg sync.WaitGroup
+		for i, ctx := range ctxs {
+			wg.Add(1)
+			go func(i int, ctx context.Context) {
+				defer wg.Done()
+				if _, err := featureViewDao.GetFeaturesWithContext(ctx, []interface{}{i}, []string{"item_id"}, 1); err != nil {
+					t.Error(err)
+				}
+			}(i, ctx)
+		}
+		wg.Wait()
+	}
+
+	// the read runs until the latest deadline of the callers, in its own span
+	first, cancel := context.WithTimeout(context.WithValue(context.Background(), spanKey{}, "caller"), 100*time.Millisecond)
+	defer cancel()
+	latest, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	read(first, latest)
+	deadline, _ := latest.Deadline()
+	if calls := backend.reads(); len(calls) != 1 || !calls[0].deadline.Equal(deadline) {
+		t.Fatalf("expect the latest deadline %v, got %v", deadline, calls)
+	}
+	if span := backend.reads()[0].span; span != "FeatureViewDao.CoalescedGetFeatures" {
+		t.Fatalf("expect the span of the batch, got %v", span)
+	}
+
+	// a caller without a deadline keeps the read running for the timeout
+	start := time.Now()
+	read(context.Background())
+	if timeout := backend.reads()[1].deadline.Sub(start); timeout < 2*time.Second || timeout > 3*time.Second {
+		t.Fatalf("expect the deadline after the timeout, got %v", timeout)
+	}
+
+	// the callers overriding the ttl differently are not coalesced
+	read(WithTTL(context.Background(), time.Hour), WithTTL(context.Background(), time.Minute))
+	var ttls []time.Duration
+	for _, call := range backend.reads()[2:] {
+		ttls = append(ttls, call.ttl)
+	}
+	sort.Slice(ttls, func(i, j int) bool { return ttls[i] < ttls[j] })
+	if len(ttls) != 2 || ttls[0] != time.Minute || ttls[1] != time.Hour {
+		t.Fatalf("expect a read of each ttl, got %v", ttls)
+	}
+}
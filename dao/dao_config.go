@@ -1,15 +1,50 @@
 package dao
 
 import (
+	"fmt"
+
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 )
 
 type DaoConfig struct {
 	DatasourceType string
 
+	// Registry to get the datasource clients, default is datasource.DefaultRegistry()
+	Registry datasource.Registry
+
+	// ReadTracker to count the in-flight reads, optional
+	ReadTracker *ReadTracker
+
+	// Logger of the dao, default is logging.Default()
+	Logger logging.Logger
+
+	// Metrics collects the metrics of the online reads, optional
+	Metrics metrics.MetricsCollector
+
+	// Tracer opens the spans of the online reads, optional
+	Tracer tracing.Tracer
+
+	// Coalesce the concurrent GetFeatures reads, optional
+	Coalesce *CoalesceConfig
+
+	// CircuitBreakers fail the reads fast while the datasource or the feature view fails, optional
+	CircuitBreakers *CircuitBreakers
+	// CircuitBreakerName is the name of the circuit breaker of the feature view, default is ProjectName/FeatureViewName
+	CircuitBreakerName string
+
+	// ProjectName and FeatureViewName are added to the log lines and the metrics of the dao
+	ProjectName     string
+	FeatureViewName string
+
 	PrimaryKeyField string
-	EventTimeField  string
-	TTL             int
+	// EventTimeField and TTL in seconds drop the rows older than the TTL by their event time, see WithTTL
+	EventTimeField string
+	TTL            int
 
 	// hologres
 	HologresName      string
@@ -25,6 +60,10 @@ type DaoConfig struct {
 	LabelName         string
 	SaveOriginalField bool
 
+	// FieldTypes are the types of the fields by their name in the GetFeatures rows, the values are converted to
+	// the go types of the feature types when it is set, see NormalizeValue
+	FieldTypes map[string]constants.FSType
+
 	FieldMap map[string]string
 	// tablestore, featuredb
 	FieldTypeMap map[string]constants.FSType
@@ -46,4 +85,59 @@ type DaoConfig struct {
 	FeatureDBSchemaName   string
 	FeatureDBTableName    string
 	FeatureDBSignature    string
+	// FeatureDBClient is the FeatureDB client of the project, default is the process-wide one when Registry is not set
+	FeatureDBClient *featuredb.FeatureDBClient
+}
+
+func (c DaoConfig) datasourceRegistry() datasource.Registry {
+	if c.Registry == nil {
+		return datasource.DefaultRegistry()
+	}
+
+	return c.Registry
+}
+
+func (c DaoConfig) featureDBClient() (*featuredb.FeatureDBClient, error) {
+	if c.FeatureDBClient != nil {
+		return c.FeatureDBClient, nil
+	}
+
+	if c.Registry == nil {
+		return featuredb.GetFeatureDBClient()
+	}
+
+	return nil, fmt.Errorf("FeatureDB client is not set, feature view:%s", c.FeatureViewName)
+}
+
+// logger returns the logger of the dao with the feature view and the datasource type fields
+func (c DaoConfig) logger() logging.Logger {
+	logger := c.Logger
+	if logger == nil {
+		logger = logging.Default()
+	}
+
+	return logger.With(logging.F("feature_view", c.FeatureViewName), logging.F("datasource_type", c.DatasourceType))
+}
+
+// DatasourceName returns the type and the name of the datasource of the dao such as hologres/holo_ds,
+// featuredb has no name
+func (c DaoConfig) DatasourceName() string {
+	switch c.DatasourceType {
+	case constants.Datasource_Type_Hologres:
+		return c.DatasourceType + "/" + c.HologresName
+	case constants.Datasource_Type_TableStore:
+		return c.DatasourceType + "/" + c.TableStoreName
+	case constants.Datasource_Type_IGraph:
+		return c.DatasourceType + "/" + c.IGraphName
+	}
+
+	return c.DatasourceType
+}
+
+func (c DaoConfig) tracer() tracing.Tracer {
+	if c.Tracer == nil {
+		return tracing.Nop()
+	}
+
+	return c.Tracer
 }
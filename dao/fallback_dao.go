@@ -0,0 +1,156 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ServedByKey is the key of the name of the online store that served a row of a fallback dao, see NewFallbackFeatureViewDao
+const ServedByKey = "__served_by__"
+
+// FallbackPolicy decides when the keys of a read are read from the next online store of a fallback dao
+type FallbackPolicy struct {
+	// OnError reads the keys of a failed read or of its failed batches from the next online store,
+	// such as the reads rejected by an open circuit breaker
+	OnError bool
+	// Timeout reads the keys from the next online store when the read is not done in it, 0 waits for the read
+	Timeout time.Duration
+	// OnMissingKeys reads the keys missing in the rows from the next online store
+	OnMissingKeys bool
+}
+
+// fallbackFeatureViewDao reads the features from the daos of the online stores in order, the keys are read from the
+// next dao when the FallbackPolicy falls back. The rows are tagged with the name of the store under ServedByKey.
+// The reads other than GetFeatures are read from the first dao
+type fallbackFeatureViewDao struct {
+	FeatureViewDao
+	daos            []FeatureViewDao
+	names           []string
+	primaryKeyField string
+	policy          FallbackPolicy
+}
+
+// NewFallbackFeatureViewDao returns a dao reading the features from daos in order, names are the names of their
+// online stores, such as featuredb or hologres/holo_ds
+func NewFallbackFeatureViewDao(daos []FeatureViewDao, names []string, policy FallbackPolicy, config DaoConfig) FeatureViewDao {
+	return &fallbackFeatureViewDao{
+		FeatureViewDao:  daos[0],
+		daos:            daos,
+		names:           names,
+		primaryKeyField: config.PrimaryKeyField,
+		policy:          policy,
+	}
+}
+
+func (d *fallbackFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+
+// GetFeaturesWithContext returns the rows of the keys read from any store, it fails when a key could not be read by
+// any store, use GetFeaturesPartial to get the rows of the other keys
+func (d *fallbackFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	partialResult, err := d.GetFeaturesPartialWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		return nil, err
+	}
+	if err := partialResult.Err(); err != nil {
+		return nil, err
+	}
+
+	return partialResult.Rows, nil
+}
+
+// GetFeaturesPartialWithContext reports the keys no store could read as the failures of the last store that read them
+func (d *fallbackFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	result := &PartialResult{}
+	remaining := keys
+	for i, featureViewDao := range d.daos {
+		last := i == len(d.daos)-1
+		readCtx, cancel := ctx, context.CancelFunc(func() {})
+		if d.policy.Timeout > 0 && !last {
+			readCtx, cancel = context.WithTimeout(ctx, d.policy.Timeout)
+		}
+		partialResult, err := GetFeaturesPartial(readCtx, featureViewDao, remaining, selectFields, weight)
+		timedOut := readCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if last || !d.fallsBack(ctx, timedOut, err) {
+				result.Failures = append(result.Failures, &BatchError{Keys: remaining, Err: fmt.Errorf("%s: %w", d.names[i], err)})
+				return result, nil
+			}
+			continue
+		}
+
+		for _, row := range partialResult.Rows {
+			row[ServedByKey] = d.names[i]
+		}
+		result.Rows = append(result.Rows, partialResult.Rows...)
+
+		var next []interface{}
+		for _, failure := range partialResult.Failures {
+			if !last && d.fallsBack(ctx, timedOut, failure.Err) {
+				next = append(next, failure.Keys...)
+			} else {
+				result.Failures = append(result.Failures, &BatchError{Keys: failure.Keys, Err: fmt.Errorf("%s: %w", d.names[i], failure.Err)})
+			}
+		}
+		if d.policy.OnMissingKeys && !last {
+			next = append(next, d.missingKeys(remaining, partialResult)...)
+		}
+		if len(next) == 0 {
+			break
+		}
+		remaining = next
+	}
+
+	return result, nil
+}
+
+// fallsBack reports whether the error of a read falls back to the next store, timedOut is true when the read is
+// past the Timeout of the policy
+func (d *fallbackFeatureViewDao) fallsBack(ctx context.Context, timedOut bool, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if timedOut {
+		return true
+	}
+
+	return d.policy.OnError
+}
+
+// missingKeys returns the keys that are neither in the rows nor failed
+func (d *fallbackFeatureViewDao) missingKeys(keys []interface{}, partialResult *PartialResult) []interface{} {
+	seenKeys := make(map[string]bool, len(keys))
+	for _, row := range partialResult.Rows {
+		seenKeys[fmt.Sprint(row[d.primaryKeyField])] = true
+	}
+	for _, key := range partialResult.FailedKeys() {
+		seenKeys[fmt.Sprint(key)] = true
+	}
+
+	var missing []interface{}
+	for _, key := range keys {
+		if !seenKeys[fmt.Sprint(key)] {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// Close closes the daos of every store
+func (d *fallbackFeatureViewDao) Close() error {
+	var firstErr error
+	for _, featureViewDao := range d.daos {
+		if err := featureViewDao.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func servedBy(rows []map[string]interface{}) map[interface{}]interface{} {
+	stores := make(map[interface{}]interface{}, len(rows))
+	for _, row := range rows {
+		stores[row["item_id"]] = row[ServedByKey]
+	}
+	return stores
+}
+
+func TestFallbackFeatureViewDao(t *testing.T) {
+	config := DaoConfig{PrimaryKeyField: "item_id", FeatureViewName: "item_fea"}
+	names := []string{"featuredb", "hologres/holo"}
+	keys := []interface{}{"1", "2"}
+
+	// a failed read falls back on error only
+	failing := &fakeFeatureViewDao{}
+	failing.failing.Store(true)
+	backend := &fakeFeatureViewDao{}
+	featureViewDao := NewFallbackFeatureViewDao([]FeatureViewDao{failing, backend}, names, FallbackPolicy{OnError: true}, config)
+	rows, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1)
+	if err != nil || len(rows) != 2 || servedBy(rows)["1"] != "hologres/holo" || servedBy(rows)["2"] != "hologres/holo" {
+		t.Fatalf("expect the rows served by the fallback store, got %v %v", rows, err)
+	}
+	featureViewDao = NewFallbackFeatureViewDao([]FeatureViewDao{failing, backend}, names, FallbackPolicy{}, config)
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1); !errors.Is(err, errReadFailed) {
+		t.Fatalf("expect the read error without fallback, got %v", err)
+	}
+	if calls := backend.reads(); len(calls) != 1 {
+		t.Fatalf("expect one read of the fallback store, got %v", calls)
+	}
+
+	// only the missing keys are read from the next store
+	backend = &fakeFeatureViewDao{}
+	primary := &fakeFeatureViewDao{keys: map[interface{}]bool{"1": true}}
+	featureViewDao = NewFallbackFeatureViewDao([]FeatureViewDao{primary, backend}, names, FallbackPolicy{OnMissingKeys: true}, config)
+	rows, err = featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1)
+	if stores := servedBy(rows); err != nil || len(rows) != 2 || stores["1"] != "featuredb" || stores["2"] != "hologres/holo" {
+		t.Fatalf("expect the missing key served by the fallback store, got %v %v", rows, err)
+	}
+	if calls := backend.reads(); len(calls) != 1 || len(calls[0].keys) != 1 || calls[0].keys[0] != "2" {
+		t.Fatalf("expect the missing key read from the fallback store, got %v", calls)
+	}
+
+	// the read fails when a key failed in every store
+	featureViewDao = NewFallbackFeatureViewDao([]FeatureViewDao{primary, failing}, names, FallbackPolicy{OnMissingKeys: true}, config)
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, nil, 1); !errors.Is(err, errReadFailed) {
+		t.Fatalf("expect the read error of the missing key, got %v", err)
+	}
+
+	// a slow read falls back after the timeout
+	slow := &fakeFeatureViewDao{delay: time.Second}
+	featureViewDao = NewFallbackFeatureViewDao([]FeatureViewDao{slow, &fakeFeatureViewDao{}}, names, FallbackPolicy{Timeout: 20 * time.Millisecond}, config)
+	start := time.Now()
+	partialResult, err := GetFeaturesPartial(context.Background(), featureViewDao, keys, nil, 1)
+	if err != nil || len(partialResult.Rows) != 2 || len(partialResult.Failures) != 0 || servedBy(partialResult.Rows)["1"] != "hologres/holo" {
+		t.Fatalf("expect the rows served by the fallback store, got %+v %v", partialResult, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expect the read to fall back after the timeout, took %v", elapsed)
+	}
+
+	// the keys no store could read are the failures of the last store
+	featureViewDao = NewFallbackFeatureViewDao([]FeatureViewDao{failing, failing}, names, FallbackPolicy{OnError: true}, config)
+	partialResult, err = GetFeaturesPartial(context.Background(), featureViewDao, keys, nil, 1)
+	if err != nil || len(partialResult.FailedKeys()) != 2 || !errors.Is(partialResult.Err(), errReadFailed) {
+		t.Fatalf("expect the keys failed in every store, got %+v %v", partialResult, err)
+	}
+}
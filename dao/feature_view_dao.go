@@ -2,14 +2,16 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 	"github.com/expr-lang/expr/ast"
 	"github.com/expr-lang/expr/parser"
@@ -29,6 +31,29 @@ type FeatureViewDao interface {
 	RowCount(string) int
 	RowCountIds(string) ([]string, int, error)
 	ScanAndIterateData(filter string, ch chan<- string) ([]string, error)
+
+	// Close releases the resources held by the dao, the datasource clients are not closed
+	Close() error
+}
+
+// ErrFeatureBatchNotSupported is returned by GetFeaturesBatch when the dao has no columnar read
+var ErrFeatureBatchNotSupported = errors.New("the dao does not support the columnar read of the features")
+
+// FeatureBatchDao is implemented by the daos that decode the features into a columnar.FeatureBatch directly.
+// The batch has one row per key in the order of the keys, the features of the missing keys are null
+// and the primary key is not in it
+type FeatureBatchDao interface {
+	GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error)
+}
+
+// GetFeaturesBatch reads the features with the columnar read of the dao, see FeatureBatchDao
+func GetFeaturesBatch(ctx context.Context, featureViewDao FeatureViewDao, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	batchDao, ok := featureViewDao.(FeatureBatchDao)
+	if !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	return batchDao.GetFeaturesBatchWithContext(ctx, keys, selectFields, weight)
 }
 
 type UnimplementedFeatureViewDao struct {
@@ -69,8 +94,39 @@ func (d *UnimplementedFeatureViewDao) RowCountIds(string) ([]string, int, error)
 func (d *UnimplementedFeatureViewDao) ScanAndIterateData(filter string, ch chan<- string) ([]string, error) {
 	return nil, nil
 }
+func (d *UnimplementedFeatureViewDao) Close() error {
+	return nil
+}
 
 func NewFeatureViewDao(config DaoConfig) FeatureViewDao {
+	featureViewDao := newFeatureViewDao(config)
+	if config.FieldTypes != nil {
+		featureViewDao = newNormalizingFeatureViewDao(featureViewDao, config)
+	}
+	if config.CircuitBreakers != nil {
+		featureViewDao = newCircuitBreakerFeatureViewDao(featureViewDao, config)
+	}
+	if config.Coalesce != nil {
+		featureViewDao = newCoalescingFeatureViewDao(featureViewDao, config)
+	}
+	// hologres filters the expired rows in the query
+	if config.EventTimeField != "" && config.DatasourceType != constants.Datasource_Type_Hologres {
+		featureViewDao = newTTLFeatureViewDao(featureViewDao, config)
+	}
+	if config.Tracer != nil {
+		featureViewDao = newTracingFeatureViewDao(featureViewDao, config)
+	}
+	if config.Metrics != nil {
+		featureViewDao = newMetricsFeatureViewDao(featureViewDao, config)
+	}
+	if config.ReadTracker != nil {
+		return &trackedFeatureViewDao{FeatureViewDao: featureViewDao, tracker: config.ReadTracker}
+	}
+
+	return featureViewDao
+}
+
+func newFeatureViewDao(config DaoConfig) FeatureViewDao {
 	if config.DatasourceType == constants.Datasource_Type_Hologres {
 		return NewFeatureViewHologresDao(config)
 	} else if config.DatasourceType == constants.Datasource_Type_IGraph {
@@ -377,6 +433,6 @@ func walk(node ast.Node, variables map[string]struct{}) {
 		// Do nothing
 
 	default:
-		log.Printf("unhandled node type: %T\n", n)
+		logging.Default().Debug("unhandled node type", logging.F("type", fmt.Sprintf("%T", n)))
 	}
 }
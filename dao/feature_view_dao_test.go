@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
+
+var errReadFailed = errors.New("read failed")
+
+// fakeFeatureViewDao has a row of each key with 1.0 as the selected features, the event_time of the item 1 is now,
+// the one of the item 2 is an hour ago and the others have none. It records its reads
+type fakeFeatureViewDao struct {
+	UnimplementedFeatureViewDao
+	// keys are the keys having a row, all the keys have one when it is nil
+	keys map[interface{}]bool
+	// delay is waited before the rows are read
+	delay time.Duration
+	// failing fails the reads with errReadFailed
+	failing atomic.Bool
+
+	mu    sync.Mutex
+	calls []fakeCall
+}
+
+// fakeCall is a read of fakeFeatureViewDao with the deadline, the span and the ttl of its context
+type fakeCall struct {
+	keys     []interface{}
+	fields   []string
+	deadline time.Time
+	span     interface{}
+	ttl      time.Duration
+}
+
+func (d *fakeFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	d.mu.Lock()
+	d.calls = append(d.calls, fakeCall{keys: keys, fields: selectFields, deadline: deadline, span: ctx.Value(spanKey{}), ttl: ttlOf(ctx, 0)})
+	d.mu.Unlock()
+
+	if d.delay > 0 {
+		select {
+		case <-time.After(d.delay):
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if d.failing.Load() {
+		return nil, errReadFailed
+	}
+
+	eventTimes := map[interface{}]interface{}{"1": time.Now(), "2": time.Now().Add(-time.Hour).Unix()}
+	var result []map[string]interface{}
+	for _, key := range keys {
+		if d.keys != nil && !d.keys[key] {
+			continue
+		}
+		row := map[string]interface{}{"item_id": key}
+		for _, field := range selectFields {
+			if field == "event_time" {
+				row[field] = eventTimes[key]
+			} else if field != "item_id" {
+				row[field] = 1.0
+			}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+func (d *fakeFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	rows, err := d.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]constants.FSType, len(selectFields))
+	for i, field := range selectFields {
+		types[i] = constants.FS_DOUBLE
+		if field == "event_time" {
+			types[i] = constants.FS_TIMESTAMP
+			for _, row := range rows {
+				row[field], _ = EventTime(row[field])
+			}
+		}
+	}
+	return columnar.FromRows(rows, selectFields, types), nil
+}
+
+func (d *fakeFeatureViewDao) reads() []fakeCall {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]fakeCall(nil), d.calls...)
+}
+
+type spanKey struct{}
+
+// spanTracer puts the name of the span in the context
+type spanTracer struct{}
+
+func (spanTracer) Start(ctx context.Context, spanName string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	return tracing.Nop().Start(context.WithValue(ctx, spanKey{}, spanName), spanName, attrs...)
+}
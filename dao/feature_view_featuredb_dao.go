@@ -9,7 +9,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -20,9 +19,12 @@ import (
 	"github.com/aliyun/aliyun-odps-go-sdk/arrow/ipc"
 	"github.com/aliyun/aliyun-odps-go-sdk/arrow/memory"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb/fdbserverfb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
@@ -53,6 +55,13 @@ type FeatureViewFeatureDBDao struct {
 	fields          []string
 	signature       string
 	primaryKeyField string
+	logger          logging.Logger
+	tracer          tracing.Tracer
+
+	// closeChan stops the goroutines of ScanAndIterateData
+	closeChan chan struct{}
+	closeOnce sync.Once
+	scanWg    sync.WaitGroup
 }
 
 func SkipBaseTypeBytes(dataCursor *utils.ByteCursor, fieldType constants.FSType) {
@@ -83,8 +92,11 @@ func NewFeatureViewFeatureDBDao(config DaoConfig) *FeatureViewFeatureDBDao {
 		signature:       config.FeatureDBSignature,
 		primaryKeyField: config.PrimaryKeyField,
 		fields:          config.Fields,
+		logger:          config.logger(),
+		tracer:          config.tracer(),
+		closeChan:       make(chan struct{}),
 	}
-	client, err := featuredb.GetFeatureDBClient()
+	client, err := config.featureDBClient()
 	if err != nil {
 		return nil
 	}
@@ -95,486 +107,300 @@ func NewFeatureViewFeatureDBDao(config DaoConfig) *FeatureViewFeatureDBDao {
 }
 
 func (d *FeatureViewFeatureDBDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
-	result := make([]map[string]interface{}, 0, len(keys))
+	partialResult, err := d.GetFeaturesPartialWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		return []map[string]interface{}{}, err
+	}
+	for _, failure := range partialResult.Failures {
+		if err := d.tolerateStatusError(ctx, failure.Err); err != nil {
+			return nil, err
+		}
+	}
+
+	return partialResult.Rows, nil
+}
+
+// GetFeaturesPartialWithContext reads the groups of the keys concurrently, a failed group is reported with its keys
+func (d *FeatureViewFeatureDBDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
 	selectFieldsSet := make(map[string]struct{})
 	for _, selectField := range selectFields {
 		selectFieldsSet[selectField] = struct{}{}
 	}
+	if err := d.checkClient(); err != nil {
+		return nil, err
+	}
 
-	var wg sync.WaitGroup
+	result := &PartialResult{Rows: make([]map[string]interface{}, 0, len(keys))}
 	var mu sync.Mutex
-	groupSize := len(keys) / 4
-	groupSize = max(groupSize, 200)
-	groupSize = min(groupSize, 500)
-	if d.signature == "" {
-		return result, errors.New("FeatureStore DB username and password are not entered, please enter them by adding client.LoginFeatureStoreDB(username, password)")
+	d.forEachKeyGroup(keys, func(start int, ks []interface{}) error {
+		innerResult := make([]map[string]interface{}, 0, len(ks))
+		err := d.batchGetKV2(ctx, ks, weight, func(keyIdx int, dataCursor *utils.ByteCursor) error {
+			properties := make(map[string]interface{})
+			for _, field := range d.fields {
+				isNull, ok := dataCursor.TryReadUint8()
+				if !ok {
+					// EOF
+					break
+				}
+
+				if isNull == 1 {
+					// 跳过空值
+					continue
+				}
+				fieldType := d.fieldTypeMap[field]
+				if _, isSelected := selectFieldsSet[field]; isSelected {
+					properties[field] = readFeatureDBValue(dataCursor, fieldType)
+				} else {
+					skipFeatureDBValue(dataCursor, fieldType)
+				}
+				if dataCursor.Err != nil {
+					return dataCursor.Err
+				}
+			}
+			properties[d.primaryKeyField] = ks[keyIdx]
+			innerResult = append(innerResult, properties)
+
+			return nil
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Failures = append(result.Failures, &BatchError{Keys: ks, Err: err})
+		} else {
+			result.Rows = append(result.Rows, innerResult...)
+		}
+
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	if d.featureDBClient.GetCurrentAddress(false) == "" || d.featureDBClient.Token == "" {
-		return result, errors.New("FeatureDB datasource has not been created")
+
+	return result, nil
+}
+
+// tolerateStatusError logs the error of a failed response and returns nil, the keys of the response are missing
+// from the result. The error is still counted by the circuit breakers. The other errors are returned
+func (d *FeatureViewFeatureDBDao) tolerateStatusError(ctx context.Context, err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		logging.WithContext(d.logger, ctx).Error("featuredb request failed", logging.F("status_code", statusErr.StatusCode), logging.F("message", statusErr.Message))
+		recordStatusError(ctx, statusErr)
+		return nil
 	}
 
-	errChan := make(chan error, len(keys)/groupSize+1)
-	for i := 0; i < len(keys); i += groupSize {
-		end := i + groupSize
-		if end > len(keys) {
-			end = len(keys)
-		}
-		ks := keys[i:end]
-		wg.Add(1)
-		go func(ks []interface{}) {
-			defer wg.Done()
-			var pkeys []string
-			for _, k := range ks {
-				pkeys = append(pkeys, utils.ToString(k, ""))
-			}
-			body, _ := json.Marshal(map[string]any{"keys": pkeys})
-			url := fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kv2?batch_size=%d&encoder=", d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table, len(pkeys))
-			requestBody := readerPool.Get().(*bytes.Reader)
-			defer readerPool.Put(requestBody)
-			requestBody.Reset(body)
-			req, err := http.NewRequestWithContext(ctx, "POST", url, requestBody)
-			if err != nil {
-				errChan <- err
-				return
-			}
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Authorization", d.featureDBClient.Token)
-			req.Header.Set("Auth", d.signature)
-			req.Header.Set("X-FeatureView-Weight", strconv.Itoa(weight))
+	return err
+}
 
-			response, err := d.featureDBClient.Client.Do(req)
-			if err != nil {
-				if ctx.Err() != nil {
-					errChan <- ctx.Err()
-					return
-				}
+// GetFeaturesBatchWithContext reads the features of the keys into a batch of one row per key in the order of the keys,
+// the features of the missing keys are null. The values are decoded into the typed columns of the selected fields
+// directly, the primary key is not in the batch
+func (d *FeatureViewFeatureDBDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if err := d.checkClient(); err != nil {
+		return nil, err
+	}
 
-				url = fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kv2?batch_size=%d&encoder=", d.featureDBClient.GetCurrentAddress(true), d.database, d.schema, d.table, len(pkeys))
-				requestBody.Reset(body)
-				req, err = http.NewRequestWithContext(ctx, "POST", url, requestBody)
-				if err != nil {
-					errChan <- err
-					return
-				}
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Authorization", d.featureDBClient.Token)
-				req.Header.Set("Auth", d.signature)
-				req.Header.Set("X-FeatureView-Weight", strconv.Itoa(weight))
-				response, err = d.featureDBClient.Client.Do(req)
-				if err != nil {
-					errChan <- err
-					return
-				}
+	selectFieldsSet := make(map[string]struct{})
+	for _, selectField := range selectFields {
+		selectFieldsSet[selectField] = struct{}{}
+	}
+	var names []string
+	var types []constants.FSType
+	// fieldColumns is the column index of each of d.fields, -1 if it is not selected
+	fieldColumns := make([]int, len(d.fields))
+	for i, field := range d.fields {
+		fieldColumns[i] = -1
+		if _, isSelected := selectFieldsSet[field]; isSelected && field != d.primaryKeyField {
+			fieldColumns[i] = len(names)
+			names = append(names, field)
+			types = append(types, d.fieldTypeMap[field])
+		}
+	}
+
+	groupSize := featureDBGroupSize(len(keys))
+	batches := make([]*columnar.FeatureBatch, (len(keys)+groupSize-1)/groupSize)
+	err := d.forEachKeyGroup(keys, func(start int, ks []interface{}) error {
+		batch := columnar.NewFeatureBatch(names, types)
+		columns := batch.Columns()
+		err := d.batchGetKV2(ctx, ks, weight, func(keyIdx int, dataCursor *utils.ByteCursor) error {
+			for batch.NumRows() < keyIdx {
+				batch.EndRow()
 			}
-			defer response.Body.Close() // 确保关闭response.Body
-			// 检查状态码
-			if response.StatusCode != http.StatusOK {
-				bodyBytes, err := io.ReadAll(response.Body)
-				if err != nil {
-					errChan <- err
-					return
+			for i, field := range d.fields {
+				isNull, ok := dataCursor.TryReadUint8()
+				if !ok {
+					break
+				}
+				if isNull == 1 {
+					continue
 				}
 
-				var bodyMap map[string]interface{}
-				if err := json.Unmarshal(bodyBytes, &bodyMap); err == nil {
-					if msg, found := bodyMap["message"]; found {
-						log.Printf("StatusCode: %d, Response message: %s\n", response.StatusCode, msg)
+				if fieldColumns[i] >= 0 {
+					if err := appendFeatureDBValue(dataCursor, columns[fieldColumns[i]]); err != nil {
+						return err
 					}
+				} else {
+					skipFeatureDBValue(dataCursor, d.fieldTypeMap[field])
+				}
+				if dataCursor.Err != nil {
+					return dataCursor.Err
 				}
-				return
 			}
+			batch.EndRow()
 
-			reader := bufio.NewReader(response.Body)
-			headerBuf := make([]byte, 4)
-			keyStartIdx := 0
-			innerResult := make([]map[string]interface{}, 0, len(ks))
-			for {
-				buf, err := deserialize(reader, headerBuf)
-				if err == io.EOF {
-					break // End of stream
-				}
-				if err != nil {
-					if ctx.Err() != nil {
-						errChan <- ctx.Err()
-					} else {
-						errChan <- err
-					}
-					return
-				}
+			return nil
+		})
+		if err := d.tolerateStatusError(ctx, err); err != nil {
+			return err
+		}
 
-				recordBlock := fdbserverfb.GetRootAsRecordBlock(buf, 0)
+		for batch.NumRows() < len(ks) {
+			batch.EndRow()
+		}
+		batches[start/groupSize] = batch
 
-				for i := 0; i < recordBlock.ValuesLength(); i++ {
-					value := new(fdbserverfb.UInt8ValueColumn)
-					recordBlock.Values(value, i)
-					dataBytes := value.ValueBytes()
-					// key 不存在
-					if len(dataBytes) < 2 {
-						// fmt.Println("key ", ks[keyStartIdx+i], " not exists")
-						continue
-					}
-					dataCursor := utils.NewByteCursor(dataBytes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-					// 读取版本号
-					protocalVersion := dataCursor.ReadUint8()
-					ifNullFlagVersion := dataCursor.ReadUint8()
+	result := columnar.NewFeatureBatch(names, types)
+	for _, batch := range batches {
+		if err := result.Append(batch); err != nil {
+			return nil, err
+		}
+	}
 
-					readFeatureDBFunc_F_1 := func() (map[string]interface{}, error) {
-						properties := make(map[string]interface{})
+	return result, nil
+}
 
-						for _, field := range d.fields {
-							isNull, ok := dataCursor.TryReadUint8()
-							if !ok {
-								// EOF
-								break
-							}
+func (d *FeatureViewFeatureDBDao) checkClient() error {
+	if d.signature == "" {
+		return errors.New("FeatureStore DB username and password are not entered, please enter them by adding client.LoginFeatureStoreDB(username, password)")
+	}
+	if d.featureDBClient.GetCurrentAddress(false) == "" || d.featureDBClient.Token == "" {
+		return errors.New("FeatureDB datasource has not been created")
+	}
 
-							if isNull == 1 {
-								// 跳过空值
-								continue
-							}
-							fieldType := d.fieldTypeMap[field]
-							_, isSelected := selectFieldsSet[field]
-							if isSelected {
-								switch fieldType {
-								case constants.FS_DOUBLE:
-									properties[field] = dataCursor.ReadFloat64()
-								case constants.FS_FLOAT:
-									properties[field] = dataCursor.ReadFloat32()
-								case constants.FS_INT64:
-									properties[field] = dataCursor.ReadInt64()
-								case constants.FS_INT32:
-									properties[field] = dataCursor.ReadInt32()
-								case constants.FS_BOOLEAN:
-									properties[field] = dataCursor.ReadBool()
-								case constants.FS_STRING:
-									properties[field] = dataCursor.ReadString()
-								case constants.FS_TIMESTAMP:
-									properties[field] = time.UnixMilli(dataCursor.ReadInt64())
-								case constants.FS_ARRAY_INT32:
-									properties[field] = dataCursor.ReadInt32Slice(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_INT64:
-									properties[field] = dataCursor.ReadInt64Slice(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_FLOAT:
-									properties[field] = dataCursor.ReadFloat32Slice(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_DOUBLE:
-									properties[field] = dataCursor.ReadFloat64Slice(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_STRING:
-									properties[field] = dataCursor.ReadStringArray(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_ARRAY_FLOAT:
-									outerLength := dataCursor.ReadUint32()
-									if outerLength == 0 {
-										properties[field] = [][]float32{}
-									} else {
-										totalElements := dataCursor.ReadUint32()
-										if totalElements == 0 {
-											arr := make([][]float32, outerLength)
-											for k := uint32(0); k < outerLength; k++ {
-												arr[k] = []float32{}
-											}
-											properties[field] = arr
-										} else {
-											innerArrayLens := dataCursor.ReadUint32Slice(outerLength)
-											innerValidElements := dataCursor.ReadFloat32Slice(totalElements)
-											result := make([][]float32, outerLength)
-											innerIndex := 0
-											for outerIdx, innerLength := range innerArrayLens {
-												result[outerIdx] = innerValidElements[innerIndex : innerIndex+int(innerLength)]
-												innerIndex += int(innerLength)
-											}
-											properties[field] = result
-										}
-									}
-								case constants.FS_MAP_INT32_INT32:
-									length := dataCursor.ReadUint32()
-									mapInt32Int32Value := make(map[int32]int32, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt32Slice(length)
-										values := dataCursor.ReadInt32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt32Int32Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt32Int32Value
-								case constants.FS_MAP_INT32_INT64:
-									length := dataCursor.ReadUint32()
-									mapInt32Int64Value := make(map[int32]int64, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt32Slice(length)
-										values := dataCursor.ReadInt64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt32Int64Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt32Int64Value
-								case constants.FS_MAP_INT32_FLOAT:
-									length := dataCursor.ReadUint32()
-									mapInt32FloatValue := make(map[int32]float32, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt32Slice(length)
-										values := dataCursor.ReadFloat32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt32FloatValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt32FloatValue
-								case constants.FS_MAP_INT32_DOUBLE:
-									length := dataCursor.ReadUint32()
-									mapInt32DoubleValue := make(map[int32]float64, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt32Slice(length)
-										values := dataCursor.ReadFloat64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt32DoubleValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt32DoubleValue
-								case constants.FS_MAP_INT32_STRING:
-									length := dataCursor.ReadUint32()
-									mapInt32StringValue := make(map[int32]string, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt32Slice(length)
-										values := dataCursor.ReadStringArray(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt32StringValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt32StringValue
-								case constants.FS_MAP_INT64_INT32:
-									length := dataCursor.ReadUint32()
-									mapInt64Int32Value := make(map[int64]int32, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt64Slice(length)
-										values := dataCursor.ReadInt32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt64Int32Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt64Int32Value
-								case constants.FS_MAP_INT64_INT64:
-									length := dataCursor.ReadUint32()
-									mapInt64Int64Value := make(map[int64]int64, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt64Slice(length)
-										values := dataCursor.ReadInt64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt64Int64Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt64Int64Value
-								case constants.FS_MAP_INT64_FLOAT:
-									length := dataCursor.ReadUint32()
-									mapInt64FloatValue := make(map[int64]float32, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt64Slice(length)
-										values := dataCursor.ReadFloat32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt64FloatValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt64FloatValue
-								case constants.FS_MAP_INT64_DOUBLE:
-									length := dataCursor.ReadUint32()
-									mapInt64DoubleValue := make(map[int64]float64, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt64Slice(length)
-										values := dataCursor.ReadFloat64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt64DoubleValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt64DoubleValue
-								case constants.FS_MAP_INT64_STRING:
-									length := dataCursor.ReadUint32()
-									mapInt64StringValue := make(map[int64]string, length)
-									if length > 0 {
-										keys := dataCursor.ReadInt64Slice(length)
-										values := dataCursor.ReadStringArray(length)
-										for k := uint32(0); k < length; k++ {
-											mapInt64StringValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapInt64StringValue
-								case constants.FS_MAP_STRING_INT32:
-									length := dataCursor.ReadUint32()
-									mapStringInt32Value := make(map[string]int32, length)
-									if length > 0 {
-										keys := dataCursor.ReadStringArray(length)
-										values := dataCursor.ReadInt32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapStringInt32Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapStringInt32Value
-								case constants.FS_MAP_STRING_INT64:
-									length := dataCursor.ReadUint32()
-									mapStringInt64Value := make(map[string]int64, length)
-									if length > 0 {
-										keys := dataCursor.ReadStringArray(length)
-										values := dataCursor.ReadInt64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapStringInt64Value[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapStringInt64Value
-								case constants.FS_MAP_STRING_FLOAT:
-									length := dataCursor.ReadUint32()
-									mapStringFloatValue := make(map[string]float32, length)
-									if length > 0 {
-										keys := dataCursor.ReadStringArray(length)
-										values := dataCursor.ReadFloat32Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapStringFloatValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapStringFloatValue
-								case constants.FS_MAP_STRING_DOUBLE:
-									length := dataCursor.ReadUint32()
-									mapStringDoubleValue := make(map[string]float64, length)
-									if length > 0 {
-										keys := dataCursor.ReadStringArray(length)
-										values := dataCursor.ReadFloat64Slice(length)
-										for k := uint32(0); k < length; k++ {
-											mapStringDoubleValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapStringDoubleValue
-								case constants.FS_MAP_STRING_STRING:
-									length := dataCursor.ReadUint32()
-									mapStringStringValue := make(map[string]string, length)
-									if length > 0 {
-										keys := dataCursor.ReadStringArray(length)
-										values := dataCursor.ReadStringArray(length)
-										for k := uint32(0); k < length; k++ {
-											mapStringStringValue[keys[k]] = values[k]
-										}
-									}
-									properties[field] = mapStringStringValue
-								default:
-									properties[field] = dataCursor.ReadStringArray(dataCursor.ReadUint32())
-								}
-							} else {
-								switch fieldType {
-								case constants.FS_DOUBLE:
-									dataCursor.Skip(8)
-								case constants.FS_FLOAT:
-									dataCursor.Skip(4)
-								case constants.FS_INT64:
-									dataCursor.Skip(8)
-								case constants.FS_INT32:
-									dataCursor.Skip(4)
-								case constants.FS_BOOLEAN:
-									dataCursor.Skip(1)
-								case constants.FS_STRING:
-									dataCursor.Skip(int(dataCursor.ReadUint32()))
-								case constants.FS_TIMESTAMP:
-									dataCursor.Skip(8)
-								case constants.FS_ARRAY_INT32:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * 4)
-								case constants.FS_ARRAY_INT64:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * 8)
-								case constants.FS_ARRAY_FLOAT:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * 4)
-								case constants.FS_ARRAY_DOUBLE:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * 8)
-								case constants.FS_ARRAY_STRING:
-									dataCursor.SkipStringArray(dataCursor.ReadUint32())
-								case constants.FS_ARRAY_ARRAY_FLOAT:
-									outerLength := dataCursor.ReadUint32()
-									if outerLength > 0 {
-										totalElements := dataCursor.ReadUint32()
-										if totalElements > 0 {
-											dataCursor.Skip(int(outerLength*4 + totalElements*4))
-										}
-									}
-								case constants.FS_MAP_INT32_INT32:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 4))
-								case constants.FS_MAP_INT32_INT64:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 8))
-								case constants.FS_MAP_INT32_FLOAT:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 4))
-								case constants.FS_MAP_INT32_DOUBLE:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 8))
-								case constants.FS_MAP_INT32_STRING:
-									length := dataCursor.ReadUint32()
-									dataCursor.Skip(int(length * 4))
-									dataCursor.SkipStringArray(length)
-								case constants.FS_MAP_INT64_INT32:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 4))
-								case constants.FS_MAP_INT64_INT64:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 8))
-								case constants.FS_MAP_INT64_FLOAT:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 4))
-								case constants.FS_MAP_INT64_DOUBLE:
-									dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 8))
-								case constants.FS_MAP_INT64_STRING:
-									length := dataCursor.ReadUint32()
-									dataCursor.Skip(int(length * 8))
-									dataCursor.SkipStringArray(length)
-								case constants.FS_MAP_STRING_INT32:
-									length := dataCursor.ReadUint32()
-									dataCursor.SkipStringArray(length)
-									dataCursor.Skip(int(length * 4))
-								case constants.FS_MAP_STRING_INT64:
-									length := dataCursor.ReadUint32()
-									dataCursor.SkipStringArray(length)
-									dataCursor.Skip(int(length * 8))
-								case constants.FS_MAP_STRING_FLOAT:
-									length := dataCursor.ReadUint32()
-									dataCursor.SkipStringArray(length)
-									dataCursor.Skip(int(length * 4))
-								case constants.FS_MAP_STRING_DOUBLE:
-									length := dataCursor.ReadUint32()
-									dataCursor.SkipStringArray(length)
-									dataCursor.Skip(int(length * 8))
-								case constants.FS_MAP_STRING_STRING:
-									length := dataCursor.ReadUint32()
-									dataCursor.SkipStringArray(length)
-									dataCursor.SkipStringArray(length)
-								default:
-									dataCursor.Skip(int(dataCursor.ReadUint32()))
-								}
-							}
-							if dataCursor.Err != nil {
-								return nil, dataCursor.Err
-							}
-						}
-						properties[d.primaryKeyField] = ks[keyStartIdx+i]
+	return nil
+}
 
-						return properties, nil
-					}
+func featureDBGroupSize(keys int) int {
+	groupSize := keys / 4
+	groupSize = max(groupSize, 200)
+	groupSize = min(groupSize, 500)
 
-					if protocalVersion == FeatureDB_Protocal_Version_F && ifNullFlagVersion == FeatureDB_IfNull_Flag_Version_1 {
-						readResult, err := readFeatureDBFunc_F_1()
-						if err != nil {
-							errChan <- err
-							return
-						}
-						innerResult = append(innerResult, readResult)
-					} else {
-						errChan <- fmt.Errorf("FeatureDB read key %v error: protocalVersion %v or ifNullFlagVersion %d is not supported", ks[keyStartIdx+i], protocalVersion, ifNullFlagVersion)
-						fmt.Printf("FeatureDB read key %v error: protocalVersion %v or ifNullFlagVersion %d is not supported", ks[keyStartIdx+i], protocalVersion, ifNullFlagVersion)
-						return
-					}
-				}
-				keyStartIdx += recordBlock.ValuesLength()
-			}
-			mu.Lock()
-			result = append(result, innerResult...)
-			mu.Unlock()
-		}(ks)
+	return groupSize
+}
 
+// forEachKeyGroup calls fn with the groups of the keys concurrently, start is the index of the first key of the group
+func (d *FeatureViewFeatureDBDao) forEachKeyGroup(keys []interface{}, fn func(start int, ks []interface{}) error) error {
+	var wg sync.WaitGroup
+	groupSize := featureDBGroupSize(len(keys))
+	errChan := make(chan error, len(keys)/groupSize+1)
+	for i := 0; i < len(keys); i += groupSize {
+		end := i + groupSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		wg.Add(1)
+		go func(start int, ks []interface{}) {
+			defer wg.Done()
+			if err := fn(start, ks); err != nil {
+				errChan <- err
+			}
+		}(i, keys[i:end])
 	}
 	wg.Wait()
 	close(errChan)
 
 	for err := range errChan {
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return result, nil
+	return nil
+}
+
+// batchGetKV2 reads the values of the keys and calls onValue with the index of each found key and the cursor of
+// its field values. A failed response is a *StatusError
+func (d *FeatureViewFeatureDBDao) batchGetKV2(ctx context.Context, ks []interface{}, weight int, onValue func(keyIdx int, dataCursor *utils.ByteCursor) error) error {
+	ctx, span := d.tracer.Start(ctx, "FeatureDB.batch_get_kv2", tracing.String(tracing.AttrTable, d.table), tracing.Int(tracing.AttrKeyCount, len(ks)))
+	defer span.End()
+	var pkeys []string
+	for _, k := range ks {
+		pkeys = append(pkeys, utils.ToString(k, ""))
+	}
+	body, _ := json.Marshal(map[string]any{"keys": pkeys})
+	request := d.readRequest(fmt.Sprintf("/batch_get_kv2?batch_size=%d&encoder=", len(pkeys)), body)
+	request.Header.Set("X-FeatureView-Weight", strconv.Itoa(weight))
+	response, err := d.featureDBClient.Do(ctx, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() // 确保关闭response.Body
+	// 检查状态码
+	if response.StatusCode != http.StatusOK {
+		bodyBytes, err := io.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+
+		statusErr := &StatusError{StatusCode: response.StatusCode}
+		var bodyMap map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &bodyMap); err == nil {
+			if msg, found := bodyMap["message"]; found {
+				statusErr.Message = fmt.Sprint(msg)
+			}
+		}
+		return statusErr
+	}
+
+	reader := bufio.NewReader(response.Body)
+	headerBuf := make([]byte, 4)
+	keyStartIdx := 0
+	found := 0
+	for {
+		buf, err := deserialize(reader, headerBuf)
+		if err == io.EOF {
+			break // End of stream
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		recordBlock := fdbserverfb.GetRootAsRecordBlock(buf, 0)
+
+		for i := 0; i < recordBlock.ValuesLength(); i++ {
+			value := new(fdbserverfb.UInt8ValueColumn)
+			recordBlock.Values(value, i)
+			dataBytes := value.ValueBytes()
+			// key 不存在
+			if len(dataBytes) < 2 {
+				continue
+			}
+			dataCursor := utils.NewByteCursor(dataBytes)
+
+			// 读取版本号
+			if err := checkFeatureDBVersion(dataCursor, ks[keyStartIdx+i]); err != nil {
+				return err
+			}
+			if err := onValue(keyStartIdx+i, dataCursor); err != nil {
+				return err
+			}
+			found++
+		}
+		keyStartIdx += recordBlock.ValuesLength()
+	}
+	span.SetAttributes(tracing.Int(tracing.AttrResultCount, found))
+
+	return nil
 }
 
 type FeatureDBBatchGetKKVRequest struct {
@@ -631,38 +457,11 @@ func (d *FeatureViewFeatureDBDao) GetUserSequenceFeatureWithContext(ctx context.
 			request.SkipMerge = true
 		}
 		body, _ := json.Marshal(request)
-		url := fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table)
-		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		response, err := d.featureDBClient.Do(ctx, d.readRequest("/batch_get_kkv", body))
 		if err != nil {
 			errChan <- err
 			return nil
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", d.featureDBClient.Token)
-		req.Header.Set("Auth", d.signature)
-
-		response, err := d.featureDBClient.Client.Do(req)
-		if err != nil {
-			if ctx.Err() != nil {
-				errChan <- ctx.Err()
-				return nil
-			}
-			url = fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(true), d.database, d.schema, d.table)
-			req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-			if err != nil {
-				errChan <- err
-				return nil
-			}
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Authorization", d.featureDBClient.Token)
-			req.Header.Set("Auth", d.signature)
-			response, err = d.featureDBClient.Client.Do(req)
-
-			if err != nil {
-				errChan <- err
-				return nil
-			}
-		}
 		defer response.Body.Close() // 确保关闭response.Body
 		// 检查状态码
 		if response.StatusCode != http.StatusOK {
@@ -674,7 +473,7 @@ func (d *FeatureViewFeatureDBDao) GetUserSequenceFeatureWithContext(ctx context.
 			var bodyMap map[string]interface{}
 			if err := json.Unmarshal(bodyBytes, &bodyMap); err == nil {
 				if msg, found := bodyMap["message"]; found {
-					log.Printf("StatusCode: %d, Response message: %s\n", response.StatusCode, msg)
+					logging.WithContext(d.logger, ctx).Error("featuredb request failed", logging.F("status_code", response.StatusCode), logging.F("message", msg))
 				}
 			}
 			return nil
@@ -940,38 +739,11 @@ func (d *FeatureViewFeatureDBDao) GetUserAggregatedSequenceFeatureWithContext(ct
 			request.SkipMerge = true
 		}
 		body, _ := json.Marshal(request)
-		url := fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table)
-		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		response, err := d.featureDBClient.Do(ctx, d.readRequest("/batch_get_kkv", body))
 		if err != nil {
 			errChan <- err
 			return nil
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", d.featureDBClient.Token)
-		req.Header.Set("Auth", d.signature)
-
-		response, err := d.featureDBClient.Client.Do(req)
-		if err != nil {
-			if ctx.Err() != nil {
-				errChan <- ctx.Err()
-				return nil
-			}
-			url = fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(true), d.database, d.schema, d.table)
-			req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-			if err != nil {
-				errChan <- err
-				return nil
-			}
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Authorization", d.featureDBClient.Token)
-			req.Header.Set("Auth", d.signature)
-			response, err = d.featureDBClient.Client.Do(req)
-
-			if err != nil {
-				errChan <- err
-				return nil
-			}
-		}
 		defer response.Body.Close() // 确保关闭response.Body
 		// 检查状态码
 		if response.StatusCode != http.StatusOK {
@@ -983,7 +755,7 @@ func (d *FeatureViewFeatureDBDao) GetUserAggregatedSequenceFeatureWithContext(ct
 			var bodyMap map[string]interface{}
 			if err := json.Unmarshal(bodyBytes, &bodyMap); err == nil {
 				if msg, found := bodyMap["message"]; found {
-					log.Printf("StatusCode: %d, Response message: %s\n", response.StatusCode, msg)
+					logging.WithContext(d.logger, ctx).Error("featuredb request failed", logging.F("status_code", response.StatusCode), logging.F("message", msg))
 				}
 			}
 			return nil
@@ -1198,6 +970,7 @@ func (d *FeatureViewFeatureDBDao) GetUserBehaviorFeatureWithContext(ctx context.
 		results := []map[string]interface{}{}
 
 		var response *http.Response
+		var err error
 		if len(events) == 0 {
 			prefixs := []string{fmt.Sprintf("%v\u001D", user_id)}
 			request := FeatureDBScanKKVRequest{
@@ -1205,37 +978,11 @@ func (d *FeatureViewFeatureDBDao) GetUserBehaviorFeatureWithContext(ctx context.
 				WithValue: true,
 			}
 			body, _ := json.Marshal(request)
-			url := fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/scan_kkv", d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table)
-			req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+			response, err = d.featureDBClient.Do(ctx, d.readRequest("/scan_kkv", body))
 			if err != nil {
 				errChan <- err
 				return nil
 			}
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Authorization", d.featureDBClient.Token)
-			req.Header.Set("Auth", d.signature)
-
-			response, err = d.featureDBClient.Client.Do(req)
-			if err != nil {
-				if ctx.Err() != nil {
-					errChan <- ctx.Err()
-					return nil
-				}
-				url = fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/scan_kkv", d.featureDBClient.GetCurrentAddress(true), d.database, d.schema, d.table)
-				req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-				if err != nil {
-					errChan <- err
-					return nil
-				}
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Authorization", d.featureDBClient.Token)
-				req.Header.Set("Auth", d.signature)
-				response, err = d.featureDBClient.Client.Do(req)
-				if err != nil {
-					errChan <- err
-					return nil
-				}
-			}
 		} else {
 			pks := make([]string, 0, len(events))
 			for _, event := range events {
@@ -1246,37 +993,11 @@ func (d *FeatureViewFeatureDBDao) GetUserBehaviorFeatureWithContext(ctx context.
 				WithValue: true,
 			}
 			body, _ := json.Marshal(request)
-			url := fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table)
-			req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+			response, err = d.featureDBClient.Do(ctx, d.readRequest("/batch_get_kkv", body))
 			if err != nil {
 				errChan <- err
 				return nil
 			}
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Authorization", d.featureDBClient.Token)
-			req.Header.Set("Auth", d.signature)
-
-			response, err = d.featureDBClient.Client.Do(req)
-			if err != nil {
-				if ctx.Err() != nil {
-					errChan <- ctx.Err()
-					return nil
-				}
-				url = fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/batch_get_kkv", d.featureDBClient.GetCurrentAddress(true), d.database, d.schema, d.table)
-				req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-				if err != nil {
-					errChan <- err
-					return nil
-				}
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Authorization", d.featureDBClient.Token)
-				req.Header.Set("Auth", d.signature)
-				response, err = d.featureDBClient.Client.Do(req)
-				if err != nil {
-					errChan <- err
-					return nil
-				}
-			}
 		}
 
 		defer response.Body.Close() // 确保关闭response.Body
@@ -1291,7 +1012,7 @@ func (d *FeatureViewFeatureDBDao) GetUserBehaviorFeatureWithContext(ctx context.
 			var bodyMap map[string]interface{}
 			if err := json.Unmarshal(bodyBytes, &bodyMap); err == nil {
 				if msg, found := bodyMap["message"]; found {
-					log.Printf("StatusCode: %d, Response message: %s\n", response.StatusCode, msg)
+					logging.WithContext(d.logger, ctx).Error("featuredb request failed", logging.F("status_code", response.StatusCode), logging.F("message", msg))
 				}
 			}
 			return nil
@@ -1458,15 +1179,7 @@ func (d *FeatureViewFeatureDBDao) RowCountIds(filterExpr string) ([]string, int,
 	}
 
 	alloc := memory.NewGoAllocator()
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/snapshots/%s/scan",
-		d.featureDBClient.GetCurrentAddress(false), d.database, d.schema, d.table, snapshotId), bytes.NewReader(nil))
-	if err != nil {
-		return nil, 0, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", d.featureDBClient.Token)
-	req.Header.Set("Auth", d.signature)
-	response, err := d.featureDBClient.Client.Do(req)
+	response, err := d.featureDBClient.Do(context.Background(), d.newRequest("GET", fmt.Sprintf("/snapshots/%s/scan", snapshotId), nil))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -1841,20 +1554,14 @@ func (d *FeatureViewFeatureDBDao) RowCountIds(filterExpr string) ([]string, int,
 		}
 		record.Release()
 	}
-	log.Printf("RowCountIds size:%d, cost:%d\n", len(ids), time.Since(start)/time.Millisecond)
+	d.logger.Debug("row count ids", logging.F("size", len(ids)), logging.F("cost_ms", time.Since(start).Milliseconds()))
 	return ids, len(ids), nil
 }
 
 func (d *FeatureViewFeatureDBDao) createSnapshot() (string, int64, error) {
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/snapshots",
-		d.featureDBClient.GetNormalAddress(), d.database, d.schema, d.table), bytes.NewReader(nil))
-	if err != nil {
-		return "", 0, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", d.featureDBClient.Token)
-	req.Header.Set("Auth", d.signature)
-	response, err := d.featureDBClient.Client.Do(req)
+	request := d.newRequest("POST", "/snapshots", nil)
+	request.NormalAddress = true
+	response, err := d.featureDBClient.Do(context.Background(), request)
 	if err != nil {
 		return "", 0, err
 	}
@@ -1880,6 +1587,38 @@ func (d *FeatureViewFeatureDBDao) createSnapshot() (string, int64, error) {
 	return resonseBody.Data["snapshot_id"].(string), utils.ToInt64(resonseBody.Data["ts"], 0), nil
 }
 
+// newRequest returns a request of the table, path is relative to the table such as /batch_get_kkv
+func (d *FeatureViewFeatureDBDao) newRequest(method, path string, body []byte) *featuredb.Request {
+	return &featuredb.Request{
+		Method: method,
+		Path:   fmt.Sprintf("/api/v1/tables/%s/%s/%s%s", d.database, d.schema, d.table, path),
+		Body:   body,
+		Header: http.Header{"Auth": []string{d.signature}},
+		Tracer: d.tracer,
+	}
+}
+
+// readRequest returns a read of the table, the reads may be hedged
+func (d *FeatureViewFeatureDBDao) readRequest(path string, body []byte) *featuredb.Request {
+	request := d.newRequest("POST", path, body)
+	request.Hedge = true
+
+	return request
+}
+
+// Close stops the goroutines started by ScanAndIterateData and waits for them to exit
+func (d *FeatureViewFeatureDBDao) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.closeOnce.Do(func() {
+		close(d.closeChan)
+	})
+	d.scanWg.Wait()
+
+	return nil
+}
+
 func (d *FeatureViewFeatureDBDao) ScanAndIterateData(filter string, ch chan<- string) ([]string, error) {
 	_, ts, err := d.createSnapshot()
 	if err != nil {
@@ -1952,19 +1691,28 @@ func (d *FeatureViewFeatureDBDao) ScanAndIterateData(filter string, ch chan<- st
 		return properties, nil
 	}
 	if ch != nil {
+		d.scanWg.Add(1)
 		go func() {
+			defer d.scanWg.Done()
+			// send delivers the key unless the dao is closed
+			send := func(key string) bool {
+				select {
+				case ch <- key:
+					return true
+				case <-d.closeChan:
+					return false
+				}
+			}
 			alloc := memory.NewGoAllocator()
 			for {
-				time.Sleep(time.Second * 5)
-				req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/iterate_get_kv?ts=%d",
-					d.featureDBClient.GetNormalAddress(), d.database, d.schema, d.table, ts), bytes.NewReader(nil))
-				if err != nil {
-					continue
+				select {
+				case <-d.closeChan:
+					return
+				case <-time.After(time.Second * 5):
 				}
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Authorization", d.featureDBClient.Token)
-				req.Header.Set("Auth", d.signature)
-				response, err := d.featureDBClient.Client.Do(req)
+				request := d.newRequest("GET", fmt.Sprintf("/iterate_get_kv?ts=%d", ts), nil)
+				request.NormalAddress = true
+				response, err := d.featureDBClient.Do(context.Background(), request)
 				if err != nil {
 					continue
 				}
@@ -1979,11 +1727,12 @@ func (d *FeatureViewFeatureDBDao) ScanAndIterateData(filter string, ch chan<- st
 				reader, _ := ipc.NewReader(response.Body, ipc.WithAllocator(alloc))
 
 				innerReader := readerPool.Get().(*bytes.Reader)
-				for reader.Next() {
+				closed := false
+				for !closed && reader.Next() {
 					record := reader.Record()
-					for i := 0; i < int(record.NumRows()); i++ {
+					for i := 0; i < int(record.NumRows()) && !closed; i++ {
 						if filter == "" {
-							ch <- record.Column(0).(*array.String).Value(i)
+							closed = !send(record.Column(0).(*array.String).Value(i))
 						} else {
 							dataBytes := record.Column(1).(*array.Binary).Value(i)
 							if len(dataBytes) < 2 {
@@ -2002,7 +1751,7 @@ func (d *FeatureViewFeatureDBDao) ScanAndIterateData(filter string, ch chan<- st
 							if ret, err := expr.Run(program, properties); err != nil {
 								continue
 							} else if r, ok := ret.(bool); ok && r {
-								ch <- record.Column(0).(*array.String).Value(i)
+								closed = !send(record.Column(0).(*array.String).Value(i))
 							}
 						}
 					}
@@ -2011,7 +1760,9 @@ func (d *FeatureViewFeatureDBDao) ScanAndIterateData(filter string, ch chan<- st
 				}
 				readerPool.Put(innerReader)
 				response.Body.Close()
-
+				if closed {
+					return
+				}
 			}
 
 		}()
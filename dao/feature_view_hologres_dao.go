@@ -3,15 +3,15 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"hash/crc32"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
-	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/hologres"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/ast"
@@ -24,9 +24,10 @@ type FeatureViewHologresDao struct {
 	table           string
 	primaryKeyField string
 	eventTimeField  string
-	ttl             int
+	ttl             time.Duration
 	mu              sync.RWMutex
 	stmtMap         map[uint32]*sql.Stmt
+	logger          logging.Logger
 
 	offlineTable string
 	onlineTable  string
@@ -37,12 +38,13 @@ func NewFeatureViewHologresDao(config DaoConfig) *FeatureViewHologresDao {
 		table:           config.HologresTableName,
 		primaryKeyField: config.PrimaryKeyField,
 		eventTimeField:  config.EventTimeField,
-		ttl:             config.TTL,
+		ttl:             time.Duration(config.TTL) * time.Second,
+		logger:          config.logger(),
 		stmtMap:         make(map[uint32]*sql.Stmt, 4),
 		offlineTable:    config.HologresOfflineTableName,
 		onlineTable:     config.HologresOnlineTableName,
 	}
-	hologres, err := hologres.GetHologres(config.HologresName)
+	hologres, err := config.datasourceRegistry().GetHologres(config.HologresName)
 	if err != nil {
 		return nil
 	}
@@ -50,6 +52,26 @@ func NewFeatureViewHologresDao(config DaoConfig) *FeatureViewHologresDao {
 	dao.db = hologres.DB
 	return &dao
 }
+
+// Close closes the prepared statements
+func (d *FeatureViewHologresDao) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	for key, stmt := range d.stmtMap {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(d.stmtMap, key)
+	}
+
+	return errors.Join(errs...)
+}
+
 func (d *FeatureViewHologresDao) getStmt(key uint32) *sql.Stmt {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -65,9 +87,8 @@ func (d *FeatureViewHologresDao) GetFeaturesWithContext(ctx context.Context, key
 	builder.Select(selector...)
 	builder.From(d.table)
 	builder.Where(builder.In(fmt.Sprintf("\"%s\"", d.primaryKeyField), keys...))
-	if d.ttl > 0 {
-		t := time.Now().Add(time.Duration(-1 * d.ttl * int(time.Second)))
-		builder.Where(builder.GreaterEqualThan(fmt.Sprintf("\"%s\"", d.eventTimeField), t))
+	if ttl := ttlOf(ctx, d.ttl); ttl > 0 && d.eventTimeField != "" {
+		builder.Where(builder.GreaterEqualThan(fmt.Sprintf("\"%s\"", d.eventTimeField), time.Now().Add(-ttl)))
 	}
 
 	sql, args := builder.Build()
@@ -121,6 +142,8 @@ func (d *FeatureViewHologresDao) GetFeaturesWithContext(ctx context.Context, key
 	return result, nil
 }
 
+// GetUserSequenceFeatureWithContext reads the sequences of the keys, the keys of which a query failed are missing
+// from the result and are reported with a *PartialError returned along with the sequences of the other keys
 func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
 	var selectFields []string
 	if sequenceConfig.PlayTimeField == "" {
@@ -133,7 +156,7 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 	currTime := time.Now().Unix()
 	sequencePlayTimeMap := makePlayTimeMap(sequenceConfig.PlayTimeFilter)
 
-	onlineFunc := func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) []*sequenceInfo {
+	onlineFunc := func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) ([]*sequenceInfo, error) {
 		onlineSequences := []*sequenceInfo{}
 		builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
 		builder.Select(selectFields...)
@@ -159,8 +182,8 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 				stmt2, err := d.db.Prepare(sql)
 				if err != nil {
 					d.mu.Unlock()
-					log.Println(err)
-					return nil
+					logging.WithContext(d.logger, ctx).Error("prepare statement failed", logging.Err(err))
+					return nil, err
 				}
 				d.stmtMap[stmtKey] = stmt2
 				stmt = stmt2
@@ -171,8 +194,8 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 		}
 		rows, err := stmt.QueryContext(ctx, args...)
 		if err != nil {
-			log.Println(err)
-			return nil
+			logging.WithContext(d.logger, ctx).Error("query failed", logging.Err(err))
+			return nil, err
 		}
 		defer rows.Close()
 		for rows.Next() {
@@ -194,15 +217,15 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 				}
 				onlineSequences = append(onlineSequences, seq)
 			} else {
-				log.Println(err)
-				return nil
+				logging.WithContext(d.logger, ctx).Error("scan row failed", logging.Err(err))
+				return nil, err
 			}
 		}
 
-		return onlineSequences
+		return onlineSequences, nil
 	}
 
-	offlineFunc := func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) []*sequenceInfo {
+	offlineFunc := func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) ([]*sequenceInfo, error) {
 		offlineSequences := []*sequenceInfo{}
 		builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
 		builder.Select(selectFields...)
@@ -227,8 +250,8 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 				stmt2, err := d.db.Prepare(sql)
 				if err != nil {
 					d.mu.Unlock()
-					log.Println(err)
-					return nil
+					logging.WithContext(d.logger, ctx).Error("prepare statement failed", logging.Err(err))
+					return nil, err
 				}
 				d.stmtMap[stmtKey] = stmt2
 				stmt = stmt2
@@ -240,8 +263,8 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 
 		rows, err := stmt.QueryContext(ctx, args...)
 		if err != nil {
-			log.Println(err)
-			return nil
+			logging.WithContext(d.logger, ctx).Error("query failed", logging.Err(err))
+			return nil, err
 		}
 		defer rows.Close()
 		for rows.Next() {
@@ -263,16 +286,17 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 				}
 				offlineSequences = append(offlineSequences, seq)
 			} else {
-				log.Println(err)
-				return nil
+				logging.WithContext(d.logger, ctx).Error("scan row failed", logging.Err(err))
+				return nil, err
 			}
 		}
 
-		return offlineSequences
+		return offlineSequences, nil
 
 	}
 
 	results := make([]map[string]interface{}, 0, len(keys))
+	var failures []*BatchError
 	var outmu sync.Mutex
 
 	var wg sync.WaitGroup
@@ -282,6 +306,8 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 			defer wg.Done()
 			properties := make(map[string]interface{})
 			var mu sync.Mutex
+			var keyErr error
+			var errOnce sync.Once
 
 			var eventWg sync.WaitGroup
 			for _, seqConfig := range onlineConfig {
@@ -301,17 +327,23 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 					innerWg.Add(1)
 					go func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) {
 						defer innerWg.Done()
-						if onlineresult := onlineFunc(seqEvent, sequence_events, seqLen, key); onlineresult != nil {
-							onlineSequences = onlineresult
+						onlineresult, err := onlineFunc(seqEvent, sequence_events, seqLen, key)
+						if err != nil {
+							errOnce.Do(func() { keyErr = err })
+							return
 						}
+						onlineSequences = onlineresult
 					}(seqConfig.SeqEvent, sequence_events, seqConfig.SeqLen, key)
 					//get data from offline table
 					innerWg.Add(1)
 					go func(seqEvent string, sequence_events []interface{}, seqLen int, key interface{}) {
 						defer innerWg.Done()
-						if offlineresult := offlineFunc(seqEvent, sequence_events, seqLen, key); offlineresult != nil {
-							offlineSequences = offlineresult
+						offlineresult, err := offlineFunc(seqEvent, sequence_events, seqLen, key)
+						if err != nil {
+							errOnce.Do(func() { keyErr = err })
+							return
 						}
+						offlineSequences = offlineresult
 					}(seqConfig.SeqEvent, sequence_events, seqConfig.SeqLen, key)
 					innerWg.Wait()
 
@@ -324,6 +356,12 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 				}(seqConfig)
 			}
 			eventWg.Wait()
+			if keyErr != nil {
+				outmu.Lock()
+				failures = append(failures, &BatchError{Keys: []interface{}{key}, Err: keyErr})
+				outmu.Unlock()
+				return
+			}
 			properties[userIdField] = key
 			outmu.Lock()
 			results = append(results, properties)
@@ -333,7 +371,7 @@ func (d *FeatureViewHologresDao) GetUserSequenceFeatureWithContext(ctx context.C
 
 	wg.Wait()
 
-	return results, nil
+	return results, (&PartialResult{Failures: failures}).Err()
 
 }
 
@@ -366,7 +404,7 @@ func (d *FeatureViewHologresDao) GetUserBehaviorFeatureWithContext(ctx context.C
 				stmt2, err := d.db.Prepare(sql)
 				if err != nil {
 					d.mu.Unlock()
-					log.Println(err)
+					logging.WithContext(d.logger, ctx).Error("prepare statement failed", logging.Err(err))
 					return nil
 				}
 				d.stmtMap[stmtKey] = stmt2
@@ -378,7 +416,7 @@ func (d *FeatureViewHologresDao) GetUserBehaviorFeatureWithContext(ctx context.C
 		}
 		rows, err := stmt.QueryContext(ctx, args...)
 		if err != nil {
-			log.Println(err)
+			logging.WithContext(d.logger, ctx).Error("query failed", logging.Err(err))
 			return nil
 		}
 		defer rows.Close()
@@ -425,7 +463,7 @@ func (d *FeatureViewHologresDao) GetUserBehaviorFeatureWithContext(ctx context.C
 				stmt2, err := d.db.Prepare(sql)
 				if err != nil {
 					d.mu.Unlock()
-					log.Println(err)
+					logging.WithContext(d.logger, ctx).Error("prepare statement failed", logging.Err(err))
 					return nil
 				}
 				d.stmtMap[stmtKey] = stmt2
@@ -437,7 +475,7 @@ func (d *FeatureViewHologresDao) GetUserBehaviorFeatureWithContext(ctx context.C
 		}
 		rows, err := stmt.QueryContext(ctx, args...)
 		if err != nil {
-			log.Println(err)
+			logging.WithContext(d.logger, ctx).Error("query failed", logging.Err(err))
 			return nil
 		}
 		defer rows.Close()
@@ -489,7 +527,7 @@ func (d *FeatureViewHologresDao) GetUserBehaviorFeatureWithContext(ctx context.C
 			}(userId)
 			innerWg.Wait()
 			if offlineResult == nil || onlineResult == nil {
-				fmt.Println("get user behavior feature failed")
+				logging.WithContext(d.logger, ctx).Error("get user behavior feature failed", logging.F("user_id", userId))
 				return
 			}
 			combinedResult := combineBehaviorFeatures(offlineResult, onlineResult, sequenceConfig.TimestampField)
@@ -547,7 +585,7 @@ func (d *FeatureViewHologresDao) RowCount(filterExpr string) int {
 	if filterExpr != "" {
 		program, err := expr.Compile(filterExpr)
 		if err != nil {
-			fmt.Println(err)
+			d.logger.Error("compile filter failed", logging.F("filter", filterExpr), logging.Err(err))
 			return 0
 		}
 		node := program.Node()
@@ -559,14 +597,14 @@ func (d *FeatureViewHologresDao) RowCount(filterExpr string) int {
 	}
 
 	sql, args := builder.Build()
-	fmt.Println("row count sql:", sql)
+	d.logger.Debug("row count", logging.F("sql", sql))
 	var count int
 	retry := 3
 	for i := 0; i < retry; i++ {
 		row := d.db.QueryRow(sql, args...)
 		err := row.Scan(&count)
 		if i == retry-1 {
-			fmt.Println(err)
+			d.logger.Error("row count failed", logging.Err(err))
 			return 0
 		}
 		if err != nil {
@@ -596,7 +634,7 @@ func (d *FeatureViewHologresDao) RowCountIds(filterExpr string) ([]string, int,
 	}
 
 	sql, args := builder.Build()
-	fmt.Println("sql:", sql)
+	d.logger.Debug("row count ids", logging.F("sql", sql))
 	rows, err := d.db.Query(sql, args...)
 	if err != nil {
 		return nil, 0, err
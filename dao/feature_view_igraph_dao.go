@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"strings"
 	"sync"
@@ -13,7 +12,7 @@ import (
 	aligraph "github.com/aliyun/aliyun-igraph-go-sdk"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
-	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/igraph"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 )
 
@@ -23,11 +22,10 @@ type FeatureViewIGraphDao struct {
 	group           string
 	label           string
 	primaryKeyField string
-	eventTimeField  string
-	ttl             int
 	fieldMap        map[string]string
 	fieldTypeMap    map[string]constants.FSType
 	reverseFieldMap map[string]string
+	logger          logging.Logger
 
 	edgeName string
 }
@@ -37,14 +35,13 @@ func NewFeatureViewIGraphDao(config DaoConfig) *FeatureViewIGraphDao {
 		group:           config.GroupName,
 		label:           config.LabelName,
 		primaryKeyField: config.PrimaryKeyField,
-		eventTimeField:  config.EventTimeField,
-		ttl:             config.TTL,
 		fieldMap:        config.FieldMap, // igraph name => feature view schema name mapping
 		fieldTypeMap:    config.FieldTypeMap,
 		reverseFieldMap: make(map[string]string, len(config.FieldMap)), // revserse fieldMap kv, feature view schema name => igraph name mapping
 		edgeName:        config.IgraphEdgeName,
+		logger:          config.logger(),
 	}
-	client, err := igraph.GetGraphClient(config.IGraphName)
+	client, err := config.datasourceRegistry().GetGraphClient(config.IGraphName)
 	if err != nil {
 		return nil
 	}
@@ -92,14 +89,8 @@ func (d *FeatureViewIGraphDao) GetFeaturesWithContext(ctx context.Context, keys
 					continue
 				}
 
-				switch d.fieldTypeMap[field] {
-				case constants.FS_DOUBLE, constants.FS_FLOAT:
-					properties[d.fieldMap[field]] = utils.ToFloat(value, -1024)
-				case constants.FS_INT32, constants.FS_INT64:
-					properties[d.fieldMap[field]] = utils.ToInt(value, -1024)
-				default:
-					properties[d.fieldMap[field]] = value
-				}
+				// the values are converted to the feature types by the normalization of the dao, see DaoConfig.FieldTypes
+				properties[d.fieldMap[field]] = value
 			}
 
 			result = append(result, properties)
@@ -140,7 +131,7 @@ func (d *FeatureViewIGraphDao) GetUserSequenceFeatureWithContext(ctx context.Con
 		}
 		resp, err := d.igraphClient.Read(&request)
 		if err != nil {
-			log.Println(err)
+			logging.WithContext(d.logger, ctx).Error("igraph read failed", logging.Err(err))
 			return nil
 		}
 
@@ -247,7 +238,7 @@ func (d *FeatureViewIGraphDao) GetUserBehaviorFeatureWithContext(ctx context.Con
 		}
 		resp, err := d.igraphClient.Read(&request)
 		if err != nil {
-			log.Println(err)
+			logging.WithContext(d.logger, ctx).Error("igraph read failed", logging.Err(err))
 			return nil
 		}
 
@@ -2,9 +2,7 @@ package dao
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,7 +11,8 @@ import (
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
-	fstablestore "github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/tablestore"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 	"github.com/aliyun/aliyun-tablestore-go-sdk/tablestore"
 )
@@ -23,9 +22,9 @@ type FeatureViewTableStoreDao struct {
 	tablestoreClient *tablestore.TableStoreClient
 	table            string
 	primaryKeyField  string
-	eventTimeField   string
-	ttl              int
 	fieldTypeMap     map[string]constants.FSType
+	logger           logging.Logger
+	tracer           tracing.Tracer
 
 	offlineTable string
 	onlineTable  string
@@ -35,13 +34,13 @@ func NewFeatureViewTableStoreDao(config DaoConfig) *FeatureViewTableStoreDao {
 	dao := FeatureViewTableStoreDao{
 		table:           config.TableStoreTableName,
 		primaryKeyField: config.PrimaryKeyField,
-		eventTimeField:  config.EventTimeField,
-		ttl:             config.TTL,
 		fieldTypeMap:    config.FieldTypeMap,
+		logger:          config.logger(),
+		tracer:          config.tracer(),
 		offlineTable:    config.TableStoreOfflineTableName,
 		onlineTable:     config.TableStoreOnlineTableName,
 	}
-	client, err := fstablestore.GetTableStoreClient(config.TableStoreName)
+	client, err := config.datasourceRegistry().GetTableStoreClient(config.TableStoreName)
 	if err != nil {
 		return nil
 	}
@@ -52,11 +51,32 @@ func NewFeatureViewTableStoreDao(config DaoConfig) *FeatureViewTableStoreDao {
 }
 
 func (d *FeatureViewTableStoreDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	partialResult, err := d.GetFeaturesPartialWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		return nil, err
+	}
+
+	// the failed batches are dropped, GetFeaturesPartialWithContext reports them
+	for _, failure := range partialResult.Failures {
+		logging.WithContext(d.logger, ctx).Error("batch get row failed", logging.F("keys", len(failure.Keys)), logging.Err(failure.Err))
+	}
+
+	return partialResult.Rows, nil
+}
+
+// GetFeaturesPartialWithContext reads the keys in batches of 100 rows, a failed batch or row is reported with its keys
+func (d *FeatureViewTableStoreDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	result := make([]map[string]interface{}, 0, len(keys))
+	pkType := d.fieldTypeMap[d.primaryKeyField]
+	if pkType != constants.FS_INT64 && pkType != constants.FS_INT32 && pkType != constants.FS_STRING {
+		err := fmt.Errorf("primary key type %d is not supported by TableStore", pkType)
+		return &PartialResult{Failures: []*BatchError{{Keys: keys, Err: err}}}, nil
+	}
+
+	result := &PartialResult{Rows: make([]map[string]interface{}, 0, len(keys))}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
@@ -69,12 +89,14 @@ func (d *FeatureViewTableStoreDao) GetFeaturesWithContext(ctx context.Context, k
 		wg.Add(1)
 		go func(ks []interface{}) {
 			defer wg.Done()
+			ctx, span := d.tracer.Start(ctx, "TableStore.BatchGetRow", tracing.String(tracing.AttrTable, d.table), tracing.Int(tracing.AttrKeyCount, len(ks)))
+			defer span.End()
 			batchGetReq := &tablestore.BatchGetRowRequest{}
 			mqCriteria := &tablestore.MultiRowQueryCriteria{}
 
 			for _, key := range ks {
 				pkToGet := new(tablestore.PrimaryKey)
-				if d.fieldTypeMap[d.primaryKeyField] == constants.FS_INT64 || d.fieldTypeMap[d.primaryKeyField] == constants.FS_INT32 {
+				if pkType == constants.FS_INT64 || pkType == constants.FS_INT32 {
 					if v, ok := key.(int64); ok {
 						pkToGet.AddPrimaryKeyColumn(d.primaryKeyField, v)
 					} else {
@@ -82,11 +104,8 @@ func (d *FeatureViewTableStoreDao) GetFeaturesWithContext(ctx context.Context, k
 						i, _ := strconv.ParseInt(s, 10, 64)
 						pkToGet.AddPrimaryKeyColumn(d.primaryKeyField, i)
 					}
-				} else if d.fieldTypeMap[d.primaryKeyField] == constants.FS_STRING {
-					pkToGet.AddPrimaryKeyColumn(d.primaryKeyField, key)
 				} else {
-					log.Println(errors.New("primary key type is not supported by TableStore"))
-					return
+					pkToGet.AddPrimaryKeyColumn(d.primaryKeyField, key)
 				}
 				mqCriteria.AddRow(pkToGet)
 				mqCriteria.MaxVersion = 1
@@ -98,15 +117,24 @@ func (d *FeatureViewTableStoreDao) GetFeaturesWithContext(ctx context.Context, k
 			batchGetResponse, err := d.tablestoreClient.BatchGetRow(batchGetReq)
 
 			if err != nil {
-				log.Println(err)
+				span.RecordError(err)
+				mu.Lock()
+				result.Failures = append(result.Failures, &BatchError{Keys: ks, Err: err})
+				mu.Unlock()
 				return
 			}
 
+			var rows []map[string]interface{}
+			var failures []*BatchError
 			for _, rowResults := range batchGetResponse.TableToRowsResult {
 				for _, rowResult := range rowResults {
 					if rowResult.Error.Message != "" {
-						log.Println(errors.New(rowResult.Error.Message))
-						return
+						failedKeys := ks
+						if index := int(rowResult.Index); index >= 0 && index < len(ks) {
+							failedKeys = ks[index : index+1]
+						}
+						failures = append(failures, &BatchError{Keys: failedKeys, Err: &RowError{Code: rowResult.Error.Code, Message: rowResult.Error.Message}})
+						continue
 					}
 					if rowResult.PrimaryKey.PrimaryKeys == nil {
 						continue
@@ -120,18 +148,20 @@ func (d *FeatureViewTableStoreDao) GetFeaturesWithContext(ctx context.Context, k
 						if d.fieldTypeMap[rowValue.ColumnName] == constants.FS_TIMESTAMP {
 							timeVal, err := time.ParseInLocation("2006-01-02 15:04:05", val.(string), time.Local)
 							if err != nil {
-								log.Println(err)
+								logging.WithContext(d.logger, ctx).Error("parse timestamp failed", logging.F("field", rowValue.ColumnName), logging.Err(err))
 							} else {
 								val = timeVal
 							}
 						}
 						newMap[rowValue.ColumnName] = val
 					}
-					mu.Lock()
-					result = append(result, newMap)
-					mu.Unlock()
+					rows = append(rows, newMap)
 				}
 			}
+			mu.Lock()
+			result.Rows = append(result.Rows, rows...)
+			result.Failures = append(result.Failures, failures...)
+			mu.Unlock()
 		}(ks)
 	}
 	wg.Wait()
@@ -195,7 +225,7 @@ func (d *FeatureViewTableStoreDao) GetUserSequenceFeatureWithContext(ctx context
 
 				for {
 					if err != nil {
-						fmt.Println("get range failed with error:", err)
+						logging.WithContext(d.logger, ctx).Error("get range failed", logging.Err(err))
 					}
 					for _, row := range getRangeResp.Rows {
 						if row.PrimaryKey.PrimaryKeys == nil {
@@ -293,7 +323,7 @@ func (d *FeatureViewTableStoreDao) GetUserSequenceFeatureWithContext(ctx context
 
 				for {
 					if err != nil {
-						fmt.Println("get range failed with error:", err)
+						logging.WithContext(d.logger, ctx).Error("get range failed", logging.Err(err))
 					}
 					for _, row := range getRangeResp.Rows {
 						if row.PrimaryKey.PrimaryKeys == nil {
@@ -463,7 +493,7 @@ func (d *FeatureViewTableStoreDao) GetUserBehaviorFeatureWithContext(ctx context
 
 		for {
 			if err != nil {
-				fmt.Println("get range failed with error:", err)
+				logging.WithContext(d.logger, ctx).Error("get range failed", logging.Err(err))
 			}
 			for _, row := range getRangeResp.Rows {
 				if row.PrimaryKey.PrimaryKeys == nil {
@@ -520,7 +550,7 @@ func (d *FeatureViewTableStoreDao) GetUserBehaviorFeatureWithContext(ctx context
 		getRangeRequest.RangeRowQueryCriteria = rangeRowQueryCriteria
 		getRangeResp, err := d.tablestoreClient.GetRange(getRangeRequest)
 		if err != nil {
-			fmt.Println("get range failed with error:", err)
+			logging.WithContext(d.logger, ctx).Error("get range failed", logging.Err(err))
 			return nil
 		}
 
@@ -528,7 +558,7 @@ func (d *FeatureViewTableStoreDao) GetUserBehaviorFeatureWithContext(ctx context
 
 		for {
 			if err != nil {
-				fmt.Println("get range failed with error:", err)
+				logging.WithContext(d.logger, ctx).Error("get range failed", logging.Err(err))
 			}
 			for _, row := range getRangeResp.Rows {
 				if row.PrimaryKey.PrimaryKeys == nil {
@@ -584,7 +614,7 @@ func (d *FeatureViewTableStoreDao) GetUserBehaviorFeatureWithContext(ctx context
 				}()
 				innerWg.Wait()
 				if offlineResult == nil || onlineResult == nil {
-					fmt.Println("get user behavior feature failed")
+					logging.WithContext(d.logger, ctx).Error("get user behavior feature failed", logging.F("user_id", userId))
 					return
 				}
 				combinedResult := combineBehaviorFeatures(offlineResult, onlineResult, sequenceConfig.TimestampField)
@@ -619,7 +649,7 @@ func (d *FeatureViewTableStoreDao) GetUserBehaviorFeatureWithContext(ctx context
 						}()
 						innerWg.Wait()
 						if offlineResult == nil || onlineResult == nil {
-							fmt.Println("get user behavior feature failed")
+							logging.WithContext(d.logger, ctx).Error("get user behavior feature failed", logging.F("user_id", userId), logging.F("event", event))
 							return
 						}
 						combinedResult := combineBehaviorFeatures(offlineResult, onlineResult, sequenceConfig.TimestampField)
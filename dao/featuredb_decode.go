@@ -0,0 +1,250 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
+)
+
+// checkFeatureDBVersion reads the protocol version and the null flag version of a FeatureDB value
+func checkFeatureDBVersion(dataCursor *utils.ByteCursor, key interface{}) error {
+	protocalVersion := dataCursor.ReadUint8()
+	ifNullFlagVersion := dataCursor.ReadUint8()
+	if protocalVersion != FeatureDB_Protocal_Version_F || ifNullFlagVersion != FeatureDB_IfNull_Flag_Version_1 {
+		return fmt.Errorf("FeatureDB read key %v error: protocalVersion %v or ifNullFlagVersion %d is not supported", key, protocalVersion, ifNullFlagVersion)
+	}
+
+	return nil
+}
+
+// readFeatureDBValue reads a not null field value of the type
+func readFeatureDBValue(dataCursor *utils.ByteCursor, fieldType constants.FSType) interface{} {
+	switch fieldType {
+	case constants.FS_DOUBLE:
+		return dataCursor.ReadFloat64()
+	case constants.FS_FLOAT:
+		return dataCursor.ReadFloat32()
+	case constants.FS_INT64:
+		return dataCursor.ReadInt64()
+	case constants.FS_INT32:
+		return dataCursor.ReadInt32()
+	case constants.FS_BOOLEAN:
+		return dataCursor.ReadBool()
+	case constants.FS_STRING:
+		return dataCursor.ReadString()
+	case constants.FS_TIMESTAMP:
+		return time.UnixMilli(dataCursor.ReadInt64())
+	case constants.FS_ARRAY_INT32:
+		return dataCursor.ReadInt32Slice(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_INT64:
+		return dataCursor.ReadInt64Slice(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_FLOAT:
+		return dataCursor.ReadFloat32Slice(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_DOUBLE:
+		return dataCursor.ReadFloat64Slice(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_STRING:
+		return dataCursor.ReadStringArray(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_ARRAY_FLOAT:
+		return readFeatureDBArrayArrayFloat(dataCursor)
+	case constants.FS_MAP_INT32_INT32:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt32Slice, dataCursor.ReadInt32Slice)
+	case constants.FS_MAP_INT32_INT64:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt32Slice, dataCursor.ReadInt64Slice)
+	case constants.FS_MAP_INT32_FLOAT:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt32Slice, dataCursor.ReadFloat32Slice)
+	case constants.FS_MAP_INT32_DOUBLE:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt32Slice, dataCursor.ReadFloat64Slice)
+	case constants.FS_MAP_INT32_STRING:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt32Slice, dataCursor.ReadStringArray)
+	case constants.FS_MAP_INT64_INT32:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt64Slice, dataCursor.ReadInt32Slice)
+	case constants.FS_MAP_INT64_INT64:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt64Slice, dataCursor.ReadInt64Slice)
+	case constants.FS_MAP_INT64_FLOAT:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt64Slice, dataCursor.ReadFloat32Slice)
+	case constants.FS_MAP_INT64_DOUBLE:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt64Slice, dataCursor.ReadFloat64Slice)
+	case constants.FS_MAP_INT64_STRING:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadInt64Slice, dataCursor.ReadStringArray)
+	case constants.FS_MAP_STRING_INT32:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadStringArray, dataCursor.ReadInt32Slice)
+	case constants.FS_MAP_STRING_INT64:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadStringArray, dataCursor.ReadInt64Slice)
+	case constants.FS_MAP_STRING_FLOAT:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadStringArray, dataCursor.ReadFloat32Slice)
+	case constants.FS_MAP_STRING_DOUBLE:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadStringArray, dataCursor.ReadFloat64Slice)
+	case constants.FS_MAP_STRING_STRING:
+		return readFeatureDBMap(dataCursor.ReadUint32(), dataCursor.ReadStringArray, dataCursor.ReadStringArray)
+	default:
+		return dataCursor.ReadStringArray(dataCursor.ReadUint32())
+	}
+}
+
+// readFeatureDBMap reads the keys and then the values of a map of the length
+func readFeatureDBMap[K comparable, V any](length uint32, readKeys func(uint32) []K, readValues func(uint32) []V) map[K]V {
+	result := make(map[K]V, length)
+	if length > 0 {
+		keys := readKeys(length)
+		values := readValues(length)
+		for k := 0; k < len(keys) && k < len(values); k++ {
+			result[keys[k]] = values[k]
+		}
+	}
+
+	return result
+}
+
+func readFeatureDBArrayArrayFloat(dataCursor *utils.ByteCursor) [][]float32 {
+	outerLength := dataCursor.ReadUint32()
+	if outerLength == 0 {
+		return [][]float32{}
+	}
+
+	totalElements := dataCursor.ReadUint32()
+	if totalElements == 0 {
+		arr := make([][]float32, outerLength)
+		for k := uint32(0); k < outerLength; k++ {
+			arr[k] = []float32{}
+		}
+		return arr
+	}
+
+	innerArrayLens := dataCursor.ReadUint32Slice(outerLength)
+	innerValidElements := dataCursor.ReadFloat32Slice(totalElements)
+	result := make([][]float32, outerLength)
+	innerIndex := 0
+	for outerIdx, innerLength := range innerArrayLens {
+		if innerIndex+int(innerLength) > len(innerValidElements) {
+			break
+		}
+		result[outerIdx] = innerValidElements[innerIndex : innerIndex+int(innerLength)]
+		innerIndex += int(innerLength)
+	}
+
+	return result
+}
+
+// skipFeatureDBValue skips a not null field value of the type
+func skipFeatureDBValue(dataCursor *utils.ByteCursor, fieldType constants.FSType) {
+	switch fieldType {
+	case constants.FS_DOUBLE:
+		dataCursor.Skip(8)
+	case constants.FS_FLOAT:
+		dataCursor.Skip(4)
+	case constants.FS_INT64:
+		dataCursor.Skip(8)
+	case constants.FS_INT32:
+		dataCursor.Skip(4)
+	case constants.FS_BOOLEAN:
+		dataCursor.Skip(1)
+	case constants.FS_STRING:
+		dataCursor.Skip(int(dataCursor.ReadUint32()))
+	case constants.FS_TIMESTAMP:
+		dataCursor.Skip(8)
+	case constants.FS_ARRAY_INT32:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * 4)
+	case constants.FS_ARRAY_INT64:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * 8)
+	case constants.FS_ARRAY_FLOAT:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * 4)
+	case constants.FS_ARRAY_DOUBLE:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * 8)
+	case constants.FS_ARRAY_STRING:
+		dataCursor.SkipStringArray(dataCursor.ReadUint32())
+	case constants.FS_ARRAY_ARRAY_FLOAT:
+		outerLength := dataCursor.ReadUint32()
+		if outerLength > 0 {
+			totalElements := dataCursor.ReadUint32()
+			if totalElements > 0 {
+				dataCursor.Skip(int(outerLength*4 + totalElements*4))
+			}
+		}
+	case constants.FS_MAP_INT32_INT32:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 4))
+	case constants.FS_MAP_INT32_INT64:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 8))
+	case constants.FS_MAP_INT32_FLOAT:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 4))
+	case constants.FS_MAP_INT32_DOUBLE:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (4 + 8))
+	case constants.FS_MAP_INT32_STRING:
+		length := dataCursor.ReadUint32()
+		dataCursor.Skip(int(length * 4))
+		dataCursor.SkipStringArray(length)
+	case constants.FS_MAP_INT64_INT32:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 4))
+	case constants.FS_MAP_INT64_INT64:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 8))
+	case constants.FS_MAP_INT64_FLOAT:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 4))
+	case constants.FS_MAP_INT64_DOUBLE:
+		dataCursor.Skip(int(dataCursor.ReadUint32()) * (8 + 8))
+	case constants.FS_MAP_INT64_STRING:
+		length := dataCursor.ReadUint32()
+		dataCursor.Skip(int(length * 8))
+		dataCursor.SkipStringArray(length)
+	case constants.FS_MAP_STRING_INT32:
+		length := dataCursor.ReadUint32()
+		dataCursor.SkipStringArray(length)
+		dataCursor.Skip(int(length * 4))
+	case constants.FS_MAP_STRING_INT64:
+		length := dataCursor.ReadUint32()
+		dataCursor.SkipStringArray(length)
+		dataCursor.Skip(int(length * 8))
+	case constants.FS_MAP_STRING_FLOAT:
+		length := dataCursor.ReadUint32()
+		dataCursor.SkipStringArray(length)
+		dataCursor.Skip(int(length * 4))
+	case constants.FS_MAP_STRING_DOUBLE:
+		length := dataCursor.ReadUint32()
+		dataCursor.SkipStringArray(length)
+		dataCursor.Skip(int(length * 8))
+	case constants.FS_MAP_STRING_STRING:
+		length := dataCursor.ReadUint32()
+		dataCursor.SkipStringArray(length)
+		dataCursor.SkipStringArray(length)
+	default:
+		dataCursor.Skip(int(dataCursor.ReadUint32()))
+	}
+}
+
+// appendFeatureDBValue reads a not null field value into the column, the scalars and the arrays are
+// appended to the typed values as they are decoded
+func appendFeatureDBValue(dataCursor *utils.ByteCursor, column columnar.Column) error {
+	switch c := column.(type) {
+	case *columnar.TypedColumn[float64]:
+		c.Append(dataCursor.ReadFloat64())
+	case *columnar.TypedColumn[float32]:
+		c.Append(dataCursor.ReadFloat32())
+	case *columnar.TypedColumn[int64]:
+		c.Append(dataCursor.ReadInt64())
+	case *columnar.TypedColumn[int32]:
+		c.Append(dataCursor.ReadInt32())
+	case *columnar.TypedColumn[bool]:
+		c.Append(dataCursor.ReadBool())
+	case *columnar.TypedColumn[string]:
+		c.Append(dataCursor.ReadString())
+	case *columnar.TypedColumn[time.Time]:
+		c.Append(time.UnixMilli(dataCursor.ReadInt64()))
+	case *columnar.TypedColumn[[]int32]:
+		c.Append(dataCursor.ReadInt32Slice(dataCursor.ReadUint32()))
+	case *columnar.TypedColumn[[]int64]:
+		c.Append(dataCursor.ReadInt64Slice(dataCursor.ReadUint32()))
+	case *columnar.TypedColumn[[]float32]:
+		c.Append(dataCursor.ReadFloat32Slice(dataCursor.ReadUint32()))
+	case *columnar.TypedColumn[[]float64]:
+		c.Append(dataCursor.ReadFloat64Slice(dataCursor.ReadUint32()))
+	case *columnar.TypedColumn[[]string]:
+		c.Append(dataCursor.ReadStringArray(dataCursor.ReadUint32()))
+	case *columnar.TypedColumn[[][]float32]:
+		c.Append(readFeatureDBArrayArrayFloat(dataCursor))
+	default:
+		return column.AppendValue(readFeatureDBValue(dataCursor, column.Type()))
+	}
+
+	return nil
+}
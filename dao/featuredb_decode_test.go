@@ -0,0 +1,171 @@
+package dao
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb/fdbserverfb"
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// featureDBValue encodes a FeatureDB value of the F/1 protocol
+type featureDBValue struct {
+	buf []byte
+}
+
+func newFeatureDBValue() *featureDBValue {
+	return &featureDBValue{buf: []byte{FeatureDB_Protocal_Version_F, FeatureDB_IfNull_Flag_Version_1}}
+}
+
+func (v *featureDBValue) null() *featureDBValue {
+	v.buf = append(v.buf, 1)
+	return v
+}
+
+func (v *featureDBValue) int64(i int64) *featureDBValue {
+	v.buf = binary.LittleEndian.AppendUint64(append(v.buf, 0), uint64(i))
+	return v
+}
+
+func (v *featureDBValue) string(s string) *featureDBValue {
+	v.buf = binary.LittleEndian.AppendUint32(append(v.buf, 0), uint32(len(s)))
+	v.buf = append(v.buf, s...)
+	return v
+}
+
+func (v *featureDBValue) appendStringArray(strs []string) {
+	offset := uint32(0)
+	v.buf = binary.LittleEndian.AppendUint32(v.buf, offset)
+	for _, s := range strs {
+		offset += uint32(len(s))
+		v.buf = binary.LittleEndian.AppendUint32(v.buf, offset)
+	}
+	for _, s := range strs {
+		v.buf = append(v.buf, s...)
+	}
+}
+
+func (v *featureDBValue) stringArray(strs []string) *featureDBValue {
+	v.buf = binary.LittleEndian.AppendUint32(append(v.buf, 0), uint32(len(strs)))
+	v.appendStringArray(strs)
+	return v
+}
+
+func (v *featureDBValue) mapStringFloat(keys []string, values []float32) *featureDBValue {
+	v.buf = binary.LittleEndian.AppendUint32(append(v.buf, 0), uint32(len(keys)))
+	v.appendStringArray(keys)
+	for _, f := range values {
+		v.buf = binary.LittleEndian.AppendUint32(v.buf, math.Float32bits(f))
+	}
+	return v
+}
+
+// featureDBRecordBlock returns the length prefixed record block of the values, a nil value is a missing key
+func featureDBRecordBlock(values [][]byte) []byte {
+	builder := flatbuffers.NewBuilder(0)
+	columns := make([]flatbuffers.UOffsetT, len(values))
+	for i, value := range values {
+		data := builder.CreateByteVector(value)
+		fdbserverfb.UInt8ValueColumnStart(builder)
+		fdbserverfb.UInt8ValueColumnAddValue(builder, data)
+		columns[i] = fdbserverfb.UInt8ValueColumnEnd(builder)
+	}
+	fdbserverfb.RecordBlockStartValuesVector(builder, len(columns))
+	for i := len(columns) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(columns[i])
+	}
+	vector := builder.EndVector(len(columns))
+	fdbserverfb.RecordBlockStart(builder)
+	fdbserverfb.RecordBlockAddValues(builder, vector)
+	builder.Finish(fdbserverfb.RecordBlockEnd(builder))
+
+	block := builder.FinishedBytes()
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(block))), block...)
+}
+
+func TestFeatureDBGetFeaturesBatch(t *testing.T) {
+	body := featureDBRecordBlock([][]byte{
+		newFeatureDBValue().int64(30).string("hangzhou").stringArray([]string{"a", "bc"}).mapStringFloat([]string{"x"}, []float32{1.5}).buf,
+		nil,
+		newFeatureDBValue().null().string("beijing").null().null().buf,
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	registry := datasource.NewRegistry()
+	featureDBClient := registry.InitFeatureDBClient(server.URL, "token", "", false)
+	defer registry.Close()
+
+	featureViewDao := NewFeatureViewFeatureDBDao(DaoConfig{
+		Registry:           registry,
+		FeatureDBClient:    featureDBClient,
+		FeatureDBSignature: "signature",
+		PrimaryKeyField:    "user_id",
+		Fields:             []string{"age", "city", "tags", "scores"},
+		FieldTypeMap: map[string]constants.FSType{
+			"user_id": constants.FS_STRING,
+			"age":     constants.FS_INT64,
+			"city":    constants.FS_STRING,
+			"tags":    constants.FS_ARRAY_STRING,
+			"scores":  constants.FS_MAP_STRING_FLOAT,
+		},
+	})
+	keys := []interface{}{"1", "2", "3"}
+	selectFields := []string{"user_id", "age", "city", "tags", "scores"}
+
+	batch, err := GetFeaturesBatch(context.Background(), featureViewDao, keys, selectFields, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.NumRows() != 3 || len(batch.Columns()) != 4 {
+		t.Fatalf("expect 3 rows of 4 columns, got %d rows of %d columns", batch.NumRows(), len(batch.Columns()))
+	}
+
+	ages, ageColumn, ok := columnar.Values[int64](batch, "age")
+	if !ok || ages[0] != 30 || !ageColumn.IsNull(1) || !ageColumn.IsNull(2) {
+		t.Fatalf("unexpected age column %v", ages)
+	}
+	cities, cityColumn, _ := columnar.Values[string](batch, "city")
+	if cities[0] != "hangzhou" || !cityColumn.IsNull(1) || cities[2] != "beijing" {
+		t.Fatalf("unexpected city column %v", cities)
+	}
+	tags, _, _ := columnar.Values[[]string](batch, "tags")
+	if !reflect.DeepEqual(tags[0], []string{"a", "bc"}) || batch.Column("tags").NullCount() != 2 {
+		t.Fatalf("unexpected tags column %v", tags)
+	}
+	scores, _, _ := columnar.Values[map[string]float32](batch, "scores")
+	if scores[0]["x"] != 1.5 {
+		t.Fatalf("unexpected scores column %v", scores)
+	}
+
+	// the rows of the map read are the rows of the batch
+	rows, err := featureViewDao.GetFeaturesWithContext(context.Background(), keys, selectFields, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expect 2 rows, got %v", rows)
+	}
+	for _, row := range rows {
+		i := 0
+		if row["user_id"] == "3" {
+			i = 2
+		}
+		expected := batch.Row(i)
+		expected["user_id"] = keys[i]
+		if !reflect.DeepEqual(row, expected) {
+			t.Fatalf("expect row %v, got %v", expected, row)
+		}
+	}
+}
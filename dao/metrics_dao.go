@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+)
+
+// metricsFeatureViewDao records the metrics of the online reads of the dao
+type metricsFeatureViewDao struct {
+	FeatureViewDao
+	collector metrics.MetricsCollector
+	labels    metrics.Labels
+}
+
+func newMetricsFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *metricsFeatureViewDao {
+	return &metricsFeatureViewDao{
+		FeatureViewDao: featureViewDao,
+		collector:      config.Metrics,
+		labels: metrics.Labels{
+			Project:        config.ProjectName,
+			FeatureView:    config.FeatureViewName,
+			DatasourceType: config.DatasourceType,
+		},
+	}
+}
+
+func (d *metricsFeatureViewDao) observe(operation string, start time.Time, keys, hits int, err error) {
+	labels := d.labels
+	labels.Operation = operation
+	metrics.ObserveRead(d.collector, labels, start, keys, hits, err)
+}
+
+func (d *metricsFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+func (d *metricsFeatureViewDao) GetUserSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *metricsFeatureViewDao) GetUserAggregatedSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	return d.GetUserAggregatedSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *metricsFeatureViewDao) GetUserBehaviorFeature(userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserBehaviorFeatureWithContext(context.Background(), userIds, events, selectFields, sequenceConfig)
+}
+
+func (d *metricsFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	start := time.Now()
+	result, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	d.observe(metrics.OperationGetFeatures, start, len(keys), len(result), err)
+
+	return result, err
+}
+func (d *metricsFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if _, ok := d.FeatureViewDao.(FeatureBatchDao); !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	start := time.Now()
+	batch, err := GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	d.observe(metrics.OperationGetFeaturesBatch, start, len(keys), -1, err)
+
+	return batch, err
+}
+func (d *metricsFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	start := time.Now()
+	partialResult, err := GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	if err != nil {
+		d.observe(metrics.OperationGetFeatures, start, len(keys), 0, err)
+		return nil, err
+	}
+	d.observe(metrics.OperationGetFeatures, start, len(keys), len(partialResult.Rows), partialResult.Err())
+
+	return partialResult, nil
+}
+func (d *metricsFeatureViewDao) GetUserSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	start := time.Now()
+	result, err := d.FeatureViewDao.GetUserSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	d.observe(metrics.OperationGetUserSequenceFeature, start, len(keys), -1, err)
+
+	return result, err
+}
+func (d *metricsFeatureViewDao) GetUserAggregatedSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	start := time.Now()
+	result, err := d.FeatureViewDao.GetUserAggregatedSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	d.observe(metrics.OperationGetUserAggregatedSequenceFeature, start, len(keys), -1, err)
+
+	return result, err
+}
+func (d *metricsFeatureViewDao) GetUserBehaviorFeatureWithContext(ctx context.Context, userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	start := time.Now()
+	result, err := d.FeatureViewDao.GetUserBehaviorFeatureWithContext(ctx, userIds, events, selectFields, sequenceConfig)
+	d.observe(metrics.OperationGetUserBehaviorFeature, start, len(userIds), -1, err)
+
+	return result, err
+}
@@ -0,0 +1,274 @@
+package dao
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NormalizeValue converts a value read from an online store to the go type of the feature type, see columnar.GoType,
+// so the features are the same whichever store they are read from. The numbers, the booleans and the timestamps are
+// converted from the other go types and from strings, the integers are not converted from the floats with a fraction
+// or from the values out of the range of their type. A timestamp number is unix milliseconds and the timestamps are
+// in the local time zone. The arrays and the maps are converted by element from the slices and the maps of other
+// types, from json and from the array literals of Hologres such as {1,2,3}. It returns false when the value can not
+// be converted, the value of an unknown feature type is returned as it is
+func NormalizeValue(value interface{}, fsType constants.FSType) (interface{}, bool) {
+	goType := columnar.GoType(fsType)
+	if goType == nil {
+		return value, true
+	}
+
+	normalized, ok := normalizeTo(value, goType)
+	if !ok {
+		return nil, false
+	}
+
+	return normalized.Interface(), true
+}
+
+func normalizeTo(value interface{}, t reflect.Type) (reflect.Value, bool) {
+	if value == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(value)
+	if v.Type() == t && t != timeType {
+		return v, true
+	}
+
+	switch t.Kind() {
+	case reflect.Int32, reflect.Int64:
+		i, ok := toInt64(v)
+		if !ok || (t.Kind() == reflect.Int32 && (i < math.MinInt32 || i > math.MaxInt32)) {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(i).Convert(t), true
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat64(v)
+		return reflect.ValueOf(f).Convert(t), ok
+	case reflect.String:
+		switch {
+		case v.Kind() == reflect.String:
+			return v.Convert(t), true
+		case v.Kind() == reflect.Bool:
+			return reflect.ValueOf(strconv.FormatBool(v.Bool())), true
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			return reflect.ValueOf(string(v.Bytes())), true
+		case v.CanInt() || v.CanUint() || v.CanFloat():
+			s := utils.ToString(value, "")
+			if v.CanUint() {
+				s = strconv.FormatUint(v.Uint(), 10)
+			}
+			return reflect.ValueOf(s), true
+		}
+	case reflect.Bool:
+		switch {
+		case v.Kind() == reflect.Bool:
+			return v.Convert(t), true
+		case v.Kind() == reflect.String:
+			b, err := strconv.ParseBool(strings.TrimSpace(v.String()))
+			return reflect.ValueOf(b), err == nil
+		case v.CanInt() || v.CanUint() || v.CanFloat():
+			f, _ := toFloat64(v)
+			return reflect.ValueOf(f != 0), true
+		}
+	case reflect.Struct:
+		if t == timeType {
+			ts, ok := toTime(v)
+			return reflect.ValueOf(ts), ok
+		}
+	case reflect.Slice:
+		return normalizeSlice(v, t)
+	case reflect.Map:
+		return normalizeMap(v, t)
+	}
+
+	return reflect.Value{}, false
+}
+
+// toInt64 converts the integers, the integral floats and their strings, the values out of the range of int64 and
+// the floats with a fraction such as 1.9 are not converted
+func toInt64(v reflect.Value) (int64, bool) {
+	switch {
+	case v.CanInt():
+		return v.Int(), true
+	case v.CanUint():
+		return int64(v.Uint()), v.Uint() <= math.MaxInt64
+	case v.CanFloat():
+		return floatToInt64(v.Float())
+	case v.Kind() == reflect.String:
+		s := strings.TrimSpace(v.String())
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return floatToInt64(f)
+		}
+	}
+
+	return 0, false
+}
+
+func floatToInt64(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(f), true
+}
+
+func toFloat64(v reflect.Value) (float64, bool) {
+	switch {
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	case v.CanFloat():
+		return v.Float(), true
+	case v.Kind() == reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func toTime(v reflect.Value) (time.Time, bool) {
+	if ts, ok := v.Interface().(time.Time); ok {
+		return ts.Local(), true
+	}
+	if v.Kind() != reflect.String {
+		millis, ok := toInt64(v)
+		return time.UnixMilli(millis), ok
+	}
+
+	s := strings.TrimSpace(v.String())
+	if ts, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return ts, true
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts.Local(), true
+	}
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(millis), true
+	}
+
+	return time.Time{}, false
+}
+
+func normalizeSlice(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	if v.Kind() == reflect.String {
+		elems, ok := parseArray(v.String(), t.Elem().Kind() == reflect.Slice)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v = reflect.ValueOf(elems)
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, false
+	}
+
+	slice := reflect.MakeSlice(t, v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, ok := normalizeTo(v.Index(i).Interface(), t.Elem())
+		if !ok {
+			return reflect.Value{}, false
+		}
+		slice.Index(i).Set(elem)
+	}
+
+	return slice, true
+}
+
+func normalizeMap(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	if v.Kind() == reflect.String {
+		var m map[string]interface{}
+		if !unmarshalJSON(v.String(), &m) {
+			return reflect.Value{}, false
+		}
+		v = reflect.ValueOf(m)
+	}
+	if v.Kind() != reflect.Map {
+		return reflect.Value{}, false
+	}
+
+	m := reflect.MakeMapWithSize(t, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, ok := normalizeTo(iter.Key().Interface(), t.Key())
+		if !ok {
+			return reflect.Value{}, false
+		}
+		elem, ok := normalizeTo(iter.Value().Interface(), t.Elem())
+		if !ok {
+			return reflect.Value{}, false
+		}
+		m.SetMapIndex(key, elem)
+	}
+
+	return m, true
+}
+
+// parseArray parses a json array or an array literal of Hologres, nested is true for the arrays of arrays
+func parseArray(s string, nested bool) ([]interface{}, bool) {
+	s = strings.TrimSpace(s)
+	var elems []interface{}
+	switch {
+	case s == "":
+		return []interface{}{}, true
+	case strings.HasPrefix(s, "["):
+		return elems, unmarshalJSON(s, &elems)
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		if nested {
+			s = strings.NewReplacer("{", "[", "}", "]").Replace(s)
+			return elems, unmarshalJSON(s, &elems)
+		}
+		return splitArrayLiteral(s[1 : len(s)-1]), true
+	}
+
+	return nil, false
+}
+
+// splitArrayLiteral splits the elements of a one dimensional array literal such as 1,2,3 or a,"b,c"
+func splitArrayLiteral(s string) []interface{} {
+	elems := []interface{}{}
+	if strings.TrimSpace(s) == "" {
+		return elems
+	}
+
+	var b strings.Builder
+	quoted, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			elems = append(elems, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return append(elems, b.String())
+}
+
+func unmarshalJSON(s string, v interface{}) bool {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	return decoder.Decode(v) == nil
+}
@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	ts := time.UnixMilli(1700000000000)
+	tests := []struct {
+		fsType   constants.FSType
+		values   []interface{}
+		expected interface{}
+	}{
+		{constants.FS_INT64, []interface{}{int64(30), 30, int32(30), "30", json.Number("30")}, int64(30)},
+		{constants.FS_INT32, []interface{}{int32(7), int64(7), 7.0, "7"}, int32(7)},
+		{constants.FS_FLOAT, []interface{}{float32(1.5), 1.5, "1.5"}, float32(1.5)},
+		{constants.FS_DOUBLE, []interface{}{1.5, float32(1.5), "1.5"}, 1.5},
+		{constants.FS_STRING, []interface{}{"100", 100, int64(100), []byte("100")}, "100"},
+		{constants.FS_BOOLEAN, []interface{}{true, "t", "true", 1}, true},
+		{constants.FS_TIMESTAMP, []interface{}{ts, ts.UTC(), int64(1700000000000), ts.Format("2006-01-02 15:04:05"), ts.Format(time.RFC3339)}, ts},
+		{constants.FS_ARRAY_INT64, []interface{}{[]int64{1, 2}, []int32{1, 2}, []interface{}{1, "2"}, "{1,2}", "[1,2]"}, []int64{1, 2}},
+		{constants.FS_ARRAY_STRING, []interface{}{[]string{"a", "b,c"}, []interface{}{"a", "b,c"}, `{a,"b,c"}`, `["a","b,c"]`}, []string{"a", "b,c"}},
+		{constants.FS_ARRAY_ARRAY_FLOAT, []interface{}{[][]float32{{1}, {2, 3}}, [][]float64{{1}, {2, 3}}, "{{1},{2,3}}"}, [][]float32{{1}, {2, 3}}},
+		{constants.FS_MAP_STRING_FLOAT, []interface{}{map[string]float32{"x": 1.5}, map[string]float64{"x": 1.5}, `{"x":1.5}`}, map[string]float32{"x": 1.5}},
+		{constants.FS_MAP_INT64_STRING, []interface{}{map[int64]string{1: "a"}, map[string]interface{}{"1": "a"}, `{"1":"a"}`}, map[int64]string{1: "a"}},
+	}
+	for _, test := range tests {
+		for _, value := range test.values {
+			normalized, ok := NormalizeValue(value, test.fsType)
+			if !ok {
+				t.Fatalf("normalize %#v to type %d failed", value, test.fsType)
+			}
+			if expectedTime, isTime := test.expected.(time.Time); isTime {
+				if !normalized.(time.Time).Equal(expectedTime) || normalized.(time.Time).Location() != time.Local {
+					t.Fatalf("expect %v, got %v of %#v", expectedTime, normalized, value)
+				}
+			} else if !reflect.DeepEqual(normalized, test.expected) {
+				t.Fatalf("expect %#v, got %#v of %#v", test.expected, normalized, value)
+			}
+		}
+	}
+
+	for _, value := range []interface{}{"abc", []string{"1", "x"}, true} {
+		if _, ok := NormalizeValue(value, constants.FS_ARRAY_INT64); ok {
+			t.Fatalf("normalize %#v to FS_ARRAY_INT64 should fail", value)
+		}
+	}
+
+	// the integers are neither truncated nor overflowed
+	for _, value := range []interface{}{1.9, float32(-0.5), "1.9", int64(math.MaxInt32) + 1, "-2147483649", uint64(math.MaxUint64), []interface{}{1, 2.5}} {
+		fsType := constants.FS_INT32
+		if _, ok := value.([]interface{}); ok {
+			fsType = constants.FS_ARRAY_INT32
+		}
+		if _, ok := NormalizeValue(value, fsType); ok {
+			t.Fatalf("normalize %#v to type %d should fail", value, fsType)
+		}
+	}
+	for _, value := range []interface{}{1.9, "1.9", uint64(math.MaxUint64), 1e19} {
+		if _, ok := NormalizeValue(value, constants.FS_INT64); ok {
+			t.Fatalf("normalize %#v to FS_INT64 should fail", value)
+		}
+	}
+	if normalized, ok := NormalizeValue("2.0", constants.FS_INT64); !ok || normalized != int64(2) {
+		t.Fatalf("expect 2, got %#v", normalized)
+	}
+}
+
+func TestNormalizingFeatureViewDao(t *testing.T) {
+	featureViewDao := newNormalizingFeatureViewDao(&fakeFeatureViewDao{}, DaoConfig{
+		FieldTypes: map[string]constants.FSType{"item_id": constants.FS_INT64, "price": constants.FS_DOUBLE},
+	})
+
+	rows, err := featureViewDao.GetFeatures([]interface{}{"1", "2"}, []string{"item_id", "price"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if _, ok := row["item_id"].(int64); !ok || row["price"] != 1.0 {
+			t.Fatalf("row not normalized, %#v", row)
+		}
+	}
+
+	// a value that can not be normalized is missing
+	featureViewDao.fieldTypes["price"] = constants.FS_ARRAY_INT64
+	rows, err = featureViewDao.GetFeatures([]interface{}{"1"}, []string{"item_id", "price"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rows[0]["price"]; ok || rows[0]["item_id"] != int64(1) {
+		t.Fatalf("unexpected row, %#v", rows[0])
+	}
+}
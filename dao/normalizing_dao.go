@@ -0,0 +1,73 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+)
+
+// normalizingFeatureViewDao converts the values of the GetFeatures rows to the go types of the feature types, see
+// NormalizeValue. A value that can not be converted is dropped from its row as if the feature were missing
+type normalizingFeatureViewDao struct {
+	FeatureViewDao
+	fieldTypes map[string]constants.FSType
+	logger     logging.Logger
+}
+
+func newNormalizingFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *normalizingFeatureViewDao {
+	return &normalizingFeatureViewDao{
+		FeatureViewDao: featureViewDao,
+		fieldTypes:     config.FieldTypes,
+		logger:         config.logger(),
+	}
+}
+
+func (d *normalizingFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+
+func (d *normalizingFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	result, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		return result, err
+	}
+	d.normalize(ctx, result)
+
+	return result, nil
+}
+
+func (d *normalizingFeatureViewDao) normalize(ctx context.Context, rows []map[string]interface{}) {
+	for _, row := range rows {
+		for field, value := range row {
+			fsType, ok := d.fieldTypes[field]
+			if !ok || value == nil {
+				continue
+			}
+			if normalized, ok := NormalizeValue(value, fsType); ok {
+				row[field] = normalized
+			} else {
+				delete(row, field)
+				logging.WithContext(d.logger, ctx).Debug("drop the feature value of unexpected type", logging.F("field", field),
+					logging.F("fs_type", int(fsType)), logging.F("value_type", fmt.Sprintf("%T", value)))
+			}
+		}
+	}
+}
+
+func (d *normalizingFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	partialResult, err := GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	if err != nil {
+		return nil, err
+	}
+	d.normalize(ctx, partialResult.Rows)
+
+	return partialResult, nil
+}
+
+// GetFeaturesBatchWithContext reads the batch of the dao as it is, its columns are of the go types of the feature types
+func (d *normalizingFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	return GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+}
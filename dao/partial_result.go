@@ -0,0 +1,148 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// BatchError is the error of reading a batch of keys, the other batches of the read are not affected by it
+type BatchError struct {
+	Keys []interface{}
+	Err  error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("read %d keys failed: %v", len(e.Keys), e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is the error of a response of an online store with a failed status
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+type statusErrorsKey struct{}
+
+// statusErrors records the first status error an online store tolerates during a read, so the read is counted as
+// failed by the circuit breakers even though its keys are only missing
+type statusErrors struct {
+	err atomic.Pointer[StatusError]
+}
+
+func withStatusErrors(ctx context.Context) (context.Context, *statusErrors) {
+	errs := &statusErrors{}
+	return context.WithValue(ctx, statusErrorsKey{}, errs), errs
+}
+
+// recordStatusError records a status error tolerated by the read of the context
+func recordStatusError(ctx context.Context, err *StatusError) {
+	if errs, ok := ctx.Value(statusErrorsKey{}).(*statusErrors); ok {
+		errs.err.CompareAndSwap(nil, err)
+	}
+}
+
+// orErr returns err, or the recorded status error if err is nil
+func (e *statusErrors) orErr(err error) error {
+	if err != nil {
+		return err
+	}
+	if statusErr := e.err.Load(); statusErr != nil {
+		return statusErr
+	}
+
+	return nil
+}
+
+// RowError is the error an online store returns for the row of a key
+type RowError struct {
+	Code    string
+	Message string
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("read row failed, code: %s, message: %s", e.Code, e.Message)
+}
+
+// PartialResult is the result of a read of the keys in batches, the rows of the batches that succeeded and the
+// errors of the batches that failed
+type PartialResult struct {
+	Rows     []map[string]interface{}
+	Failures []*BatchError
+}
+
+// FailedKeys returns the keys of the failed batches
+func (r *PartialResult) FailedKeys() []interface{} {
+	var keys []interface{}
+	for _, failure := range r.Failures {
+		keys = append(keys, failure.Keys...)
+	}
+
+	return keys
+}
+
+// Err returns a *PartialError of the failed batches, nil if every batch succeeded
+func (r *PartialResult) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+
+	return &PartialError{Failures: r.Failures}
+}
+
+// PartialError is the error of a read of which some batches of keys failed, the rows of the other batches may be
+// returned with it
+type PartialError struct {
+	Failures []*BatchError
+}
+
+func (e *PartialError) Error() string {
+	keys := 0
+	for _, failure := range e.Failures {
+		keys += len(failure.Keys)
+	}
+
+	return fmt.Sprintf("%d batches of %d keys failed, first error: %v", len(e.Failures), keys, e.Failures[0].Err)
+}
+
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+
+	return errs
+}
+
+// PartialResultDao is implemented by the daos that read the keys in batches and report the batches that failed
+// instead of failing or dropping them. The error is returned when no key could be read at all, such as when the
+// client is not initialized
+type PartialResultDao interface {
+	GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error)
+}
+
+// GetFeaturesPartial reads the features with the partial read of the dao, see PartialResultDao. The keys of a dao
+// without it are one batch, its error fails all of them
+func GetFeaturesPartial(ctx context.Context, featureViewDao FeatureViewDao, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	if partialDao, ok := featureViewDao.(PartialResultDao); ok {
+		return partialDao.GetFeaturesPartialWithContext(ctx, keys, selectFields, weight)
+	}
+
+	rows, err := featureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return &PartialResult{Failures: []*BatchError{{Keys: keys, Err: err}}}, nil
+	}
+
+	return &PartialResult{Rows: rows}, nil
+}
@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+)
+
+func TestFeatureDBGetFeaturesPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"table is loading"}`))
+	}))
+	defer server.Close()
+
+	registry := datasource.NewRegistry()
+	featureDBClient := registry.InitFeatureDBClient(server.URL, "token", "", false)
+	defer registry.Close()
+
+	featureViewDao := NewFeatureViewFeatureDBDao(DaoConfig{
+		Registry:           registry,
+		FeatureDBClient:    featureDBClient,
+		FeatureDBSignature: "signature",
+		PrimaryKeyField:    "user_id",
+		Fields:             []string{"age"},
+		FieldTypeMap:       map[string]constants.FSType{"user_id": constants.FS_STRING, "age": constants.FS_INT64},
+	})
+	keys := []interface{}{"1", "2", "3"}
+
+	partialResult, err := GetFeaturesPartial(context.Background(), featureViewDao, keys, []string{"user_id", "age"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partialResult.Rows) != 0 || len(partialResult.FailedKeys()) != 3 {
+		t.Fatalf("expect the 3 keys failed, got %v", partialResult.Failures)
+	}
+	var statusErr *StatusError
+	if !errors.As(partialResult.Err(), &statusErr) || statusErr.StatusCode != http.StatusInternalServerError || statusErr.Message != "table is loading" {
+		t.Fatalf("expect status error, got %v", partialResult.Err())
+	}
+
+	// the failed response leaves the keys missing in GetFeatures
+	rows, err := featureViewDao.GetFeatures(keys, []string{"user_id", "age"}, 1)
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("expect no rows and no error, got %v %v", rows, err)
+	}
+}
+
+func TestGetFeaturesPartialFallback(t *testing.T) {
+	failing := &fakeFeatureViewDao{}
+	failing.failing.Store(true)
+	partialResult, err := GetFeaturesPartial(context.Background(), failing, []interface{}{"1", "2"}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partialResult.Failures) != 1 || len(partialResult.FailedKeys()) != 2 || !errors.Is(partialResult.Err(), errReadFailed) {
+		t.Fatalf("expect one failed batch of all keys, got %v", partialResult.Err())
+	}
+}
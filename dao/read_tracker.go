@@ -0,0 +1,173 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+)
+
+// ErrClosed is returned by the reads after the tracker is closed
+var ErrClosed = errors.New("featurestore client closed")
+
+// ReadTracker counts the in-flight reads of the DAOs created with it, so they can be drained before the clients are closed
+type ReadTracker struct {
+	parent *ReadTracker
+
+	count   atomic.Int64
+	closed  atomic.Bool
+	drained chan struct{}
+}
+
+func NewReadTracker() *ReadTracker {
+	return &ReadTracker{
+		drained: make(chan struct{}, 1),
+	}
+}
+
+// NewChild returns a tracker of a part of the reads of t, such as the ones of a project. The reads are counted by both
+// trackers and rejected once either is closed
+func (t *ReadTracker) NewChild() *ReadTracker {
+	child := NewReadTracker()
+	child.parent = t
+
+	return child
+}
+
+func (t *ReadTracker) begin() error {
+	if t.parent != nil {
+		if err := t.parent.begin(); err != nil {
+			return err
+		}
+	}
+
+	t.count.Add(1)
+	if t.closed.Load() {
+		t.end()
+		return ErrClosed
+	}
+
+	return nil
+}
+
+func (t *ReadTracker) end() {
+	if t.count.Add(-1) == 0 && t.closed.Load() {
+		select {
+		case t.drained <- struct{}{}:
+		default:
+		}
+	}
+	if t.parent != nil {
+		t.parent.end()
+	}
+}
+
+// Close rejects new reads and waits until the in-flight reads finish or ctx is done
+func (t *ReadTracker) Close(ctx context.Context) error {
+	t.closed.Store(true)
+
+	for t.count.Load() > 0 {
+		select {
+		case <-t.drained:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// trackedFeatureViewDao registers the reads of the dao to the tracker
+type trackedFeatureViewDao struct {
+	FeatureViewDao
+	tracker *ReadTracker
+}
+
+func (d *trackedFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+func (d *trackedFeatureViewDao) GetUserSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *trackedFeatureViewDao) GetUserAggregatedSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	return d.GetUserAggregatedSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *trackedFeatureViewDao) GetUserBehaviorFeature(userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserBehaviorFeatureWithContext(context.Background(), userIds, events, selectFields, sequenceConfig)
+}
+
+func (d *trackedFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+}
+func (d *trackedFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+}
+func (d *trackedFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+}
+func (d *trackedFeatureViewDao) GetUserSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.GetUserSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *trackedFeatureViewDao) GetUserAggregatedSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.GetUserAggregatedSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *trackedFeatureViewDao) GetUserBehaviorFeatureWithContext(ctx context.Context, userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.GetUserBehaviorFeatureWithContext(ctx, userIds, events, selectFields, sequenceConfig)
+}
+
+func (d *trackedFeatureViewDao) RowCount(filter string) int {
+	if err := d.tracker.begin(); err != nil {
+		return 0
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.RowCount(filter)
+}
+func (d *trackedFeatureViewDao) RowCountIds(filter string) ([]string, int, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, 0, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.RowCountIds(filter)
+}
+func (d *trackedFeatureViewDao) ScanAndIterateData(filter string, ch chan<- string) ([]string, error) {
+	if err := d.tracker.begin(); err != nil {
+		return nil, err
+	}
+	defer d.tracker.end()
+
+	return d.FeatureViewDao.ScanAndIterateData(filter, ch)
+}
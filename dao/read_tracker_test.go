@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadTrackerChild(t *testing.T) {
+	parent := NewReadTracker()
+	child := parent.NewChild()
+	featureViewDao := &trackedFeatureViewDao{FeatureViewDao: &fakeFeatureViewDao{delay: 50 * time.Millisecond}, tracker: child}
+
+	done := make(chan error)
+	go func() {
+		_, err := featureViewDao.GetFeaturesWithContext(context.Background(), []interface{}{"1"}, nil, 1)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if parent.count.Load() != 1 {
+		t.Fatalf("expect the read of the child counted by the parent, got %d", parent.count.Load())
+	}
+
+	// closing the child waits for its in-flight read and rejects the new ones, the parent keeps serving
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := child.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("expect the in-flight read finished, got %v", err)
+	}
+	if _, err := featureViewDao.GetFeaturesWithContext(context.Background(), []interface{}{"1"}, nil, 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expect closed error, got %v", err)
+	}
+	if parent.count.Load() != 0 || parent.begin() != nil {
+		t.Fatalf("expect the parent open without reads, got %d", parent.count.Load())
+	}
+	parent.end()
+
+	// closing the parent rejects the reads of its children
+	parent.Close(ctx)
+	if err := parent.NewChild().begin(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expect closed error, got %v", err)
+	}
+}
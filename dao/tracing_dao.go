@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
+
+// tracingFeatureViewDao opens a span for each online read of the dao
+type tracingFeatureViewDao struct {
+	FeatureViewDao
+	tracer tracing.Tracer
+	attrs  []tracing.Attribute
+}
+
+func newTracingFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *tracingFeatureViewDao {
+	return &tracingFeatureViewDao{
+		FeatureViewDao: featureViewDao,
+		tracer:         config.Tracer,
+		attrs: []tracing.Attribute{
+			tracing.String(tracing.AttrProject, config.ProjectName),
+			tracing.String(tracing.AttrFeatureView, config.FeatureViewName),
+			tracing.String(tracing.AttrDatasourceType, config.DatasourceType),
+		},
+	}
+}
+
+func (d *tracingFeatureViewDao) start(ctx context.Context, spanName string, keys int) (context.Context, tracing.Span) {
+	attrs := make([]tracing.Attribute, 0, len(d.attrs)+1)
+	attrs = append(attrs, d.attrs...)
+	attrs = append(attrs, tracing.Int(tracing.AttrKeyCount, keys))
+
+	return d.tracer.Start(ctx, spanName, attrs...)
+}
+
+func (d *tracingFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+func (d *tracingFeatureViewDao) GetUserSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *tracingFeatureViewDao) GetUserAggregatedSequenceFeature(keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	return d.GetUserAggregatedSequenceFeatureWithContext(context.Background(), keys, userIdField, sequenceConfig, onlineConfig)
+}
+func (d *tracingFeatureViewDao) GetUserBehaviorFeature(userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	return d.GetUserBehaviorFeatureWithContext(context.Background(), userIds, events, selectFields, sequenceConfig)
+}
+
+func (d *tracingFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	ctx, span := d.start(ctx, "FeatureViewDao.GetFeatures", len(keys))
+	result, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, weight)
+	span.SetAttributes(tracing.Int(tracing.AttrResultCount, len(result)))
+	tracing.End(span, err)
+
+	return result, err
+}
+func (d *tracingFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if _, ok := d.FeatureViewDao.(FeatureBatchDao); !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	ctx, span := d.start(ctx, "FeatureViewDao.GetFeaturesBatch", len(keys))
+	batch, err := GetFeaturesBatch(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	tracing.End(span, err)
+
+	return batch, err
+}
+func (d *tracingFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	ctx, span := d.start(ctx, "FeatureViewDao.GetFeatures", len(keys))
+	partialResult, err := GetFeaturesPartial(ctx, d.FeatureViewDao, keys, selectFields, weight)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.Int(tracing.AttrResultCount, len(partialResult.Rows)))
+	tracing.End(span, partialResult.Err())
+
+	return partialResult, nil
+}
+func (d *tracingFeatureViewDao) GetUserSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) ([]map[string]interface{}, error) {
+	ctx, span := d.start(ctx, "FeatureViewDao.GetUserSequenceFeature", len(keys))
+	result, err := d.FeatureViewDao.GetUserSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	tracing.End(span, err)
+
+	return result, err
+}
+func (d *tracingFeatureViewDao) GetUserAggregatedSequenceFeatureWithContext(ctx context.Context, keys []interface{}, userIdField string, sequenceConfig api.FeatureViewSeqConfig, onlineConfig []*api.SeqConfig) (map[string]interface{}, error) {
+	ctx, span := d.start(ctx, "FeatureViewDao.GetUserAggregatedSequenceFeature", len(keys))
+	result, err := d.FeatureViewDao.GetUserAggregatedSequenceFeatureWithContext(ctx, keys, userIdField, sequenceConfig, onlineConfig)
+	tracing.End(span, err)
+
+	return result, err
+}
+func (d *tracingFeatureViewDao) GetUserBehaviorFeatureWithContext(ctx context.Context, userIds []interface{}, events []interface{}, selectFields []string, sequenceConfig api.FeatureViewSeqConfig) ([]map[string]interface{}, error) {
+	ctx, span := d.start(ctx, "FeatureViewDao.GetUserBehaviorFeature", len(userIds))
+	result, err := d.FeatureViewDao.GetUserBehaviorFeatureWithContext(ctx, userIds, events, selectFields, sequenceConfig)
+	tracing.End(span, err)
+
+	return result, err
+}
@@ -0,0 +1,226 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+)
+
+type ttlKey struct{}
+
+// WithTTL returns a context overriding the ttl of the feature views read with it, such as a longer one to tolerate
+// the stale features during an incident. A ttl <= 0 returns the expired rows too
+func WithTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, ttlKey{}, ttl)
+}
+
+// ttlOf returns the ttl of a read, the ttl of the context overrides the one of the feature view
+func ttlOf(ctx context.Context, ttl time.Duration) time.Duration {
+	if override, ok := ctx.Value(ttlKey{}).(time.Duration); ok {
+		return override
+	}
+
+	return ttl
+}
+
+// ttlFeatureViewDao drops the rows older than the ttl by the value of the event time field, the same way for every
+// online store. The event time field is read even when it is not selected and removed from the rows then. The rows
+// without an event time cannot be expired, they are kept and logged
+type ttlFeatureViewDao struct {
+	FeatureViewDao
+	eventTimeField string
+	ttl            time.Duration
+	logger         logging.Logger
+}
+
+func newTTLFeatureViewDao(featureViewDao FeatureViewDao, config DaoConfig) *ttlFeatureViewDao {
+	return &ttlFeatureViewDao{
+		FeatureViewDao: featureViewDao,
+		eventTimeField: config.EventTimeField,
+		ttl:            time.Duration(config.TTL) * time.Second,
+		logger:         config.logger(),
+	}
+}
+
+// readFields returns the expire time of the read, zero if the rows do not expire, and the fields to read with the
+// event time field. added is true when the event time field is not selected
+func (d *ttlFeatureViewDao) readFields(ctx context.Context, selectFields []string) (expireTime time.Time, fields []string, added bool) {
+	ttl := ttlOf(ctx, d.ttl)
+	if ttl <= 0 {
+		return time.Time{}, selectFields, false
+	}
+
+	expireTime = time.Now().Add(-ttl)
+	for _, field := range selectFields {
+		if field == d.eventTimeField {
+			return expireTime, selectFields, false
+		}
+	}
+	fields = make([]string, len(selectFields), len(selectFields)+1)
+	copy(fields, selectFields)
+
+	return expireTime, append(fields, d.eventTimeField), true
+}
+
+// filterRows returns the rows not expired at expireTime
+func (d *ttlFeatureViewDao) filterRows(ctx context.Context, rows []map[string]interface{}, expireTime time.Time, added bool) []map[string]interface{} {
+	result := rows[:0]
+	missing := 0
+	for _, row := range rows {
+		eventTime, ok := EventTime(row[d.eventTimeField])
+		if !ok {
+			missing++
+		} else if eventTime.Before(expireTime) {
+			continue
+		}
+		if added {
+			delete(row, d.eventTimeField)
+		}
+		result = append(result, row)
+	}
+	d.warnMissing(ctx, missing)
+
+	return result
+}
+
+// warnMissing logs the rows kept without an event time, the ttl does not apply to them
+func (d *ttlFeatureViewDao) warnMissing(ctx context.Context, missing int) {
+	if missing > 0 {
+		logging.WithContext(d.logger, ctx).Warn("rows without event time are not expired by the ttl",
+			logging.F("event_time_field", d.eventTimeField), logging.F("rows", missing))
+	}
+}
+
+func (d *ttlFeatureViewDao) GetFeatures(keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	return d.GetFeaturesWithContext(context.Background(), keys, selectFields, weight)
+}
+
+func (d *ttlFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	expireTime, fields, added := d.readFields(ctx, selectFields)
+	rows, err := d.FeatureViewDao.GetFeaturesWithContext(ctx, keys, fields, weight)
+	if err != nil || expireTime.IsZero() {
+		return rows, err
+	}
+
+	return d.filterRows(ctx, rows, expireTime, added), nil
+}
+
+// GetFeaturesPartialWithContext drops the expired rows, their keys are missing and not failed
+func (d *ttlFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*PartialResult, error) {
+	expireTime, fields, added := d.readFields(ctx, selectFields)
+	partialResult, err := GetFeaturesPartial(ctx, d.FeatureViewDao, keys, fields, weight)
+	if err != nil || expireTime.IsZero() {
+		return partialResult, err
+	}
+	partialResult.Rows = d.filterRows(ctx, partialResult.Rows, expireTime, added)
+
+	return partialResult, nil
+}
+
+// GetFeaturesBatchWithContext sets the features of the expired rows to null
+func (d *ttlFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if _, ok := d.FeatureViewDao.(FeatureBatchDao); !ok {
+		return nil, ErrFeatureBatchNotSupported
+	}
+
+	expireTime, fields, added := d.readFields(ctx, selectFields)
+	batch, err := GetFeaturesBatch(ctx, d.FeatureViewDao, keys, fields, weight)
+	if err != nil || expireTime.IsZero() {
+		return batch, err
+	}
+
+	column := batch.Column(d.eventTimeField)
+	if column == nil {
+		d.warnMissing(ctx, batch.NumRows())
+		return batch, nil
+	}
+	indexes := make([]int, batch.NumRows())
+	missing := 0
+	for i := range indexes {
+		indexes[i] = i
+		if column.IsNull(i) {
+			// the rows of the keys not found are null too
+			if hasValue(batch, i) {
+				missing++
+			}
+			continue
+		}
+		if eventTime, ok := EventTime(column.Value(i)); !ok {
+			missing++
+		} else if eventTime.Before(expireTime) {
+			indexes[i] = -1
+		}
+	}
+	d.warnMissing(ctx, missing)
+	taken := batch.Take(indexes)
+	if !added {
+		return taken, nil
+	}
+
+	result := &columnar.FeatureBatch{}
+	for _, c := range taken.Columns() {
+		if c.Name() != d.eventTimeField {
+			result.AddColumn(c)
+		}
+	}
+
+	return result, nil
+}
+
+// hasValue reports whether a row of the batch has a non-null column
+func hasValue(batch *columnar.FeatureBatch, i int) bool {
+	for _, column := range batch.Columns() {
+		if !column.IsNull(i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventTime returns the time of an event time value, a time.Time, a unix timestamp in seconds or milliseconds, or a
+// string of one of them or of a time in the RFC3339 or the "2006-01-02 15:04:05" layout
+func EventTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, !v.IsZero()
+	case int64:
+		return unixTime(v), true
+	case int32:
+		return unixTime(int64(v)), true
+	case int:
+		return unixTime(int64(v)), true
+	case float64:
+		return unixTime(int64(v)), true
+	case float32:
+		return unixTime(int64(v)), true
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return unixTime(i), true
+		}
+		for _, layout := range []string{time.RFC3339, time.DateTime} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// unixTime returns the time of a unix timestamp, the ones past year 5138 in seconds are in milliseconds
+func unixTime(ts int64) time.Time {
+	if ts > 1e11 || ts < -1e11 {
+		return time.UnixMilli(ts)
+	}
+
+	return time.Unix(ts, 0)
+}
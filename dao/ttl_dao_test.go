@@ -0,0 +1,180 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/hologres"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+)
+
+func TestTTLFeatureViewDao(t *testing.T) {
+	backend := &fakeFeatureViewDao{}
+	var lines []string
+	logger := logging.New(logging.LevelWarn, func(level logging.Level, line string) { lines = append(lines, line) })
+	featureViewDao := newTTLFeatureViewDao(backend, DaoConfig{PrimaryKeyField: "item_id", EventTimeField: "event_time", TTL: 60, Logger: logger})
+	keys := []interface{}{"1", "2"}
+
+	// the row without an event time is kept and logged
+	rows, err := featureViewDao.GetFeaturesWithContext(context.Background(), []interface{}{"1", "3"}, []string{"item_id", "price"}, 1)
+	if err != nil || len(rows) != 2 || len(lines) != 1 || !strings.Contains(lines[0], "rows without event time") {
+		t.Fatalf("expect the row without event time kept and logged, got %v %v %v", rows, err, lines)
+	}
+
+	rows, err = featureViewDao.GetFeaturesWithContext(context.Background(), keys, []string{"item_id", "price"}, 1)
+	if err != nil || len(rows) != 1 || rows[0]["item_id"] != "1" {
+		t.Fatalf("expect the expired row dropped, got %v %v", rows, err)
+	}
+	if _, ok := rows[0]["event_time"]; ok || len(backend.reads()[1].fields) != 3 {
+		t.Fatalf("expect the event time read and removed, got %v %v", rows, backend.reads())
+	}
+	rows, _ = featureViewDao.GetFeaturesWithContext(context.Background(), keys, []string{"item_id", "event_time"}, 1)
+	if len(rows) != 1 || rows[0]["event_time"] == nil {
+		t.Fatalf("expect the selected event time kept, got %v", rows)
+	}
+
+	// the ttl of the context overrides the one of the feature view
+	partialResult, err := GetFeaturesPartial(WithTTL(context.Background(), 2*time.Hour), featureViewDao, keys, []string{"item_id", "price"}, 1)
+	if err != nil || len(partialResult.Rows) != 2 || len(partialResult.Failures) != 0 {
+		t.Fatalf("expect the rows within the longer ttl, got %+v %v", partialResult, err)
+	}
+	rows, _ = featureViewDao.GetFeaturesWithContext(WithTTL(context.Background(), -1), keys, []string{"item_id", "price"}, 1)
+	if len(rows) != 2 {
+		t.Fatalf("expect the expired rows without ttl, got %v", rows)
+	}
+
+	// the features of the expired rows of a batch are null
+	batch, err := GetFeaturesBatch(context.Background(), featureViewDao, keys, []string{"price"}, 1)
+	if err != nil || batch.NumRows() != 2 || batch.Column("event_time") != nil {
+		t.Fatalf("unexpected batch %v %v", batch, err)
+	}
+	if price := batch.Column("price"); price.IsNull(0) || !price.IsNull(1) {
+		t.Fatalf("expect the price of the expired row null, got %v", batch.Rows())
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)
+	for _, value := range []interface{}{want, want.Unix(), want.UnixMilli(), int32(want.Unix()), float64(want.Unix()), strconv.FormatInt(want.Unix(), 10),
+		want.Format(time.RFC3339), want.Format(time.DateTime)} {
+		if got, ok := EventTime(value); !ok || !got.Equal(want) {
+			t.Fatalf("event time of %v: expect %v, got %v %v", value, want, got, ok)
+		}
+	}
+	if _, ok := EventTime(nil); ok {
+		t.Fatalf("expect no event time of nil")
+	}
+}
+
+// recordingConnector records the queries of its connections, they return no rows
+type recordingConnector struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.Value
+}
+
+func (c *recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver {
+	return hologres.HologresDriver{}
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{connector: c.connector, query: query}, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type recordingStmt struct {
+	connector *recordingConnector
+	query     string
+}
+
+func (s *recordingStmt) Close() error {
+	return nil
+}
+
+func (s *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.connector.mu.Lock()
+	defer s.connector.mu.Unlock()
+	s.connector.queries = append(s.connector.queries, s.query)
+	s.connector.args = append(s.connector.args, args)
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return []string{"item_id"}
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+type hologresRegistry struct {
+	datasource.Registry
+	hologres *hologres.Hologres
+}
+
+func (r hologresRegistry) GetHologres(name string) (*hologres.Hologres, error) {
+	return r.hologres, nil
+}
+
+func TestHologresTTL(t *testing.T) {
+	connector := &recordingConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	config := DaoConfig{Registry: hologresRegistry{hologres: &hologres.Hologres{DB: db}}, HologresTableName: "item_fea",
+		PrimaryKeyField: "item_id", EventTimeField: "event_time", TTL: 60}
+	featureViewDao := NewFeatureViewHologresDao(config)
+
+	// the expired rows are filtered by the query, the ttl of the context overrides the one of the feature view
+	for _, ctx := range []context.Context{context.Background(), WithTTL(context.Background(), time.Hour), WithTTL(context.Background(), -1)} {
+		if _, err := featureViewDao.GetFeaturesWithContext(ctx, []interface{}{"1"}, []string{"item_id", "price"}, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(connector.queries) != 3 || !strings.Contains(connector.queries[0], `"event_time" >= $2`) || strings.Contains(connector.queries[2], "event_time") {
+		t.Fatalf("unexpected queries %v", connector.queries)
+	}
+	for i, ttl := range []time.Duration{time.Minute, time.Hour} {
+		expireTime, ok := connector.args[i][1].(time.Time)
+		if age := time.Since(expireTime); !ok || age < ttl || age > ttl+time.Minute {
+			t.Fatalf("expect the expire time %v ago, got %v", ttl, connector.args[i])
+		}
+	}
+}
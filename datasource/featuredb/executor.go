@@ -0,0 +1,349 @@
+package featuredb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
+
+// RetryConfig is the retry of the requests to FeatureDB. A request is retried after a transport error or a response
+// with a retryable status, the retries re-check the vpc address and wait for an exponential backoff with full jitter
+type RetryConfig struct {
+	// MaxRetries is the number of the retries after the first attempt, 0 disables the retry
+	MaxRetries int
+	// InitialBackoff is the maximum wait before the first retry, it doubles for each retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatusCodes are the status codes of the responses that are retried
+	RetryableStatusCodes []int
+}
+
+// HedgeConfig is the hedging of the reads from FeatureDB. When the response of a read is slower than the percentile
+// of the latency of the recent reads, a second request is sent and the first response of the two is used
+type HedgeConfig struct {
+	// Percentile of the latency after which a read is hedged, such as 0.95
+	Percentile float64
+	// MinDelay is the minimum delay before a read is hedged
+	MinDelay time.Duration
+	// MinSamples is the number of the reads whose latency is recorded before the reads are hedged, 100 if it is 0
+	MinSamples int
+}
+
+// ExecutorConfig is the config of the Executor of a FeatureDBClient
+type ExecutorConfig struct {
+	Retry RetryConfig
+	// Hedge is nil if the reads are not hedged
+	Hedge *HedgeConfig
+}
+
+// DefaultExecutorConfig retries once after 10ms at most, the responses of status 429 and 503 are retried
+func DefaultExecutorConfig() ExecutorConfig {
+	return ExecutorConfig{
+		Retry: RetryConfig{
+			MaxRetries:           1,
+			InitialBackoff:       10 * time.Millisecond,
+			MaxBackoff:           100 * time.Millisecond,
+			RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		},
+	}
+}
+
+// Request is a request to FeatureDB sent by an Executor, Path is relative to the address of the client
+type Request struct {
+	Method string
+	Path   string
+	Body   []byte
+	// Header is set on each attempt after the Content-Type and the Authorization of the client
+	Header http.Header
+	// Hedge is true for the reads that may be hedged, see HedgeConfig
+	Hedge bool
+	// NormalAddress is true for the requests that are always sent to the public address
+	NormalAddress bool
+	// Tracer opens a span of each attempt, nil opens none
+	Tracer tracing.Tracer
+}
+
+// Executor sends the requests to FeatureDB with the retry and the hedging of its config
+type Executor struct {
+	client  *FeatureDBClient
+	config  ExecutorConfig
+	latency *latencyTracker
+}
+
+// NewExecutor creates an Executor sending the requests with the client
+func NewExecutor(client *FeatureDBClient, config ExecutorConfig) *Executor {
+	executor := &Executor{
+		client: client,
+		config: config,
+	}
+	if config.Hedge != nil {
+		executor.latency = newLatencyTracker(config.Hedge.Percentile)
+	}
+
+	return executor
+}
+
+// Do sends the request and returns the response of the last attempt, the caller closes its body. The responses of a
+// retryable status are returned when no retry is left
+func (e *Executor) Do(ctx context.Context, request *Request) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= e.config.Retry.MaxRetries; attempt++ {
+		address := e.address(request, false)
+		if attempt > 0 {
+			if err := sleep(ctx, e.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			address = e.address(request, true)
+		}
+
+		response, err := e.send(ctx, request, address, attempt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		if attempt < e.config.Retry.MaxRetries && slices.Contains(e.config.Retry.RetryableStatusCodes, response.StatusCode) {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			continue
+		}
+
+		return response, nil
+	}
+
+	return nil, lastErr
+}
+
+func (e *Executor) address(request *Request, check bool) string {
+	if request.NormalAddress {
+		return e.client.GetNormalAddress()
+	}
+
+	return e.client.GetCurrentAddress(check)
+}
+
+func (e *Executor) backoff(attempt int) time.Duration {
+	backoff := e.config.Retry.InitialBackoff
+	for i := 1; i < attempt && backoff < e.config.Retry.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if e.config.Retry.MaxBackoff > 0 && backoff > e.config.Retry.MaxBackoff {
+		backoff = e.config.Retry.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	return rand.N(backoff + 1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type attemptResult struct {
+	response *http.Response
+	err      error
+	cancel   context.CancelFunc
+}
+
+// send sends one attempt of the request, a read is hedged when it is slower than the delay of the hedging
+func (e *Executor) send(ctx context.Context, request *Request, address string, attempt int) (*http.Response, error) {
+	delay, hedge := e.hedgeDelay(request)
+	if !hedge {
+		start := time.Now()
+		response, err := e.roundTrip(ctx, request, address, attempt, false)
+		if err == nil {
+			e.record(time.Since(start))
+		}
+		return response, err
+	}
+
+	results := make(chan attemptResult, 2)
+	start := time.Now()
+	launch := func(hedged bool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			response, err := e.roundTrip(attemptCtx, request, address, attempt, hedged)
+			results <- attemptResult{response: response, err: err, cancel: cancel}
+		}()
+	}
+	launch(false)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending := 1
+	var result attemptResult
+	select {
+	case result = <-results:
+		pending--
+	case <-timer.C:
+		launch(true)
+		pending++
+		result = <-results
+		pending--
+		if result.err != nil && ctx.Err() == nil {
+			result.cancel()
+			result = <-results
+			pending--
+		}
+	}
+	if pending > 0 {
+		go discard(results)
+	}
+	if result.err != nil {
+		result.cancel()
+		return nil, result.err
+	}
+	e.record(time.Since(start))
+	result.response.Body = &cancelOnClose{ReadCloser: result.response.Body, cancel: result.cancel}
+
+	return result.response, nil
+}
+
+// discard closes the response of the attempt that lost the hedging
+func discard(results chan attemptResult) {
+	result := <-results
+	result.cancel()
+	if result.err == nil {
+		result.response.Body.Close()
+	}
+}
+
+// roundTrip sends the request to the address in a span of the attempt, the parent span is taken from ctx
+func (e *Executor) roundTrip(ctx context.Context, request *Request, address string, attempt int, hedged bool) (*http.Response, error) {
+	var body io.Reader
+	if request.Body != nil {
+		body = bytes.NewReader(request.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, address+request.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", e.client.Token)
+	for key, values := range request.Header {
+		req.Header[key] = values
+	}
+
+	tracer := request.Tracer
+	if tracer == nil {
+		tracer = tracing.Nop()
+	}
+	ctx, span := tracer.Start(ctx, "FeatureDB.request", tracing.String(tracing.AttrServerAddress, req.URL.Host),
+		tracing.Int(tracing.AttrAttempt, attempt), tracing.Bool(tracing.AttrHedged, hedged))
+	response, err := e.client.Client.Do(req.WithContext(ctx))
+	if err == nil {
+		span.SetAttributes(tracing.Int(tracing.AttrHTTPStatusCode, response.StatusCode))
+	}
+	tracing.End(span, err)
+
+	return response, err
+}
+
+func (e *Executor) hedgeDelay(request *Request) (time.Duration, bool) {
+	if e.latency == nil || !request.Hedge {
+		return 0, false
+	}
+	minSamples := e.config.Hedge.MinSamples
+	if minSamples <= 0 {
+		minSamples = 100
+	}
+	delay, ok := e.latency.percentile(minSamples)
+	if !ok {
+		return 0, false
+	}
+
+	return max(delay, e.config.Hedge.MinDelay), true
+}
+
+func (e *Executor) record(latency time.Duration) {
+	if e.latency != nil {
+		e.latency.add(latency)
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+
+	return err
+}
+
+// latencyTrackerSize is the number of the recent latencies of which the percentile is computed
+const latencyTrackerSize = 1000
+
+// latencyTracker keeps the recent latencies, the percentile is computed again every 100 latencies
+type latencyTracker struct {
+	percentileRank float64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	count     int
+
+	value atomic.Int64
+}
+
+func newLatencyTracker(percentile float64) *latencyTracker {
+	return &latencyTracker{
+		percentileRank: min(max(percentile, 0), 1),
+		latencies:      make([]time.Duration, 0, latencyTrackerSize),
+	}
+}
+
+func (t *latencyTracker) add(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.latencies) < latencyTrackerSize {
+		t.latencies = append(t.latencies, latency)
+	} else {
+		t.latencies[t.next] = latency
+	}
+	t.next = (t.next + 1) % latencyTrackerSize
+	t.count++
+	if t.count%100 == 0 || t.count < 100 {
+		sorted := slices.Clone(t.latencies)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		t.value.Store(int64(sorted[int(t.percentileRank*float64(len(sorted)-1))]))
+	}
+}
+
+// percentile returns the percentile of the latencies, false until minSamples latencies are recorded
+func (t *latencyTracker) percentile(minSamples int) (time.Duration, bool) {
+	t.mu.Lock()
+	count := t.count
+	t.mu.Unlock()
+	if count < minSamples {
+		return 0, false
+	}
+
+	return time.Duration(t.value.Load()), true
+}
@@ -0,0 +1,109 @@
+package featuredb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecutorRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" || r.Header.Get("Auth") != "signature" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	client := NewFeatureDBClient(server.URL, "token", "", false)
+	defer client.Close()
+	client.SetExecutorConfig(ExecutorConfig{Retry: RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}})
+
+	request := &Request{Method: "POST", Path: "/api/v1/tables/db/schema/table/batch_get_kv2", Body: []byte(`{"keys":["1"]}`),
+		Header: http.Header{"Auth": []string{"signature"}}}
+	response, err := client.Do(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	// the status 503 is retried, the status 500 is not
+	if response.StatusCode != http.StatusInternalServerError || calls.Load() != 2 {
+		t.Fatalf("expect the response of status 500 after 2 calls, got %d after %d", response.StatusCode, calls.Load())
+	}
+
+	response, err = client.Do(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || string(body) != `{"keys":["1"]}` {
+		t.Fatalf("expect the request body echoed, got %d %s", response.StatusCode, body)
+	}
+
+	// the transport errors are retried until no retry is left
+	server.Close()
+	if _, err := client.Do(context.Background(), request); err == nil {
+		t.Fatal("expect error of the closed server")
+	}
+}
+
+func TestExecutorHedge(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(300 * time.Millisecond):
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewFeatureDBClient(server.URL, "token", "", false)
+	defer client.Close()
+	client.SetExecutorConfig(ExecutorConfig{Hedge: &HedgeConfig{Percentile: 0.9, MinDelay: 10 * time.Millisecond, MinSamples: 10}})
+	executor := client.executor.Load()
+	for i := 0; i < 10; i++ {
+		executor.latency.add(time.Millisecond)
+	}
+
+	start := time.Now()
+	response, err := client.Do(context.Background(), &Request{Method: "POST", Path: "/batch_get_kkv", Hedge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "ok" || calls.Load() != 2 || time.Since(start) > 200*time.Millisecond {
+		t.Fatalf("expect the hedged response, got %s after %d calls in %v", body, calls.Load(), time.Since(start))
+	}
+
+	// the requests that are not reads are not hedged
+	calls.Store(0)
+	start = time.Now()
+	response, err = client.Do(context.Background(), &Request{Method: "POST", Path: "/bloom_write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if calls.Load() != 1 || time.Since(start) < 300*time.Millisecond {
+		t.Fatalf("expect one call, got %d", calls.Load())
+	}
+}
@@ -1,7 +1,7 @@
 package fdbserverpb
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,41 +11,21 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-func BatchWriteBloomKV(project *domain.Project, featureView domain.FeatureView, request *BatchWriteKVReqeust) error {
-	fdbClient, err := featuredb.GetFeatureDBClient()
-	if err != nil {
-		return err
-	}
-
+func BatchWriteBloomKV(fdbClient *featuredb.FeatureDBClient, project *domain.Project, featureView domain.FeatureView, request *BatchWriteKVReqeust) error {
 	requestData, err := proto.Marshal(request)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/bloom_write",
-		fdbClient.GetCurrentAddress(false), project.InstanceId, project.ProjectName, featureView.GetName()), bytes.NewReader(requestData))
+	response, err := fdbClient.Do(context.Background(), &featuredb.Request{
+		Method: "POST",
+		Path:   fmt.Sprintf("/api/v1/tables/%s/%s/%s/bloom_write", project.InstanceId, project.ProjectName, featureView.GetName()),
+		Body:   requestData,
+		Header: http.Header{"Auth": []string{project.Signature}},
+	})
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fdbClient.Token)
-	req.Header.Set("Auth", project.Signature)
-
-	response, err := fdbClient.Client.Do(req)
-	if err != nil {
-		req, err = http.NewRequest("POST", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/bloom_write",
-			fdbClient.GetCurrentAddress(true), project.InstanceId, project.ProjectName, featureView.GetName()), bytes.NewReader(requestData))
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fdbClient.Token)
-		req.Header.Set("Auth", project.Signature)
-		response, err = fdbClient.Client.Do(req)
-		if err != nil {
-			return err
-		}
-	}
 
 	defer response.Body.Close()
 
@@ -70,41 +50,21 @@ func BatchWriteBloomKV(project *domain.Project, featureView domain.FeatureView,
 	return nil
 }
 
-func TestBloomItems(project *domain.Project, featureView domain.FeatureView, request *TestBloomItemsRequest) ([]bool, error) {
-	fdbClient, err := featuredb.GetFeatureDBClient()
-	if err != nil {
-		return nil, err
-	}
-
+func TestBloomItems(fdbClient *featuredb.FeatureDBClient, project *domain.Project, featureView domain.FeatureView, request *TestBloomItemsRequest) ([]bool, error) {
 	requestData, err := proto.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/test_bloom_items",
-		fdbClient.GetCurrentAddress(false), project.InstanceId, project.ProjectName, featureView.GetName()), bytes.NewReader(requestData))
+	response, err := fdbClient.Do(context.Background(), &featuredb.Request{
+		Method: "POST",
+		Path:   fmt.Sprintf("/api/v1/tables/%s/%s/%s/test_bloom_items", project.InstanceId, project.ProjectName, featureView.GetName()),
+		Body:   requestData,
+		Header: http.Header{"Auth": []string{project.Signature}},
+	})
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fdbClient.Token)
-	req.Header.Set("Auth", project.Signature)
-
-	response, err := fdbClient.Client.Do(req)
-	if err != nil {
-		req, err = http.NewRequest("POST", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/test_bloom_items",
-			fdbClient.GetCurrentAddress(true), project.InstanceId, project.ProjectName, featureView.GetName()), bytes.NewReader(requestData))
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fdbClient.Token)
-		req.Header.Set("Auth", project.Signature)
-		response, err = fdbClient.Client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-	}
 
 	defer response.Body.Close()
 
@@ -125,36 +85,15 @@ func TestBloomItems(project *domain.Project, featureView domain.FeatureView, req
 
 }
 
-func DeleteBloomByKey(project *domain.Project, featureView domain.FeatureView, key string) error {
-	fdbClient, err := featuredb.GetFeatureDBClient()
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/delete_bloom_key?key=%s",
-		fdbClient.GetCurrentAddress(false), project.InstanceId, project.ProjectName, featureView.GetName(), key), nil)
+func DeleteBloomByKey(fdbClient *featuredb.FeatureDBClient, project *domain.Project, featureView domain.FeatureView, key string) error {
+	response, err := fdbClient.Do(context.Background(), &featuredb.Request{
+		Method: "DELETE",
+		Path:   fmt.Sprintf("/api/v1/tables/%s/%s/%s/delete_bloom_key?key=%s", project.InstanceId, project.ProjectName, featureView.GetName(), key),
+		Header: http.Header{"Auth": []string{project.Signature}},
+	})
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fdbClient.Token)
-	req.Header.Set("Auth", project.Signature)
-
-	response, err := fdbClient.Client.Do(req)
-	if err != nil {
-		req, err = http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/tables/%s/%s/%s/delete_bloom_key?key=%s",
-			fdbClient.GetCurrentAddress(true), project.InstanceId, project.ProjectName, featureView.GetName(), key), nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", fdbClient.Token)
-		req.Header.Set("Auth", project.Signature)
-		response, err = fdbClient.Client.Do(req)
-		if err != nil {
-			return err
-		}
-	}
 
 	defer response.Body.Close()
 
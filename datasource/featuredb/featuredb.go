@@ -1,9 +1,12 @@
 package featuredb
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -15,8 +18,10 @@ type FeatureDBClient struct {
 	vpcAddress string
 
 	useVpcAddress atomic.Bool
+	executor      atomic.Pointer[Executor]
 	checkInterval time.Duration
 	stopChan      chan struct{}
+	stopOnce      sync.Once
 }
 
 var (
@@ -28,6 +33,11 @@ func InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) {
 		return
 	}
 
+	featureDBClient = NewFeatureDBClient(address, token, vpcAddress, isTestMode)
+}
+
+// NewFeatureDBClient creates a FeatureDBClient, unlike InitFeatureDBClient it is not shared by the process
+func NewFeatureDBClient(address, token, vpcAddress string, isTestMode bool) *FeatureDBClient {
 	dialTimeout := 200 * time.Millisecond
 	responseTimeout := 500 * time.Millisecond
 	if isTestMode {
@@ -48,7 +58,7 @@ func InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) {
 			IdleConnTimeout:       90 * time.Second,
 		},
 	}
-	featureDBClient = &FeatureDBClient{
+	featureDBClient := &FeatureDBClient{
 		Client:        client,
 		address:       address,
 		Token:         token,
@@ -58,12 +68,15 @@ func InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) {
 	}
 
 	featureDBClient.useVpcAddress.Store(false)
+	featureDBClient.SetExecutorConfig(DefaultExecutorConfig())
 
 	if vpcAddress != "" {
 		featureDBClient.CheckVpcAddress()
 
 		go featureDBClient.backgroundCheckVpcAddress()
 	}
+
+	return featureDBClient
 }
 
 func GetFeatureDBClient() (*FeatureDBClient, error) {
@@ -88,24 +101,57 @@ func (f *FeatureDBClient) backgroundCheckVpcAddress() {
 }
 
 func (f *FeatureDBClient) CheckVpcAddress() {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/health", f.vpcAddress), nil)
+	f.CheckVpcAddressWithContext(context.Background())
+}
+
+// CheckVpcAddressWithContext requests /health of the vpc address, the reads use the vpc address while it is healthy
+func (f *FeatureDBClient) CheckVpcAddressWithContext(ctx context.Context) error {
+	err := f.checkAddress(ctx, f.vpcAddress)
+	f.useVpcAddress.Store(err == nil)
+
+	return err
+}
+
+// HasVpcAddress reports whether the client is created with a vpc address
+func (f *FeatureDBClient) HasVpcAddress() bool {
+	return f.vpcAddress != "" && f.vpcAddress != "http://"
+}
+
+// CheckHealth requests /health of the address the reads use, the vpc address is checked first if the client has one
+// and the public address when the vpc address is unhealthy. It returns the checked address and whether it is the vpc one
+func (f *FeatureDBClient) CheckHealth(ctx context.Context) (address string, isVpc bool, err error) {
+	if f.HasVpcAddress() {
+		vpcErr := f.CheckVpcAddressWithContext(ctx)
+		if vpcErr == nil {
+			return f.vpcAddress, true, nil
+		}
+
+		if err := f.checkAddress(ctx, f.address); err != nil {
+			return f.address, false, fmt.Errorf("vpc address err=%v, address err=%v", vpcErr, err)
+		}
+
+		return f.address, false, nil
+	}
+
+	return f.address, false, f.checkAddress(ctx, f.address)
+}
+
+func (f *FeatureDBClient) checkAddress(ctx context.Context, address string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/health", address), nil)
 	if err != nil {
-		f.useVpcAddress.Store(false)
-		return
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	resp, err := f.Client.Do(req)
 	if err != nil {
-		f.useVpcAddress.Store(false)
-		return
+		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusOK {
-		f.useVpcAddress.Store(true)
-		return
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("featuredb health check error, address:%s, status code:%d", address, resp.StatusCode)
 	}
 
-	f.useVpcAddress.Store(false)
+	return nil
 }
 
 func (f *FeatureDBClient) GetCurrentAddress(check bool) string {
@@ -124,10 +170,42 @@ func (f *FeatureDBClient) GetCurrentAddress(check bool) string {
 	}
 }
 
+// SetExecutorConfig replaces the retry and the hedging of the requests of the client, see ExecutorConfig. The
+// executor is kept when the config is the same, so are the latencies the hedging learned
+func (f *FeatureDBClient) SetExecutorConfig(config ExecutorConfig) {
+	if executor := f.executor.Load(); executor != nil && reflect.DeepEqual(executor.config, config) {
+		return
+	}
+	f.executor.Store(NewExecutor(f, config))
+}
+
+// Do sends the request with the Executor of the client, see Executor.Do
+func (f *FeatureDBClient) Do(ctx context.Context, request *Request) (*http.Response, error) {
+	return f.executor.Load().Do(ctx, request)
+}
+
 func (f *FeatureDBClient) GetNormalAddress() string {
 	return f.address
 }
 
 func (f *FeatureDBClient) Stop() {
-	close(f.stopChan)
+	f.stopOnce.Do(func() {
+		close(f.stopChan)
+	})
+}
+
+// Close stops the background check of the vpc address and closes the idle connections
+func (f *FeatureDBClient) Close() {
+	f.Stop()
+	f.Client.CloseIdleConnections()
+}
+
+// CloseFeatureDBClient closes the process-wide client, the next InitFeatureDBClient creates a new one
+func CloseFeatureDBClient() {
+	if featureDBClient == nil {
+		return
+	}
+
+	featureDBClient.Close()
+	featureDBClient = nil
 }
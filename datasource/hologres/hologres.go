@@ -1,8 +1,10 @@
 package hologres
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -40,6 +42,12 @@ type Hologres struct {
 
 var hologresInstances sync.Map
 
+// RetireGracePeriod is how long a replaced pool of a datasource registry keeps serving the reads started with it
+// before it is closed, the process-wide pools are never closed on replacement
+var RetireGracePeriod = time.Minute
+
+var newHologres = NewHologres
+
 func GetHologres(name string) (*Hologres, error) {
 	value, ok := hologresInstances.Load(name)
 	if !ok {
@@ -69,29 +77,76 @@ func (m *Hologres) Init() error {
 	return err
 }
 
-func RegisterHologres(name, dsn string, useCustomAuth bool) {
+// RegisterHologres creates the process-wide instance of the name, it is renewed when the dsn changes or after 12 hours
+// unless custom auth is used
+func RegisterHologres(name, dsn string, useCustomAuth bool) error {
 	value, ok := hologresInstances.Load(name)
 	if ok {
 		if useCustomAuth {
-			return
+			return nil
 		}
+		// a new dsn means rotated credentials, the old pool is left open since the feature views of any client may
+		// still hold it
 		hologresInstance, ok2 := value.(*Hologres)
-		if ok2 && time.Since(hologresInstance.RegisterTime) < 12*time.Hour {
-			return
+		if ok2 && hologresInstance.DSN == dsn && time.Since(hologresInstance.RegisterTime) < 12*time.Hour {
+			return nil
 		}
 	}
+	m, err := newHologres(name, dsn)
+	if err != nil {
+		return fmt.Errorf("register hologres error, name:%s, err=%v", name, err)
+	}
+	hologresInstances.Store(name, m)
+
+	return nil
+}
+
+// NewHologres creates and connects a Hologres instance, unlike RegisterHologres it is not shared by the process
+func NewHologres(name, dsn string) (*Hologres, error) {
 	m := &Hologres{
 		DSN:          dsn,
 		Name:         name,
 		RegisterTime: time.Now(),
 	}
-	err := m.Init()
-	if err != nil {
-		fmt.Printf("event=RegisterHologres\tdsn=%s\tname=%s", dsn, name)
-		panic(err)
+	if err := m.Init(); err != nil {
+		return nil, err
 	}
-	hologresInstances.Store(name, m)
 
+	return m, nil
+}
+
+// Ping verifies a connection of the pool is alive, establishing one if necessary
+func (m *Hologres) Ping(ctx context.Context) error {
+	if m.DB == nil {
+		return fmt.Errorf("Hologres not initialized, name:%s", m.Name)
+	}
+
+	return m.DB.PingContext(ctx)
+}
+
+// Close closes the connection pool
+func (m *Hologres) Close() error {
+	if m.DB == nil {
+		return nil
+	}
+
+	return m.DB.Close()
+}
+
+// RemoveAllHologres closes and removes all the process-wide instances
+func RemoveAllHologres() error {
+	var errs []error
+	hologresInstances.Range(func(key, value any) bool {
+		hologresInstances.Delete(key)
+		if hologresInstance, ok := value.(*Hologres); ok {
+			if err := hologresInstance.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close hologres error, name:%s, err=%v", hologresInstance.Name, err))
+			}
+		}
+		return true
+	})
+
+	return errors.Join(errs...)
 }
 
 func RemoveHologres(name string) {
@@ -1,7 +1,10 @@
 package igraph
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"strings"
 
 	aligraph "github.com/aliyun/aliyun-igraph-go-sdk"
@@ -28,6 +31,11 @@ func RegisterGraphClient(name string, client *GraphClient) {
 	}
 }
 
+// RemoveAllGraphClients removes all the process-wide clients, the igraph sdk keeps no connection per client
+func RemoveAllGraphClients() {
+	graphInstances = make(map[string]*GraphClient)
+}
+
 func NewGraphClient(host, userName, passwd string) *GraphClient {
 	p := &GraphClient{}
 	if !strings.HasPrefix(host, "http://") {
@@ -38,6 +46,31 @@ func NewGraphClient(host, userName, passwd string) *GraphClient {
 	return p
 }
 
+// Ping dials the endpoint of the client, the igraph sdk has no api to check the connectivity
+func (d *GraphClient) Ping(ctx context.Context) error {
+	endpoint, err := url.Parse(d.GraphClient.Endpoint)
+	if err != nil {
+		return fmt.Errorf("parse igraph endpoint error, endpoint:%s, err=%v", d.GraphClient.Endpoint, err)
+	}
+
+	host := endpoint.Host
+	if endpoint.Port() == "" {
+		port := "80"
+		if endpoint.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(endpoint.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
 func (d *GraphClient) Init() error {
 
 	return nil
@@ -0,0 +1,307 @@
+package datasource
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/hologres"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/igraph"
+	fstablestore "github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/tablestore"
+)
+
+// Registry owns the online store clients used by the feature views of a project
+type Registry interface {
+	// InitFeatureDBClient returns the FeatureDB client of the address, token and vpc address, it is held until
+	// ReleaseFeatureDBClient
+	InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) *featuredb.FeatureDBClient
+	// GetFeatureDBClient returns the FeatureDB client held for the address, token and vpc address
+	GetFeatureDBClient(address, token, vpcAddress string) (*featuredb.FeatureDBClient, error)
+	// ReleaseFeatureDBClient releases a client returned by InitFeatureDBClient
+	ReleaseFeatureDBClient(client *featuredb.FeatureDBClient)
+
+	RegisterHologres(name, dsn string, useCustomAuth bool) error
+	GetHologres(name string) (*hologres.Hologres, error)
+
+	RegisterGraphClient(name string, client *igraph.GraphClient)
+	GetGraphClient(name string) (*igraph.GraphClient, error)
+
+	RegisterTableStoreClient(name string, client *fstablestore.TableStoreClient)
+	GetTableStoreClient(name string) (*fstablestore.TableStoreClient, error)
+
+	// Close releases the clients, they are created again by the next registration
+	Close() error
+}
+
+// DefaultRegistry returns the registry backed by the process-wide clients of the datasource packages.
+// Its clients are shared by all the FeatureStoreClients of the process, Close it only when none is in use
+func DefaultRegistry() Registry {
+	return defaultRegistry{}
+}
+
+type defaultRegistry struct{}
+
+// InitFeatureDBClient returns the process-wide client, it is created with the address, token and vpc address of the
+// first call
+func (defaultRegistry) InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) *featuredb.FeatureDBClient {
+	featuredb.InitFeatureDBClient(address, token, vpcAddress, isTestMode)
+	client, _ := featuredb.GetFeatureDBClient()
+
+	return client
+}
+
+// GetFeatureDBClient returns the process-wide client, there is only one whatever the address, token and vpc address
+func (defaultRegistry) GetFeatureDBClient(address, token, vpcAddress string) (*featuredb.FeatureDBClient, error) {
+	return featuredb.GetFeatureDBClient()
+}
+
+// ReleaseFeatureDBClient keeps the process-wide client, it is closed by Close
+func (defaultRegistry) ReleaseFeatureDBClient(client *featuredb.FeatureDBClient) {}
+
+func (defaultRegistry) RegisterHologres(name, dsn string, useCustomAuth bool) error {
+	return hologres.RegisterHologres(name, dsn, useCustomAuth)
+}
+
+func (defaultRegistry) GetHologres(name string) (*hologres.Hologres, error) {
+	return hologres.GetHologres(name)
+}
+
+func (defaultRegistry) RegisterGraphClient(name string, client *igraph.GraphClient) {
+	igraph.RegisterGraphClient(name, client)
+}
+
+func (defaultRegistry) GetGraphClient(name string) (*igraph.GraphClient, error) {
+	return igraph.GetGraphClient(name)
+}
+
+func (defaultRegistry) RegisterTableStoreClient(name string, client *fstablestore.TableStoreClient) {
+	fstablestore.RegisterClient(name, client)
+}
+
+func (defaultRegistry) GetTableStoreClient(name string) (*fstablestore.TableStoreClient, error) {
+	return fstablestore.GetTableStoreClient(name)
+}
+
+func (defaultRegistry) Close() error {
+	featuredb.CloseFeatureDBClient()
+	err := hologres.RemoveAllHologres()
+	igraph.RemoveAllGraphClients()
+	fstablestore.RemoveAllTableStoreClients()
+
+	return err
+}
+
+// registry keeps its own clients, so several FeatureStoreClients in one process do not share connections
+type registry struct {
+	mu sync.RWMutex
+
+	// featureDBClients are the FeatureDB clients by their address, token and vpc address with the number of their
+	// holders
+	featureDBClients    map[featureDBKey]*featureDBClientRef
+	hologresInstances   map[string]*hologres.Hologres
+	graphInstances      map[string]*igraph.GraphClient
+	tablestoreInstances map[string]*fstablestore.TableStoreClient
+
+	// retiredHologres are the replaced pools with the timers closing them, they are still used by the feature views
+	// built before the replacement until hologres.RetireGracePeriod passes
+	retiredHologres map[*hologres.Hologres]*time.Timer
+}
+
+type featureDBKey struct {
+	address, token, vpcAddress string
+}
+
+type featureDBClientRef struct {
+	client *featuredb.FeatureDBClient
+	refs   int
+}
+
+var newHologres = hologres.NewHologres
+
+// NewRegistry creates a registry isolated from the process-wide clients and from other registries
+func NewRegistry() Registry {
+	return &registry{
+		featureDBClients:    make(map[featureDBKey]*featureDBClientRef),
+		hologresInstances:   make(map[string]*hologres.Hologres),
+		graphInstances:      make(map[string]*igraph.GraphClient),
+		tablestoreInstances: make(map[string]*fstablestore.TableStoreClient),
+		retiredHologres:     make(map[*hologres.Hologres]*time.Timer),
+	}
+}
+
+// InitFeatureDBClient returns the FeatureDB client of the address, token and vpc address, the projects with the same
+// ones share it and the ones with different ones, such as a rotated token, do not affect each other's
+func (r *registry) InitFeatureDBClient(address, token, vpcAddress string, isTestMode bool) *featuredb.FeatureDBClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := featureDBKey{address: address, token: token, vpcAddress: vpcAddress}
+	ref, ok := r.featureDBClients[key]
+	if !ok {
+		ref = &featureDBClientRef{client: featuredb.NewFeatureDBClient(address, token, vpcAddress, isTestMode)}
+		r.featureDBClients[key] = ref
+	}
+	ref.refs++
+
+	return ref.client
+}
+
+func (r *registry) GetFeatureDBClient(address, token, vpcAddress string) (*featuredb.FeatureDBClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ref, ok := r.featureDBClients[featureDBKey{address: address, token: token, vpcAddress: vpcAddress}]
+	if !ok {
+		return nil, fmt.Errorf("FeatureDB has not been provisioned, address:%s", address)
+	}
+
+	return ref.client, nil
+}
+
+// ReleaseFeatureDBClient closes the client when it is released by all its holders
+func (r *registry) ReleaseFeatureDBClient(client *featuredb.FeatureDBClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, ref := range r.featureDBClients {
+		if ref.client != client {
+			continue
+		}
+		if ref.refs--; ref.refs > 0 {
+			return
+		}
+		delete(r.featureDBClients, key)
+		client.Close()
+		return
+	}
+}
+
+// RegisterHologres follows hologres.RegisterHologres, the instance is renewed when the dsn changes or after 12 hours
+// unless custom auth is used
+func (r *registry) RegisterHologres(name, dsn string, useCustomAuth bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hologresInstance, ok := r.hologresInstances[name]
+	if ok {
+		if useCustomAuth || (hologresInstance.DSN == dsn && time.Since(hologresInstance.RegisterTime) < 12*time.Hour) {
+			return nil
+		}
+	}
+
+	m, err := newHologres(name, dsn)
+	if err != nil {
+		return fmt.Errorf("register hologres error, name:%s, err=%v", name, err)
+	}
+	if ok {
+		r.retiredHologres[hologresInstance] = time.AfterFunc(hologres.RetireGracePeriod, func() {
+			r.closeRetiredHologres(hologresInstance)
+		})
+	}
+	r.hologresInstances[name] = m
+
+	return nil
+}
+
+// closeRetiredHologres closes a retired pool unless the registry is closed meanwhile
+func (r *registry) closeRetiredHologres(hologresInstance *hologres.Hologres) {
+	r.mu.Lock()
+	_, ok := r.retiredHologres[hologresInstance]
+	delete(r.retiredHologres, hologresInstance)
+	r.mu.Unlock()
+
+	if ok {
+		hologresInstance.Close()
+	}
+}
+
+func (r *registry) GetHologres(name string) (*hologres.Hologres, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hologresInstance, ok := r.hologresInstances[name]
+	if !ok {
+		return nil, fmt.Errorf("Hologres not found, name:%s", name)
+	}
+
+	return hologresInstance, nil
+}
+
+func (r *registry) RegisterGraphClient(name string, client *igraph.GraphClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.graphInstances[name]; !ok {
+		r.graphInstances[name] = client
+	}
+}
+
+func (r *registry) GetGraphClient(name string) (*igraph.GraphClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.graphInstances[name]
+	if !ok {
+		return nil, fmt.Errorf("GraphClient not found, name:%s", name)
+	}
+
+	return client, nil
+}
+
+func (r *registry) RegisterTableStoreClient(name string, client *fstablestore.TableStoreClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tablestoreInstances[name]; !ok {
+		r.tablestoreInstances[name] = client
+	}
+}
+
+func (r *registry) GetTableStoreClient(name string) (*fstablestore.TableStoreClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.tablestoreInstances[name]
+	if !ok {
+		return nil, fmt.Errorf("TableStoreClient not found, name:%s", name)
+	}
+
+	return client, nil
+}
+
+// Close closes the FeatureDB clients, the Hologres connection pools and the TableStore connections
+func (r *registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for _, ref := range r.featureDBClients {
+		ref.client.Close()
+	}
+	r.featureDBClients = make(map[featureDBKey]*featureDBClientRef)
+	hologresInstances := make([]*hologres.Hologres, 0, len(r.hologresInstances)+len(r.retiredHologres))
+	for _, hologresInstance := range r.hologresInstances {
+		hologresInstances = append(hologresInstances, hologresInstance)
+	}
+	for hologresInstance, timer := range r.retiredHologres {
+		timer.Stop()
+		hologresInstances = append(hologresInstances, hologresInstance)
+	}
+	for _, hologresInstance := range hologresInstances {
+		if err := hologresInstance.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close hologres error, name:%s, err=%v", hologresInstance.Name, err))
+		}
+	}
+	r.retiredHologres = make(map[*hologres.Hologres]*time.Timer)
+	for _, client := range r.tablestoreInstances {
+		client.Close()
+	}
+
+	r.hologresInstances = make(map[string]*hologres.Hologres)
+	r.graphInstances = make(map[string]*igraph.GraphClient)
+	r.tablestoreInstances = make(map[string]*fstablestore.TableStoreClient)
+
+	return errors.Join(errs...)
+}
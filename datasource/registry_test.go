@@ -0,0 +1,143 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/hologres"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/igraph"
+)
+
+func TestRegistry(t *testing.T) {
+	r1 := NewRegistry()
+	r2 := NewRegistry()
+
+	r1.InitFeatureDBClient("http://fdb-1", "token", "", false)
+	r2.InitFeatureDBClient("http://fdb-2", "token", "", false)
+
+	client1, err := r1.GetFeatureDBClient("http://fdb-1", "token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client2, err := r2.GetFeatureDBClient("http://fdb-2", "token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client1.GetNormalAddress() != "http://fdb-1" || client2.GetNormalAddress() != "http://fdb-2" {
+		t.Fatalf("registries share the featuredb client, %s, %s", client1.GetNormalAddress(), client2.GetNormalAddress())
+	}
+
+	// the clients of different tokens are kept apart, the one of the same token is shared
+	rotated := r1.InitFeatureDBClient("http://fdb-1", "new_token", "", false)
+	if client, _ := r1.GetFeatureDBClient("http://fdb-1", "new_token", ""); client != rotated || rotated == client1 || rotated.Token != "new_token" {
+		t.Fatalf("expect a new featuredb client of the new token, got %v", client)
+	}
+	if client, _ := r1.GetFeatureDBClient("http://fdb-1", "token", ""); client != client1 {
+		t.Fatal("expect the featuredb client of the old token kept for its holders")
+	}
+	if shared := r1.InitFeatureDBClient("http://fdb-1", "token", "", false); shared != client1 {
+		t.Fatal("expect the featuredb client of the same token shared")
+	}
+
+	// a client is closed when all its holders release it
+	held := func(client *featuredb.FeatureDBClient) bool {
+		for _, ref := range r1.(*registry).featureDBClients {
+			if ref.client == client {
+				return true
+			}
+		}
+		return false
+	}
+	r1.ReleaseFeatureDBClient(client1)
+	if !held(client1) {
+		t.Fatal("expect the featuredb client kept while it is held")
+	}
+	r1.ReleaseFeatureDBClient(client1)
+	if held(client1) || !held(rotated) {
+		t.Fatal("expect only the released featuredb client closed")
+	}
+	if _, err := r1.GetFeatureDBClient("http://fdb-1", "token", ""); err == nil {
+		t.Fatal("expect the released featuredb client not found")
+	}
+
+	if _, err := DefaultRegistry().GetFeatureDBClient("http://fdb-1", "token", ""); err == nil {
+		t.Fatal("default registry should not see the clients of other registries")
+	}
+
+	r1.RegisterGraphClient("igraph", &igraph.GraphClient{})
+	if _, err := r2.GetGraphClient("igraph"); err == nil {
+		t.Fatal("registries share the igraph client")
+	}
+}
+
+type unreachableConnector struct{}
+
+func (unreachableConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, errors.New("unreachable")
+}
+
+func (unreachableConnector) Driver() driver.Driver {
+	return hologres.HologresDriver{}
+}
+
+func TestRegistryRetiresHologres(t *testing.T) {
+	defer func(gracePeriod time.Duration) {
+		hologres.RetireGracePeriod, newHologres = gracePeriod, hologres.NewHologres
+	}(hologres.RetireGracePeriod)
+	hologres.RetireGracePeriod = 20 * time.Millisecond
+	newHologres = func(name, dsn string) (*hologres.Hologres, error) {
+		return &hologres.Hologres{DSN: dsn, Name: name, RegisterTime: time.Now(), DB: sql.OpenDB(unreachableConnector{})}, nil
+	}
+	closed := func(m *hologres.Hologres) bool {
+		err := m.DB.Ping()
+		return err != nil && err.Error() == "sql: database is closed"
+	}
+
+	r := NewRegistry()
+	if err := r.RegisterHologres("holo", "dsn1", false); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := r.GetHologres("holo")
+	if err := r.RegisterHologres("holo", "dsn2", false); err != nil {
+		t.Fatal(err)
+	}
+	if closed(old) {
+		t.Fatal("expect the retired pool open during the grace period")
+	}
+	time.Sleep(10 * hologres.RetireGracePeriod)
+	if !closed(old) {
+		t.Fatal("expect the retired pool closed after the grace period")
+	}
+
+	// the pools retired when the registry closes are closed at once
+	current, _ := r.GetHologres("holo")
+	if err := r.RegisterHologres("holo", "dsn3", false); err != nil {
+		t.Fatal(err)
+	}
+	latest, _ := r.GetHologres("holo")
+	r.Close()
+	if !closed(current) || !closed(latest) {
+		t.Fatal("expect every pool closed with the registry")
+	}
+}
+
+func TestRegistryRegisterHologresError(t *testing.T) {
+	defer func() { newHologres = hologres.NewHologres }()
+	newHologres = func(name, dsn string) (*hologres.Hologres, error) {
+		return nil, errors.New("unreachable")
+	}
+
+	r := NewRegistry()
+	defer r.Close()
+	if err := r.RegisterHologres("holo", "dsn", false); err == nil {
+		t.Fatal("expect the register error")
+	}
+	if _, err := r.GetHologres("holo"); err == nil {
+		t.Fatal("expect no hologres registered")
+	}
+}
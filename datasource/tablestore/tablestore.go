@@ -1,13 +1,19 @@
 package tablestore
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
 
 	"github.com/aliyun/aliyun-tablestore-go-sdk/tablestore"
 )
 
 type TableStoreClient struct {
 	client *tablestore.TableStoreClient
+
+	// transport of the client, nil if the transport is not known
+	transport *http.Transport
 }
 
 var (
@@ -27,6 +33,42 @@ func RegisterTableStoreClient(name string, client *tablestore.TableStoreClient)
 	}
 }
 
+// NewTableStoreClient wraps the client, unlike RegisterTableStoreClient it is not shared by the process.
+// The transport is the one the client is created with, see NewTransport, Close releases its connections
+func NewTableStoreClient(client *tablestore.TableStoreClient, transport *http.Transport) *TableStoreClient {
+	return &TableStoreClient{
+		client:    client,
+		transport: transport,
+	}
+}
+
+// NewTransport creates the transport the tablestore sdk uses by default
+func NewTransport() *http.Transport {
+	config := tablestore.NewDefaultTableStoreConfig()
+
+	return &http.Transport{
+		MaxIdleConnsPerHost: config.MaxIdleConnections,
+		Dial: (&net.Dialer{
+			Timeout: config.HTTPTimeout.ConnectionTimeout,
+		}).Dial,
+	}
+}
+
+// RegisterClient registers the client as the process-wide client of the name, the first registered one is kept
+func RegisterClient(name string, client *TableStoreClient) {
+	if _, ok := tablestoreInstances[name]; !ok {
+		tablestoreInstances[name] = client
+	}
+}
+
+// RemoveAllTableStoreClients closes and removes all the process-wide clients
+func RemoveAllTableStoreClients() {
+	for name, client := range tablestoreInstances {
+		client.Close()
+		delete(tablestoreInstances, name)
+	}
+}
+
 func GetTableStoreClient(name string) (*TableStoreClient, error) {
 	if _, ok := tablestoreInstances[name]; !ok {
 		return nil, fmt.Errorf("TableStoreClient not found, name:%s", name)
@@ -46,3 +88,41 @@ func DeleteTableStoreClient(name string) {
 func (o *TableStoreClient) GetClient() *tablestore.TableStoreClient {
 	return o.client
 }
+
+// DescribeTable describes the table, the tablestore sdk takes no context so the request keeps running
+// in the background when ctx is done first
+func (o *TableStoreClient) DescribeTable(ctx context.Context, tableName string) error {
+	return runWithContext(ctx, func() error {
+		_, err := o.client.DescribeTable(&tablestore.DescribeTableRequest{TableName: tableName})
+		return err
+	})
+}
+
+// ListTable lists the tables of the instance to check it is reachable, see DescribeTable
+func (o *TableStoreClient) ListTable(ctx context.Context) error {
+	return runWithContext(ctx, func() error {
+		_, err := o.client.ListTable()
+		return err
+	})
+}
+
+func runWithContext(ctx context.Context, fn func() error) error {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fn()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the idle connections of the client
+func (o *TableStoreClient) Close() {
+	if o.transport != nil {
+		o.transport.CloseIdleConnections()
+	}
+}
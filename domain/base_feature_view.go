@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 )
 
 type BaseFeatureView struct {
@@ -19,6 +25,15 @@ type BaseFeatureView struct {
 	primaryKeyField api.FeatureViewFields
 	eventTimeField  api.FeatureViewFields
 	featureViewDao  dao.FeatureViewDao
+	// storeName is the name of the online store the rows without dao.ServedByKey are read from
+	storeName string
+
+	// cache of the online features, nil if the feature view is not configured with a FeatureCacheConfig
+	cache            *featureCache
+	cacheTTL         time.Duration
+	cacheNegativeTTL time.Duration
+
+	structDecoders structDecoders
 }
 
 func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity) *BaseFeatureView {
@@ -40,20 +55,115 @@ func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity
 		}
 	}
 
+	featureView.featureViewDao = featureView.newFeatureViewDao()
+
+	if cache, config := p.featureCaches.get(p.ProjectName, view.Name); cache != nil {
+		featureView.cache = cache
+		featureView.cacheTTL, featureView.cacheNegativeTTL = featureCacheTTL(config, view.Ttl)
+	}
+
+	return featureView
+}
+
+// newFeatureViewDao returns the dao of the online store of the feature view, FeatureDB if the feature view is written
+// to it. With a FallbackConfig the dao reads from the stores of the config in order
+func (f *BaseFeatureView) newFeatureViewDao() dao.FeatureViewDao {
+	p := f.Project
+	datasourceType := p.OnlineDatasourceType
+	if f.WriteToFeatureDB {
+		datasourceType = constants.Datasource_Type_FeatureDB
+	}
+
+	fallback, ok := p.featureViewFallbacks[f.Name]
+	if !ok {
+		daoConfig := f.newDaoConfig(datasourceType)
+		f.storeName = daoConfig.DatasourceName()
+		return dao.NewFeatureViewDao(daoConfig)
+	}
+
+	stores := fallback.Stores
+	if len(stores) == 0 {
+		stores = []string{datasourceType, p.OnlineDatasourceType}
+	}
+	var daos []dao.FeatureViewDao
+	var names []string
+	var firstConfig dao.DaoConfig
+	seenStores := make(map[string]bool, len(stores))
+	for _, store := range stores {
+		if seenStores[store] {
+			continue
+		}
+		seenStores[store] = true
+		if store != p.OnlineDatasourceType && store != constants.Datasource_Type_FeatureDB {
+			p.logger.Warn("the fallback store is not the online store of the project", logging.F("feature_view", f.Name), logging.F("store", store))
+			continue
+		}
+		if store == constants.Datasource_Type_FeatureDB {
+			if _, err := p.FeatureDBClient(); err != nil {
+				p.logger.Warn("the fallback store is not available", logging.F("feature_view", f.Name), logging.F("store", store), logging.Err(err))
+				continue
+			}
+		}
+
+		daoConfig := f.newDaoConfig(store)
+		if len(daos) == 0 {
+			firstConfig = daoConfig
+		} else {
+			// the fallback stores do not share the circuit breaker of the feature view with the first one
+			daoConfig.CircuitBreakerName = fmt.Sprintf("%s/%s/%s", p.ProjectName, f.Name, daoConfig.DatasourceName())
+		}
+		daos = append(daos, dao.NewFeatureViewDao(daoConfig))
+		names = append(names, daoConfig.DatasourceName())
+	}
+
+	switch len(daos) {
+	case 0:
+		daoConfig := f.newDaoConfig(datasourceType)
+		f.storeName = daoConfig.DatasourceName()
+		return dao.NewFeatureViewDao(daoConfig)
+	case 1:
+		f.storeName = names[0]
+		return daos[0]
+	}
+
+	return dao.NewFallbackFeatureViewDao(daos, names, fallback.Policy, firstConfig)
+}
+
+// newDaoConfig returns the config of the dao of the feature view reading from the online store of the datasource type,
+// FeatureDB or the online store of the project
+func (f *BaseFeatureView) newDaoConfig(datasourceType string) dao.DaoConfig {
+	p := f.Project
+	view := f.FeatureView
 	daoConfig := dao.DaoConfig{
-		DatasourceType:    p.OnlineDatasourceType,
-		PrimaryKeyField:   featureView.primaryKeyField.Name,
-		EventTimeField:    featureView.eventTimeField.Name,
-		TTL:               int(featureView.Ttl),
+		DatasourceType:    datasourceType,
+		Registry:          p.registry,
+		ReadTracker:       p.readTracker,
+		Logger:            p.logger,
+		Metrics:           p.metrics,
+		Tracer:            p.tracer,
+		Coalesce:          p.coalesce,
+		CircuitBreakers:   p.circuitBreakers,
+		ProjectName:       p.ProjectName,
+		FeatureViewName:   view.Name,
+		PrimaryKeyField:   f.primaryKeyField.Name,
+		EventTimeField:    f.eventTimeField.Name,
+		TTL:               int(f.Ttl),
 		SaveOriginalField: false,
 	}
 
-	if view.WriteToFeatureDB || p.OnlineDatasourceType == constants.Datasource_Type_FeatureDB {
-		daoConfig.DatasourceType = constants.Datasource_Type_FeatureDB
+	daoConfig.FieldTypes = make(map[string]constants.FSType, len(view.Fields))
+	for _, field := range view.Fields {
+		if !field.IsPartition {
+			daoConfig.FieldTypes[field.Name] = field.Type
+		}
+	}
+
+	if datasourceType == constants.Datasource_Type_FeatureDB {
 		daoConfig.FeatureDBDatabaseName = p.InstanceId
 		daoConfig.FeatureDBSchemaName = p.ProjectName
-		daoConfig.FeatureDBTableName = featureView.Name
+		daoConfig.FeatureDBTableName = f.Name
 		daoConfig.FeatureDBSignature = p.Signature
+		daoConfig.FeatureDBClient, _ = p.FeatureDBClient()
 
 		fieldTypeMap := make(map[string]constants.FSType, len(view.Fields))
 		for _, field := range view.Fields {
@@ -64,11 +174,11 @@ func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity
 			}
 		}
 		daoConfig.FieldTypeMap = fieldTypeMap
-		daoConfig.Fields = featureView.featureFields
+		daoConfig.Fields = f.featureFields
 	} else {
-		switch p.OnlineDatasourceType {
+		switch datasourceType {
 		case constants.Datasource_Type_Hologres:
-			daoConfig.HologresTableName = p.OnlineStore.GetTableName(featureView)
+			daoConfig.HologresTableName = p.OnlineStore.GetTableName(f)
 			daoConfig.HologresName = p.OnlineStore.GetDatasourceName()
 		case constants.Datasource_Type_IGraph:
 			if view.Config != "" {
@@ -83,7 +193,7 @@ func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity
 			}
 			daoConfig.IGraphName = p.OnlineStore.GetDatasourceName()
 			daoConfig.GroupName = p.ProjectName
-			daoConfig.LabelName = p.OnlineStore.GetTableName(featureView)
+			daoConfig.LabelName = p.OnlineStore.GetTableName(f)
 			fieldMap := make(map[string]string, len(view.Fields))
 			fieldTypeMap := make(map[string]constants.FSType, len(view.Fields))
 			for _, field := range view.Fields {
@@ -107,7 +217,7 @@ func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity
 			daoConfig.FieldMap = fieldMap
 			daoConfig.FieldTypeMap = fieldTypeMap
 		case constants.Datasource_Type_TableStore:
-			daoConfig.TableStoreTableName = p.OnlineStore.GetTableName(featureView)
+			daoConfig.TableStoreTableName = p.OnlineStore.GetTableName(f)
 			daoConfig.TableStoreName = p.OnlineStore.GetDatasourceName()
 			fieldTypeMap := make(map[string]constants.FSType, len(view.Fields))
 			for _, field := range view.Fields {
@@ -126,10 +236,7 @@ func NewBaseFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity
 		}
 	}
 
-	featureViewDao := dao.NewFeatureViewDao(daoConfig)
-	featureView.featureViewDao = featureViewDao
-
-	return featureView
+	return daoConfig
 }
 
 func (f *BaseFeatureView) GetOnlineFeatures(joinIds []interface{}, features []string, alias map[string]string) ([]map[string]interface{}, error) {
@@ -149,18 +256,114 @@ func (f *BaseFeatureView) GetOnlineFeaturesWithOptions(joinIds []interface{}, fe
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	count := opts.count
-	if count <= 0 {
-		count = 1
+	if opts.count <= 0 {
+		opts.count = 1
+	}
+	featureResult, err := f.readOnlineFeatures(ctx, joinIds, features, alias, opts)
+	if err == nil && !opts.noDefaultValues && f.Project != nil && f.Project.defaultValues != nil {
+		fields := f.defaultFields(features, alias)
+		featureResult = f.addMissingRows(joinIds, featureResult)
+		for _, featureMap := range featureResult {
+			f.Project.defaultValues.fill(featureMap, fields)
+		}
 	}
-	return f.getOnlineFeaturesWithCountWithContext(ctx, joinIds, features, alias, count)
+
+	return featureResult, err
+}
+
+// addMissingRows appends a row of only the join id for each join id without a row, so the default values fill it
+func (f *BaseFeatureView) addMissingRows(joinIds []interface{}, featureResult []map[string]interface{}) []map[string]interface{} {
+	joinIdField := f.FeatureEntity.FeatureEntityJoinid
+	foundKeys := make(map[string]bool, len(featureResult))
+	for _, featureMap := range featureResult {
+		foundKeys[utils.ToString(featureMap[joinIdField], "")] = true
+	}
+
+	for _, joinId := range joinIds {
+		key := utils.ToString(joinId, "")
+		if foundKeys[key] {
+			continue
+		}
+		foundKeys[key] = true
+		featureResult = append(featureResult, map[string]interface{}{joinIdField: joinId})
+	}
+
+	return featureResult
+}
+
+// defaultFields returns the selected features by their name in the result
+func (f *BaseFeatureView) defaultFields(features []string, alias map[string]string) []defaultField {
+	fieldTypes := make(map[string]constants.FSType, len(f.Fields))
+	for _, field := range f.Fields {
+		fieldTypes[field.Name] = field.Type
+	}
+
+	var names []string
+	for _, featureName := range features {
+		if featureName == "*" {
+			names = append(names, f.featureFields...)
+		} else {
+			names = append(names, featureName)
+		}
+	}
+
+	seenNames := make(map[string]bool, len(names))
+	fields := make([]defaultField, 0, len(names))
+	for _, name := range names {
+		if seenNames[name] {
+			continue
+		}
+		seenNames[name] = true
+
+		field := defaultField{name: name, fsType: fieldTypes[name]}
+		if aliasName, ok := alias[name]; ok {
+			field.name = aliasName
+		}
+		fields = append(fields, field)
+	}
+
+	return fields
 }
 
 func (f *BaseFeatureView) getOnlineFeaturesWithCountWithContext(ctx context.Context, joinIds []interface{}, features []string, alias map[string]string, count int) ([]map[string]interface{}, error) {
+	return f.readOnlineFeatures(ctx, joinIds, features, alias, FeatureViewOptions{count: count})
+}
+
+func (f *BaseFeatureView) readOnlineFeatures(ctx context.Context, joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) ([]map[string]interface{}, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
+	selectFields, err := f.selectFields(features, alias)
+	if err != nil {
+		return nil, err
+	}
+	featureResult, err := f.getFreshFeatures(ctx, joinIds, selectFields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.primaryKeyField.Name != f.FeatureEntity.FeatureEntityJoinid {
+		for _, featureMap := range featureResult {
+			featureMap[f.FeatureEntity.FeatureEntityJoinid] = featureMap[f.primaryKeyField.Name]
+			delete(featureMap, f.primaryKeyField.Name)
+		}
+	}
+
+	for featureName, aliasName := range alias {
+		for _, featureMap := range featureResult {
+			if _, ok := featureMap[featureName]; ok {
+				featureMap[aliasName] = featureMap[featureName]
+				delete(featureMap, featureName)
+			}
+		}
+	}
+
+	return featureResult, err
+}
+
+// selectFields returns the primary key and the features to read, the features and the alias must be in the feature view
+func (f *BaseFeatureView) selectFields(features []string, alias map[string]string) ([]string, error) {
 	var selectFields []string
 	selectFields = append(selectFields, f.primaryKeyField.Name)
 	seenFields := make(map[string]bool)
@@ -202,28 +405,264 @@ func (f *BaseFeatureView) getOnlineFeaturesWithCountWithContext(ctx context.Cont
 		}
 	}
 
-	featureResult, err := f.featureViewDao.GetFeaturesWithContext(ctx, joinIds, selectFields, count)
+	return selectFields, nil
+}
+
+// getFreshFeatures reads the features of the join ids with the freshness fields of opts
+func (f *BaseFeatureView) getFreshFeatures(ctx context.Context, joinIds []interface{}, selectFields []string, opts FeatureViewOptions) ([]map[string]interface{}, error) {
+	if opts.Freshness == nil {
+		return f.getFeatures(ctx, joinIds, selectFields, opts)
+	}
+
+	fields, eventTimeField, selected := opts.Freshness.readFields(f, selectFields)
+	featureResult, err := f.getFeatures(ctx, joinIds, fields, opts)
+	if err != nil || eventTimeField == "" {
+		return featureResult, err
+	}
+
+	return opts.Freshness.apply(f, featureResult, eventTimeField, selected), nil
+}
+
+// getFeatures reads the features of the join ids from the dao, with the cache only the join ids missing in it are read.
+// The join ids are not cached as missing after a read with failed batches. The reads overriding the ttl bypass the cache
+func (f *BaseFeatureView) getFeatures(ctx context.Context, joinIds []interface{}, selectFields []string, opts FeatureViewOptions) ([]map[string]interface{}, error) {
+	if f.cache == nil || opts.TTL != 0 {
+		featureResult, _, err := f.readDao(ctx, joinIds, selectFields, opts, false)
+		return featureResult, err
+	}
+
+	fieldsKey := fmt.Sprintf("%d:%s", opts.count, strings.Join(selectFields, ","))
+	var result []map[string]interface{}
+	var missingIds []interface{}
+	missingKeys := make(map[string]string)
+	seenIds := make(map[string]bool, len(joinIds))
+	for _, joinId := range joinIds {
+		id := fmt.Sprint(joinId)
+		if seenIds[id] {
+			continue
+		}
+		seenIds[id] = true
+
+		key := id + "\x00" + fieldsKey
+		if rows, ok := f.cache.get(key); ok {
+			opts.ServedBy.add(f.Name, ServedByCache, len(rows))
+			result = append(result, rows...)
+			continue
+		}
+		missingIds = append(missingIds, joinId)
+		missingKeys[id] = key
+	}
+
+	if len(missingIds) == 0 {
+		return result, nil
+	}
+
+	featureResult, failedKeys, err := f.readDao(ctx, missingIds, selectFields, opts, true)
 	if err != nil {
 		return nil, err
 	}
+	for _, failedKey := range failedKeys {
+		delete(missingKeys, fmt.Sprint(failedKey))
+	}
 
-	if f.primaryKeyField.Name != f.FeatureEntity.FeatureEntityJoinid {
-		for _, featureMap := range featureResult {
-			featureMap[f.FeatureEntity.FeatureEntityJoinid] = featureMap[f.primaryKeyField.Name]
-			delete(featureMap, f.primaryKeyField.Name)
+	fetchedRows := make(map[string][]map[string]interface{}, len(missingIds))
+	for _, featureMap := range featureResult {
+		id := fmt.Sprint(featureMap[f.primaryKeyField.Name])
+		fetchedRows[id] = append(fetchedRows[id], featureMap)
+	}
+	for id, key := range missingKeys {
+		if rows, ok := fetchedRows[id]; ok {
+			f.cache.set(key, rows, f.cacheTTL)
+		} else if f.cacheNegativeTTL > 0 && len(failedKeys) == 0 {
+			f.cache.set(key, nil, f.cacheNegativeTTL)
+		}
+	}
+
+	return append(result, featureResult...), nil
+}
+
+// readDao reads the features of the keys from the dao. With a FailurePolicy the batches of the keys are read
+// independently, the read fails when the policy does not tolerate the failed keys and otherwise they are returned
+// and reported to the PartialFailures of opts. Without one the failed keys are only returned with partial, the keys of
+// the failed responses are missing and the other failures fail the read like GetFeaturesWithContext
+func (f *BaseFeatureView) readDao(ctx context.Context, keys []interface{}, selectFields []string, opts FeatureViewOptions, partial bool) ([]map[string]interface{}, []interface{}, error) {
+	if opts.TTL != 0 {
+		ctx = dao.WithTTL(ctx, opts.TTL)
+	}
+	if opts.FailurePolicy == nil && !partial {
+		featureResult, err := f.featureViewDao.GetFeaturesWithContext(ctx, keys, selectFields, opts.count)
+		opts.ServedBy.report(f.Name, f.storeName, featureResult)
+		return featureResult, nil, err
+	}
+
+	partialResult, err := dao.GetFeaturesPartial(ctx, f.featureViewDao, keys, selectFields, opts.count)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.FailurePolicy == nil {
+		for _, failure := range partialResult.Failures {
+			var statusErr *dao.StatusError
+			if !errors.As(failure.Err, &statusErr) {
+				return nil, nil, failure.Err
+			}
+		}
+	} else if err := opts.FailurePolicy.check(partialResult, len(keys)); err != nil {
+		return nil, nil, fmt.Errorf("read feature view %s failed: %w", f.Name, err)
+	}
+	opts.PartialFailures.add(f.Name, partialResult.Failures)
+	opts.ServedBy.report(f.Name, f.storeName, partialResult.Rows)
+
+	return partialResult.Rows, partialResult.FailedKeys(), nil
+}
+
+// CacheStats returns the statistics of the cache of the feature view, false if it is not configured with a FeatureCacheConfig
+func (f *BaseFeatureView) CacheStats() (FeatureCacheStats, bool) {
+	if f.cache == nil {
+		return FeatureCacheStats{}, false
+	}
+
+	return f.cache.stats(), true
+}
+
+func (f *BaseFeatureView) GetOnlineFeaturesAligned(joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*AlignedFeatures, error) {
+	featureResult, err := f.GetOnlineFeaturesWithOptions(joinIds, features, alias, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return alignFeatures(joinIds, featureResult, f.FeatureEntity.FeatureEntityJoinid), nil
+}
+
+// GetOnlineFeaturesInto reads the features of the fields of the struct of dest tagged with `fs:"name"`, dest is a pointer
+// to a slice of structs or struct pointers and gets an element per join id, a missing join id is a nil pointer or a zero
+// struct. The join id of the feature entity can be tagged too. The struct is validated against the fields of the feature
+// view at first use, see StructTag
+func (f *BaseFeatureView) GetOnlineFeaturesInto(ctx context.Context, joinIds []interface{}, dest interface{}) error {
+	decoder, err := f.structDecoders.decoderOf(dest, "feature view "+f.Name, f.structSchema)
+	if err != nil {
+		return err
+	}
+
+	var features []string
+	for _, name := range decoder.featureNames() {
+		if name != f.FeatureEntity.FeatureEntityJoinid {
+			features = append(features, name)
+		}
+	}
+	aligned, err := f.GetOnlineFeaturesAligned(joinIds, features, nil, FeatureViewOptions{Ctx: ctx})
+	if err != nil {
+		return err
+	}
+
+	return decoder.decode(aligned.Rows, dest)
+}
+
+// structSchema returns the types of the fields by their name in the rows
+func (f *BaseFeatureView) structSchema() map[string]constants.FSType {
+	schema := make(map[string]constants.FSType, len(f.Fields))
+	for _, field := range f.Fields {
+		if field.IsPartition {
+			continue
+		} else if field.IsPrimaryKey {
+			schema[f.FeatureEntity.FeatureEntityJoinid] = field.Type
+		} else {
+			schema[field.Name] = field.Type
 		}
 	}
 
+	return schema
+}
+
+// GetOnlineFeaturesBatch reads the features into a batch of one row per join id in the order of the join ids, the
+// first column is the join id of the feature entity and the features of the missing join ids are null. The daos
+// with a columnar read fill the columns directly, the rows of the other daos and of the cache are converted
+func (f *BaseFeatureView) GetOnlineFeaturesBatch(joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*columnar.FeatureBatch, error) {
+	ctx := opts.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.count <= 0 {
+		opts.count = 1
+	}
+
+	selectFields, err := f.selectFields(features, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	// the columnar reads are not partial and do not report the stores, the rows are read with a failure policy,
+	// a ServedBy or a Freshness
+	var batch *columnar.FeatureBatch
+	rowsOnly := f.cache != nil || opts.FailurePolicy != nil || opts.ServedBy != nil || opts.Freshness != nil
+	if !rowsOnly {
+		batchCtx := ctx
+		if opts.TTL != 0 {
+			batchCtx = dao.WithTTL(ctx, opts.TTL)
+		}
+		batch, err = dao.GetFeaturesBatch(batchCtx, f.featureViewDao, joinIds, selectFields, opts.count)
+	}
+	if rowsOnly || errors.Is(err, dao.ErrFeatureBatchNotSupported) {
+		batch, err = f.getFeaturesBatchFromRows(ctx, joinIds, selectFields, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	for featureName, aliasName := range alias {
-		for _, featureMap := range featureResult {
-			if _, ok := featureMap[featureName]; ok {
-				featureMap[aliasName] = featureMap[featureName]
-				delete(featureMap, featureName)
+		batch.RenameColumn(featureName, aliasName)
+	}
+
+	result := &columnar.FeatureBatch{}
+	result.AddColumn(columnar.ColumnFromValues(f.FeatureEntity.FeatureEntityJoinid, f.primaryKeyField.Type, joinIds))
+	for _, field := range selectFields[1:] {
+		name := field
+		if aliasName, ok := alias[field]; ok {
+			name = aliasName
+		}
+		if column := batch.Column(name); column != nil && result.Column(name) == nil {
+			result.AddColumn(column)
+		}
+	}
+	if opts.Freshness != nil {
+		names, _ := opts.Freshness.columns()
+		for _, name := range names {
+			if column := batch.Column(name); column != nil && result.Column(name) == nil {
+				result.AddColumn(column)
 			}
 		}
 	}
 
-	return featureResult, err
+	return result, nil
+}
+
+// getFeaturesBatchFromRows converts the rows of the join ids to a batch with the types of the fields, the freshness
+// fields follow them
+func (f *BaseFeatureView) getFeaturesBatchFromRows(ctx context.Context, joinIds []interface{}, selectFields []string, opts FeatureViewOptions) (*columnar.FeatureBatch, error) {
+	featureResult, err := f.getFreshFeatures(ctx, joinIds, selectFields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldTypes := make(map[string]constants.FSType, len(f.Fields))
+	for _, field := range f.Fields {
+		fieldTypes[field.Name] = field.Type
+	}
+	names := selectFields
+	types := make([]constants.FSType, len(selectFields))
+	for i, field := range selectFields {
+		types[i] = fieldTypes[field]
+	}
+	if opts.Freshness != nil {
+		freshnessNames, freshnessTypes := opts.Freshness.columns()
+		names = append(slices.Clip(names), freshnessNames...)
+		types = append(types, freshnessTypes...)
+	}
+	aligned := alignFeatures(joinIds, featureResult, f.primaryKeyField.Name)
+
+	return columnar.FromRows(aligned.Rows, names, types), nil
 }
 
 func (f *BaseFeatureView) GetOnlineAggregatedFeatures(joinIds []interface{}, features []string, alias map[string]string) (map[string]interface{}, error) {
@@ -289,3 +728,7 @@ func (f *BaseFeatureView) RowCountIds(expr string) ([]string, int, error) {
 func (f *BaseFeatureView) ScanAndIterateData(filter string, ch chan<- string) ([]string, error) {
 	return f.featureViewDao.ScanAndIterateData(filter, ch)
 }
+
+func (f *BaseFeatureView) Close() error {
+	return f.featureViewDao.Close()
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+// DefaultedFeaturesKey is the key of the names of the defaulted features of a row, see DefaultValues.ReportDefaulted
+const DefaultedFeaturesKey = "__defaulted_features__"
+
+// DefaultValues configures the values of the features missing in the rows of the reads, a feature is missing when the
+// row has no entry of it or its value is nil. A join id without a row gets a row of the join id and the default values
+type DefaultValues struct {
+	// Values of the features by the name in the result, the alias name if the feature has one.
+	// The values are shared by the rows and must not be modified
+	Values map[string]interface{}
+
+	// ZeroValues fills the missing features without a value in Values with the zero value of their type, see ZeroValue
+	ZeroValues bool
+
+	// ReportDefaulted adds the names of the filled features of a row as a []string under DefaultedFeaturesKey
+	ReportDefaulted bool
+}
+
+// ZeroValue returns the zero value of the type in the go type the daos read it as, nil if the type is unknown
+func ZeroValue(fsType constants.FSType) interface{} {
+	switch fsType {
+	case constants.FS_INT32:
+		return int32(0)
+	case constants.FS_INT64:
+		return int64(0)
+	case constants.FS_FLOAT:
+		return float32(0)
+	case constants.FS_DOUBLE:
+		return float64(0)
+	case constants.FS_STRING:
+		return ""
+	case constants.FS_BOOLEAN:
+		return false
+	case constants.FS_TIMESTAMP:
+		return time.Time{}
+	case constants.FS_ARRAY_INT32:
+		return []int32{}
+	case constants.FS_ARRAY_INT64:
+		return []int64{}
+	case constants.FS_ARRAY_FLOAT:
+		return []float32{}
+	case constants.FS_ARRAY_DOUBLE:
+		return []float64{}
+	case constants.FS_ARRAY_STRING:
+		return []string{}
+	case constants.FS_ARRAY_ARRAY_FLOAT:
+		return [][]float32{}
+	case constants.FS_MAP_INT32_INT32:
+		return map[int32]int32{}
+	case constants.FS_MAP_INT32_INT64:
+		return map[int32]int64{}
+	case constants.FS_MAP_INT32_FLOAT:
+		return map[int32]float32{}
+	case constants.FS_MAP_INT32_DOUBLE:
+		return map[int32]float64{}
+	case constants.FS_MAP_INT32_STRING:
+		return map[int32]string{}
+	case constants.FS_MAP_INT64_INT32:
+		return map[int64]int32{}
+	case constants.FS_MAP_INT64_INT64:
+		return map[int64]int64{}
+	case constants.FS_MAP_INT64_FLOAT:
+		return map[int64]float32{}
+	case constants.FS_MAP_INT64_DOUBLE:
+		return map[int64]float64{}
+	case constants.FS_MAP_INT64_STRING:
+		return map[int64]string{}
+	case constants.FS_MAP_STRING_INT32:
+		return map[string]int32{}
+	case constants.FS_MAP_STRING_INT64:
+		return map[string]int64{}
+	case constants.FS_MAP_STRING_FLOAT:
+		return map[string]float32{}
+	case constants.FS_MAP_STRING_DOUBLE:
+		return map[string]float64{}
+	case constants.FS_MAP_STRING_STRING:
+		return map[string]string{}
+	default:
+		return nil
+	}
+}
+
+// defaultField is a feature filled by the default values, name is its name in the result
+type defaultField struct {
+	name   string
+	fsType constants.FSType
+
+	// joinId of the feature entity of the feature, set by the model
+	joinId string
+}
+
+// fill sets the missing features of the row
+func (d *DefaultValues) fill(row map[string]interface{}, fields []defaultField) {
+	var defaulted []string
+	for _, field := range fields {
+		if value, ok := row[field.name]; ok && value != nil {
+			continue
+		}
+
+		value, ok := d.Values[field.name]
+		if !ok && d.ZeroValues {
+			value = ZeroValue(field.fsType)
+			ok = value != nil
+		}
+		if !ok {
+			continue
+		}
+
+		row[field.name] = value
+		defaulted = append(defaulted, field.name)
+	}
+
+	if d.ReportDefaulted && len(defaulted) > 0 {
+		row[DefaultedFeaturesKey] = defaulted
+	}
+}
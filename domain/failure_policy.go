@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+)
+
+// FailurePolicy decides whether a read fails when the features of some of its keys could not be read, see
+// dao.PartialResult. The keys of the read that failed and are tolerated are reported to PartialFailures
+type FailurePolicy struct {
+	// MaxFailedPercent is the percentage of the keys of a read of a feature view that may fail, 0 tolerates no
+	// failed key and 100 tolerates any
+	MaxFailedPercent float64
+}
+
+// FailFast fails the read when any key failed
+func FailFast() *FailurePolicy {
+	return &FailurePolicy{}
+}
+
+// BestEffort returns the features of the keys that were read however many keys failed
+func BestEffort() *FailurePolicy {
+	return &FailurePolicy{MaxFailedPercent: 100}
+}
+
+// ErrorThreshold fails the read when more than percent of its keys failed
+func ErrorThreshold(percent float64) *FailurePolicy {
+	return &FailurePolicy{MaxFailedPercent: percent}
+}
+
+// check returns the *dao.PartialError of the result when its failed keys are more than the policy tolerates
+func (p *FailurePolicy) check(result *dao.PartialResult, keys int) error {
+	failed := len(result.FailedKeys())
+	if failed == 0 || keys == 0 {
+		return nil
+	}
+	if float64(failed)*100/float64(keys) > p.MaxFailedPercent {
+		return result.Err()
+	}
+
+	return nil
+}
+
+// PartialFailures collects the failed keys a FailurePolicy tolerated by feature view, the reads of the feature views
+// of a model report to it concurrently
+type PartialFailures struct {
+	mu       sync.Mutex
+	failures map[string][]*dao.BatchError
+}
+
+func (p *PartialFailures) add(featureViewName string, failures []*dao.BatchError) {
+	if p == nil || len(failures) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures == nil {
+		p.failures = make(map[string][]*dao.BatchError)
+	}
+	p.failures[featureViewName] = append(p.failures[featureViewName], failures...)
+}
+
+// FeatureViews returns the names of the feature views with failed keys in order
+func (p *PartialFailures) FeatureViews() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.failures))
+	for name := range p.failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Failures returns the failed batches of keys of the feature view
+func (p *PartialFailures) Failures(featureViewName string) []*dao.BatchError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*dao.BatchError(nil), p.failures[featureViewName]...)
+}
+
+// FailedKeys returns the failed keys of the feature view
+func (p *PartialFailures) FailedKeys(featureViewName string) []interface{} {
+	failures := p.Failures(featureViewName)
+	return (&dao.PartialResult{Failures: failures}).FailedKeys()
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+)
+
+// ServedByCache is the store of the rows read from the cache of the feature view, see ServedBy
+const ServedByCache = "cache"
+
+// FallbackConfig is the chain of the online stores a base feature view reads from in order, a read falls back to the
+// next store by the policy, see dao.FallbackPolicy
+type FallbackConfig struct {
+	// Stores are the datasource types of the stores, featuredb or the online datasource type of the project. Default
+	// is featuredb then the online store of the project for the feature views written to FeatureDB
+	Stores []string
+	Policy dao.FallbackPolicy
+}
+
+// ServedBy collects the number of the rows each online store served by feature view, the stores are named by their
+// datasource type and name such as featuredb or hologres/holo_ds, the rows read from the cache are served by
+// ServedByCache. The reads of the feature views of a model report to it concurrently
+type ServedBy struct {
+	mu     sync.Mutex
+	stores map[string]map[string]int
+}
+
+func (s *ServedBy) add(featureViewName, store string, rows int) {
+	if s == nil || rows == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stores == nil {
+		s.stores = make(map[string]map[string]int)
+	}
+	if s.stores[featureViewName] == nil {
+		s.stores[featureViewName] = make(map[string]int)
+	}
+	s.stores[featureViewName][store] += rows
+}
+
+// FeatureViews returns the names of the feature views with served rows in order
+func (s *ServedBy) FeatureViews() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.stores))
+	for name := range s.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Stores returns the number of the rows of the feature view by the store that served them
+func (s *ServedBy) Stores(featureViewName string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stores := make(map[string]int, len(s.stores[featureViewName]))
+	for store, rows := range s.stores[featureViewName] {
+		stores[store] = rows
+	}
+
+	return stores
+}
+
+// report removes dao.ServedByKey of the rows and reports their stores, the rows without it are served by store
+func (s *ServedBy) report(featureViewName, store string, rows []map[string]interface{}) {
+	var counts map[string]int
+	for _, row := range rows {
+		rowStore := store
+		if servedBy, ok := row[dao.ServedByKey].(string); ok {
+			rowStore = servedBy
+			delete(row, dao.ServedByKey)
+		}
+		if s != nil {
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[rowStore]++
+		}
+	}
+
+	for rowStore, rows := range counts {
+		s.add(featureViewName, rowStore, rows)
+	}
+}
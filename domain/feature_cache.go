@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultFeatureCacheMaxEntries = 100000
+	DefaultFeatureCacheTTL        = time.Minute
+)
+
+// FeatureCacheConfig configures the in-process cache of the online features of a feature view
+type FeatureCacheConfig struct {
+	// MaxEntries is the max number of cached entries, the least recently used ones are evicted first.
+	// An entry is the features of a join id read with the same fields, default is DefaultFeatureCacheMaxEntries
+	MaxEntries int
+
+	// TTL of the cached features, capped by the ttl of the feature view.
+	// Default is the ttl of the feature view, or DefaultFeatureCacheTTL if the feature view has none
+	TTL time.Duration
+
+	// NegativeTTL of the join ids without features, 0 means TTL and a negative value disables the caching of them
+	NegativeTTL time.Duration
+}
+
+// FeatureCacheStats are the statistics of the cache of a feature view
+type FeatureCacheStats struct {
+	// Hits is the number of join ids served by the cache, including NegativeHits
+	Hits uint64
+	// NegativeHits is the number of join ids served by the cache without features
+	NegativeHits uint64
+	// Misses is the number of join ids read from the online store
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// FeatureCaches holds the caches of the feature views configured with a FeatureCacheConfig,
+// the caches are kept when the project metadata is refreshed
+type FeatureCaches struct {
+	configs map[string]FeatureCacheConfig
+
+	// caches of project name and feature view name
+	caches sync.Map
+}
+
+// NewFeatureCaches returns the caches of the feature views, configs is keyed by the feature view name
+func NewFeatureCaches(configs map[string]FeatureCacheConfig) *FeatureCaches {
+	return &FeatureCaches{configs: configs}
+}
+
+// Stats returns the statistics of the cache of the feature view, false if it has no cache yet
+func (c *FeatureCaches) Stats(projectName, featureViewName string) (FeatureCacheStats, bool) {
+	value, ok := c.caches.Load(projectName + "/" + featureViewName)
+	if !ok {
+		return FeatureCacheStats{}, false
+	}
+
+	return value.(*featureCache).stats(), true
+}
+
+// get returns the cache of the feature view, nil if it is not configured
+func (c *FeatureCaches) get(projectName, featureViewName string) (*featureCache, FeatureCacheConfig) {
+	if c == nil {
+		return nil, FeatureCacheConfig{}
+	}
+
+	config, ok := c.configs[featureViewName]
+	if !ok {
+		return nil, config
+	}
+
+	maxEntries := config.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultFeatureCacheMaxEntries
+	}
+	value, _ := c.caches.LoadOrStore(projectName+"/"+featureViewName, newFeatureCache(maxEntries))
+
+	return value.(*featureCache), config
+}
+
+// featureCache is a LRU cache of the rows of the join ids
+type featureCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	evictions    atomic.Uint64
+}
+
+type featureCacheEntry struct {
+	key      string
+	rows     []map[string]interface{}
+	expireAt time.Time
+}
+
+func newFeatureCache(maxEntries int) *featureCache {
+	return &featureCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// get returns a copy of the cached rows of key, the rows are empty for a negative entry
+func (c *featureCache) get(key string) ([]map[string]interface{}, bool) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok && time.Now().After(element.Value.(*featureCacheEntry).expireAt) {
+		c.remove(element)
+		ok = false
+	}
+	if !ok {
+		c.mu.Unlock()
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	rows := copyRows(element.Value.(*featureCacheEntry).rows)
+	c.mu.Unlock()
+
+	c.hits.Add(1)
+	if len(rows) == 0 {
+		c.negativeHits.Add(1)
+	}
+
+	return rows, true
+}
+
+// set caches a copy of the rows of key, the callers modify the rows they get
+func (c *featureCache) set(key string, rows []map[string]interface{}, ttl time.Duration) {
+	entry := &featureCacheEntry{
+		key:      key,
+		rows:     copyRows(rows),
+		expireAt: time.Now().Add(ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *featureCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*featureCacheEntry).key)
+}
+
+func (c *featureCache) stats() FeatureCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return FeatureCacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    c.evictions.Load(),
+		Entries:      entries,
+	}
+}
+
+func copyRows(rows []map[string]interface{}) []map[string]interface{} {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	copied := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		copied[i] = make(map[string]interface{}, len(row))
+		for k, v := range row {
+			copied[i][k] = v
+		}
+	}
+
+	return copied
+}
+
+// featureCacheTTL returns the ttl of the found and the missing join ids, featureViewTTL is in seconds
+func featureCacheTTL(config FeatureCacheConfig, featureViewTTL int) (time.Duration, time.Duration) {
+	ttl := config.TTL
+	if featureViewTTL > 0 {
+		if viewTTL := time.Duration(featureViewTTL) * time.Second; ttl <= 0 || ttl > viewTTL {
+			ttl = viewTTL
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultFeatureCacheTTL
+	}
+
+	negativeTTL := config.NegativeTTL
+	if negativeTTL == 0 || negativeTTL > ttl {
+		negativeTTL = ttl
+	}
+
+	return ttl, negativeTTL
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+)
+
+func TestFeatureCache(t *testing.T) {
+	featureViewDao := &fakeFeatureViewDao{}
+	caches := NewFeatureCaches(map[string]FeatureCacheConfig{"item_fea": {MaxEntries: 3, TTL: time.Hour}})
+	cache, config := caches.get("fs_test", "item_fea")
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea", Ttl: 60},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "iid"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  featureViewDao,
+		cache:           cache,
+	}
+	featureView.cacheTTL, featureView.cacheNegativeTTL = featureCacheTTL(config, featureView.Ttl)
+	if featureView.cacheTTL != time.Minute || featureView.cacheNegativeTTL != time.Minute {
+		t.Fatalf("ttl not capped by the feature view, %v %v", featureView.cacheTTL, featureView.cacheNegativeTTL)
+	}
+
+	result, err := featureView.GetOnlineFeatures([]interface{}{"1", "missing"}, []string{"*"}, map[string]string{"price": "p"})
+	if err != nil || len(result) != 1 || result[0]["iid"] != "1" || result[0]["p"] != "price_1" {
+		t.Fatalf("unexpected result, %v, err=%v", result, err)
+	}
+
+	result, err = featureView.GetOnlineFeatures([]interface{}{"1", "2", "missing"}, []string{"*"}, nil)
+	if err != nil || len(result) != 2 || result[0]["iid"] != "1" || result[0]["price"] != "price_1" || result[1]["iid"] != "2" {
+		t.Fatalf("unexpected result, %v, err=%v", result, err)
+	}
+	if len(featureViewDao.keys) != 2 || len(featureViewDao.keys[1]) != 1 || featureViewDao.keys[1][0] != "2" {
+		t.Fatalf("only the missing join ids should be read, %v", featureViewDao.keys)
+	}
+
+	featureView.GetOnlineFeatures([]interface{}{"3"}, []string{"*"}, nil)
+	stats, ok := featureView.CacheStats()
+	if !ok || stats.Hits != 2 || stats.NegativeHits != 1 || stats.Misses != 4 || stats.Evictions != 1 || stats.Entries != 3 {
+		t.Fatalf("unexpected stats, %+v", stats)
+	}
+	if cachedStats, ok := caches.Stats("fs_test", "item_fea"); !ok || cachedStats != stats {
+		t.Fatalf("unexpected stats, %+v", cachedStats)
+	}
+}
+
+func TestFeatureCacheFailedRead(t *testing.T) {
+	featureViewDao := &fakeFeatureViewDao{failedKeys: map[interface{}]bool{"2": true}}
+	cache, config := NewFeatureCaches(map[string]FeatureCacheConfig{"item_fea": {MaxEntries: 10, TTL: time.Hour}}).get("fs_test", "item_fea")
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea"},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "iid"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  featureViewDao,
+		cache:           cache,
+	}
+	featureView.cacheTTL, featureView.cacheNegativeTTL = featureCacheTTL(config, featureView.Ttl)
+
+	// the failed response leaves the join id missing without an error, the read caches no join id as missing
+	for i := 0; i < 2; i++ {
+		result, err := featureView.GetOnlineFeatures([]interface{}{"1", "2", "missing"}, []string{"*"}, nil)
+		if err != nil || len(result) != 1 || result[0]["iid"] != "1" {
+			t.Fatalf("unexpected result, %v, err=%v", result, err)
+		}
+	}
+	if len(featureViewDao.keys) != 2 || len(featureViewDao.keys[1]) != 1 || featureViewDao.keys[1][0] != "missing" {
+		t.Fatalf("expect the missing join id read again, %v", featureViewDao.keys)
+	}
+	if stats, _ := featureView.CacheStats(); stats.NegativeHits != 0 || stats.Entries != 1 {
+		t.Fatalf("unexpected stats, %+v", stats)
+	}
+}
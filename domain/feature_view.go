@@ -2,9 +2,13 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 )
 
 type FeatureView interface {
@@ -36,6 +40,103 @@ type FeatureView interface {
 	ScanAndIterateData(filter string, ch chan<- string) ([]string, error)
 }
 
+// AlignedFeatureView is implemented by the feature views returning the features aligned with the join ids
+type AlignedFeatureView interface {
+	// GetOnlineFeaturesAligned returns the features aligned with joinIds, see AlignedFeatures
+	GetOnlineFeaturesAligned(joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*AlignedFeatures, error)
+}
+
+// FeatureBatchView is implemented by the feature views returning the features as typed columns
+type FeatureBatchView interface {
+	// GetOnlineFeaturesBatch returns the features as typed columns of one row per join id
+	GetOnlineFeaturesBatch(joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*columnar.FeatureBatch, error)
+}
+
+// StructFeatureView is implemented by the feature views decoding the features into structs
+type StructFeatureView interface {
+	// GetOnlineFeaturesInto decodes the features into the structs of dest by their fs tags
+	GetOnlineFeaturesInto(ctx context.Context, joinIds []interface{}, dest interface{}) error
+}
+
+// ErrNotSupported is returned by the helpers of the optional feature view interfaces when the feature view does not
+// implement the interface
+var ErrNotSupported = errors.New("the feature view does not support the read")
+
+// GetOnlineFeaturesAligned returns the features of the feature view aligned with joinIds, see AlignedFeatureView
+func GetOnlineFeaturesAligned(featureView FeatureView, joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*AlignedFeatures, error) {
+	alignedView, ok := featureView.(AlignedFeatureView)
+	if !ok {
+		return nil, fmt.Errorf("%w, feature view:%s", ErrNotSupported, featureView.GetName())
+	}
+
+	return alignedView.GetOnlineFeaturesAligned(joinIds, features, alias, opts)
+}
+
+// GetOnlineFeaturesBatch returns the features of the feature view as typed columns, see FeatureBatchView
+func GetOnlineFeaturesBatch(featureView FeatureView, joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*columnar.FeatureBatch, error) {
+	batchView, ok := featureView.(FeatureBatchView)
+	if !ok {
+		return nil, fmt.Errorf("%w, feature view:%s", ErrNotSupported, featureView.GetName())
+	}
+
+	return batchView.GetOnlineFeaturesBatch(joinIds, features, alias, opts)
+}
+
+// GetOnlineFeaturesInto decodes the features of the feature view into the structs of dest, see StructFeatureView
+func GetOnlineFeaturesInto(ctx context.Context, featureView FeatureView, joinIds []interface{}, dest interface{}) error {
+	structView, ok := featureView.(StructFeatureView)
+	if !ok {
+		return fmt.Errorf("%w, feature view:%s", ErrNotSupported, featureView.GetName())
+	}
+
+	return structView.GetOnlineFeaturesInto(ctx, joinIds, dest)
+}
+
+// AlignedFeatures are the features of a read aligned 1:1 with its join ids
+type AlignedFeatures struct {
+	// Rows[i] is the features of the i-th join id, nil if it has none
+	Rows []map[string]interface{}
+
+	// Missing is the join ids without features, in the order of the read
+	Missing []interface{}
+}
+
+// alignFeatures aligns the rows with the join ids by the join id field of the rows, the join ids are compared
+// as strings so the int and string ids of the backends match
+func alignFeatures(joinIds []interface{}, featureResult []map[string]interface{}, joinIdField string) *AlignedFeatures {
+	rowMap := make(map[string]map[string]interface{}, len(featureResult))
+	for _, featureMap := range featureResult {
+		key := utils.ToString(featureMap[joinIdField], "")
+		if _, ok := rowMap[key]; !ok {
+			rowMap[key] = featureMap
+		}
+	}
+
+	aligned := &AlignedFeatures{Rows: make([]map[string]interface{}, len(joinIds))}
+	usedKeys := make(map[string]bool, len(rowMap))
+	for i, joinId := range joinIds {
+		key := utils.ToString(joinId, "")
+		featureMap, ok := rowMap[key]
+		if !ok {
+			aligned.Missing = append(aligned.Missing, joinId)
+			continue
+		}
+
+		// a repeated join id gets its own copy of the row
+		if usedKeys[key] {
+			copied := make(map[string]interface{}, len(featureMap))
+			for k, v := range featureMap {
+				copied[k] = v
+			}
+			featureMap = copied
+		}
+		usedKeys[key] = true
+		aligned.Rows[i] = featureMap
+	}
+
+	return aligned
+}
+
 func NewFeatureView(view *api.FeatureView, p *Project, entity *FeatureEntity) FeatureView {
 	if view.Type == constants.Feature_View_Type_Sequence {
 		return NewSequenceFeatureView(view, p, entity)
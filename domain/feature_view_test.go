@@ -0,0 +1,353 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+)
+
+// fakeFeatureViewDao has a row of each key but "missing" with price_<key> as the price. The event_time of the item 1
+// is a minute ago, the one of the item 2 a day ago and the others have none. It records the keys it reads
+type fakeFeatureViewDao struct {
+	dao.UnimplementedFeatureViewDao
+	// failedKeys fail in the partial reads with a status error
+	failedKeys map[interface{}]bool
+	// columnar reads 1.5 as the price of the keys in a batch
+	columnar bool
+
+	keys [][]interface{}
+}
+
+func (d *fakeFeatureViewDao) GetFeaturesWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) ([]map[string]interface{}, error) {
+	d.keys = append(d.keys, keys)
+	eventTimes := map[interface{}]interface{}{"1": time.Now().Add(-time.Minute), "2": time.Now().Add(-24 * time.Hour).Unix()}
+	var result []map[string]interface{}
+	for _, key := range keys {
+		if key == "missing" {
+			continue
+		}
+		row := map[string]interface{}{"item_id": key, "price": fmt.Sprintf("price_%v", key)}
+		for _, field := range selectFields {
+			if field == "event_time" && eventTimes[key] != nil {
+				row[field] = eventTimes[key]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+func (d *fakeFeatureViewDao) GetFeaturesPartialWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*dao.PartialResult, error) {
+	var readKeys []interface{}
+	partialResult := &dao.PartialResult{}
+	for _, key := range keys {
+		if d.failedKeys[key] {
+			partialResult.Failures = append(partialResult.Failures, &dao.BatchError{Keys: []interface{}{key}, Err: &dao.StatusError{StatusCode: 503}})
+		} else {
+			readKeys = append(readKeys, key)
+		}
+	}
+	partialResult.Rows, _ = d.GetFeaturesWithContext(ctx, readKeys, selectFields, weight)
+	return partialResult, nil
+}
+
+func (d *fakeFeatureViewDao) GetFeaturesBatchWithContext(ctx context.Context, keys []interface{}, selectFields []string, weight int) (*columnar.FeatureBatch, error) {
+	if !d.columnar {
+		return nil, dao.ErrFeatureBatchNotSupported
+	}
+	batch := columnar.NewFeatureBatch([]string{"price"}, []constants.FSType{constants.FS_DOUBLE})
+	prices := batch.Column("price").(*columnar.TypedColumn[float64])
+	for _, key := range keys {
+		if key != "missing" {
+			prices.Append(1.5)
+		}
+		batch.EndRow()
+	}
+	return batch, nil
+}
+
+func TestGetOnlineFeaturesAligned(t *testing.T) {
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea"},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "iid"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  &fakeFeatureViewDao{},
+	}
+
+	aligned, err := GetOnlineFeaturesAligned(featureView, []interface{}{"2", "missing", 1, "2"}, []string{"price"}, nil, FeatureViewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aligned.Rows) != 4 || aligned.Rows[1] != nil {
+		t.Fatalf("rows not aligned, %v", aligned.Rows)
+	}
+	if aligned.Rows[0]["price"] != "price_2" || aligned.Rows[2]["price"] != "price_1" || aligned.Rows[3]["iid"] != "2" {
+		t.Fatalf("rows not aligned, %v", aligned.Rows)
+	}
+	if len(aligned.Missing) != 1 || aligned.Missing[0] != "missing" {
+		t.Fatalf("unexpected missing join ids, %v", aligned.Missing)
+	}
+
+	aligned.Rows[3]["price"] = "changed"
+	if aligned.Rows[0]["price"] != "price_2" {
+		t.Fatal("repeated join ids should not share the row")
+	}
+}
+
+func TestBaseFeatureViewDefaultValues(t *testing.T) {
+	featureView := &BaseFeatureView{
+		FeatureView: &api.FeatureView{Name: "item_fea", Fields: []*api.FeatureViewFields{
+			{Name: "item_id", Type: constants.FS_STRING, IsPrimaryKey: true},
+			{Name: "price", Type: constants.FS_STRING},
+			{Name: "tags", Type: constants.FS_ARRAY_STRING},
+		}},
+		Project:         &Project{defaultValues: &DefaultValues{ZeroValues: true, ReportDefaulted: true}},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "item_id"}},
+		featureFields:   []string{"price", "tags"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  &fakeFeatureViewDao{},
+	}
+
+	features, err := featureView.GetOnlineFeatures([]interface{}{"1"}, []string{"*"}, map[string]string{"tags": "item_tags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 1 || features[0]["price"] != "price_1" {
+		t.Fatalf("unexpected features, %v", features)
+	}
+	if tags, ok := features[0]["item_tags"].([]string); !ok || len(tags) != 0 {
+		t.Fatalf("tags not defaulted, %v", features[0])
+	}
+	if defaulted := features[0][DefaultedFeaturesKey].([]string); len(defaulted) != 1 || defaulted[0] != "item_tags" {
+		t.Fatalf("unexpected defaulted features, %v", defaulted)
+	}
+
+	// the join ids without a row get a row of the default values
+	features, err = featureView.GetOnlineFeatures([]interface{}{"1", "missing", "missing"}, []string{"price"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 || features[0]["price"] != "price_1" {
+		t.Fatalf("unexpected features, %v", features)
+	}
+	if features[1]["item_id"] != "missing" || features[1]["price"] != "" {
+		t.Fatalf("missing join id not defaulted, %v", features[1])
+	}
+	if defaulted := features[1][DefaultedFeaturesKey].([]string); len(defaulted) != 1 || defaulted[0] != "price" {
+		t.Fatalf("unexpected defaulted features, %v", defaulted)
+	}
+}
+
+func TestGetOnlineFeaturesBatch(t *testing.T) {
+	for _, featureViewDao := range []*fakeFeatureViewDao{{}, {columnar: true}} {
+		featureView := &BaseFeatureView{
+			FeatureView: &api.FeatureView{Name: "item_fea", Fields: []*api.FeatureViewFields{
+				{Name: "item_id", Type: constants.FS_STRING, IsPrimaryKey: true},
+				{Name: "price", Type: constants.FS_STRING},
+			}},
+			FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "iid"}},
+			featureFields:   []string{"price"},
+			primaryKeyField: api.FeatureViewFields{Name: "item_id", Type: constants.FS_STRING},
+			featureViewDao:  featureViewDao,
+		}
+
+		batch, err := featureView.GetOnlineFeaturesBatch([]interface{}{"2", "missing", 1}, []string{"price"}, map[string]string{"price": "item_price"}, FeatureViewOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.NumRows() != 3 || len(batch.Columns()) != 2 {
+			t.Fatalf("expect 3 rows of 2 columns, got %v", batch.Rows())
+		}
+
+		ids, _, ok := columnar.Values[string](batch, "iid")
+		if !ok || ids[0] != "2" || ids[2] != "1" {
+			t.Fatalf("unexpected join id column %v", ids)
+		}
+		prices := batch.Column("item_price")
+		if prices == nil || !prices.IsNull(1) || prices.IsNull(0) || prices.IsNull(2) {
+			t.Fatalf("unexpected price column %v", batch.Rows())
+		}
+		var expected interface{} = "price_1"
+		if featureViewDao.columnar {
+			expected = 1.5
+		}
+		if prices.Value(2) != expected {
+			t.Fatalf("expect price %v, got %v", expected, prices.Value(2))
+		}
+	}
+}
+
+type itemStruct struct {
+	ItemId string  `fs:"iid"`
+	Price  *string `fs:"price"`
+	Other  int
+}
+
+func TestGetOnlineFeaturesInto(t *testing.T) {
+	featureView := &BaseFeatureView{
+		FeatureView: &api.FeatureView{Name: "item_fea", Fields: []*api.FeatureViewFields{
+			{Name: "item_id", Type: constants.FS_STRING, IsPrimaryKey: true},
+			{Name: "price", Type: constants.FS_STRING},
+			{Name: "ds", Type: constants.FS_STRING, IsPartition: true},
+		}},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "iid"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id", Type: constants.FS_STRING},
+		featureViewDao:  &fakeFeatureViewDao{},
+	}
+
+	var items []*itemStruct
+	if err := featureView.GetOnlineFeaturesInto(context.Background(), []interface{}{"2", "missing", "1"}, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[1] != nil {
+		t.Fatalf("items not aligned, %v", items)
+	}
+	if items[0].ItemId != "2" || *items[0].Price != "price_2" || *items[2].Price != "price_1" {
+		t.Fatalf("unexpected items, %+v %+v", items[0], items[2])
+	}
+
+	var mismatched []struct {
+		Price int64 `fs:"price"`
+	}
+	err := featureView.GetOnlineFeaturesInto(context.Background(), []interface{}{"1"}, &mismatched)
+	if err == nil || !strings.Contains(err.Error(), "not compatible with feature price of type FS_STRING") {
+		t.Fatalf("expect schema mismatch error, got %v", err)
+	}
+	var unknown []struct {
+		Ds string `fs:"ds"`
+	}
+	err = featureView.GetOnlineFeaturesInto(context.Background(), []interface{}{"1"}, &unknown)
+	if err == nil || !strings.Contains(err.Error(), "feature ds not found in feature view item_fea") {
+		t.Fatalf("expect unknown feature error, got %v", err)
+	}
+}
+
+func TestStructDecoderCoercion(t *testing.T) {
+	type typedStruct struct {
+		Count   int64             `fs:"count"`
+		Score   float64           `fs:"score"`
+		Created time.Time         `fs:"created"`
+		Updated *time.Time        `fs:"updated"`
+		Ids     []int64           `fs:"ids"`
+		Weights map[int64]float64 `fs:"weights"`
+	}
+	schema := map[string]constants.FSType{
+		"count":   constants.FS_INT32,
+		"score":   constants.FS_FLOAT,
+		"created": constants.FS_TIMESTAMP,
+		"updated": constants.FS_TIMESTAMP,
+		"ids":     constants.FS_ARRAY_INT32,
+		"weights": constants.FS_MAP_INT32_FLOAT,
+	}
+	var decoders structDecoders
+	var dest []typedStruct
+	decoder, err := decoders.decoderOf(&dest, "test", func() map[string]constants.FSType { return schema })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.UnixMilli(1700000000000)
+	rows := []map[string]interface{}{
+		{"count": int32(3), "score": float32(1.5), "created": created, "updated": int64(1700000000000), "ids": []int32{1, 2}, "weights": map[int32]float32{1: 0.5}},
+		{"count": int32(4)},
+	}
+	if err := decoder.decode(rows, &dest); err != nil {
+		t.Fatal(err)
+	}
+	if dest[0].Count != 3 || dest[0].Score != 1.5 || !dest[0].Created.Equal(created) || !dest[0].Updated.Equal(created) {
+		t.Fatalf("unexpected decoded struct %+v", dest[0])
+	}
+	if len(dest[0].Ids) != 2 || dest[0].Ids[1] != 2 || dest[0].Weights[1] != 0.5 {
+		t.Fatalf("unexpected decoded struct %+v", dest[0])
+	}
+	if dest[1].Count != 4 || dest[1].Updated != nil || dest[1].Ids != nil {
+		t.Fatalf("unexpected decoded struct %+v", dest[1])
+	}
+
+	if _, err := decoders.decoderOf(dest, "test", nil); err == nil {
+		t.Fatal("expect error of non pointer dest")
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea"},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "item_id"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  &fakeFeatureViewDao{failedKeys: map[interface{}]bool{"bad": true}},
+	}
+	joinIds := []interface{}{"1", "bad", "2"}
+
+	var partialErr *dao.PartialError
+	_, err := featureView.GetOnlineFeaturesWithOptions(joinIds, []string{"price"}, nil, FeatureViewOptions{FailurePolicy: FailFast()})
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("expect partial error, got %v", err)
+	}
+	_, err = featureView.GetOnlineFeaturesWithOptions(joinIds, []string{"price"}, nil, FeatureViewOptions{FailurePolicy: ErrorThreshold(30)})
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("expect partial error above the threshold, got %v", err)
+	}
+
+	for _, policy := range []*FailurePolicy{BestEffort(), ErrorThreshold(50)} {
+		failures := &PartialFailures{}
+		features, err := featureView.GetOnlineFeaturesWithOptions(joinIds, []string{"price"}, nil, FeatureViewOptions{FailurePolicy: policy, PartialFailures: failures})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(features) != 2 {
+			t.Fatalf("expect the features of 2 keys, got %v", features)
+		}
+		if failedKeys := failures.FailedKeys("item_fea"); len(failedKeys) != 1 || failedKeys[0] != "bad" {
+			t.Fatalf("unexpected failed keys %v", failedKeys)
+		}
+	}
+
+	// without a policy the dao reads as it always did
+	features, err := featureView.GetOnlineFeatures(joinIds, []string{"price"}, nil)
+	if err != nil || len(features) != 3 {
+		t.Fatalf("unexpected features %v, %v", features, err)
+	}
+}
+
+func TestServedBy(t *testing.T) {
+	fallbackDao := dao.NewFallbackFeatureViewDao([]dao.FeatureViewDao{&fakeFeatureViewDao{failedKeys: map[interface{}]bool{"bad": true}}, &fakeFeatureViewDao{}},
+		[]string{"featuredb", "hologres/holo"}, dao.FallbackPolicy{OnError: true}, dao.DaoConfig{PrimaryKeyField: "item_id"})
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea"},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "item_id"}},
+		featureFields:   []string{"price"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		featureViewDao:  fallbackDao,
+	}
+
+	servedBy := &ServedBy{}
+	features, err := featureView.GetOnlineFeaturesWithOptions([]interface{}{"1", "bad", "2"}, []string{"price"}, nil, FeatureViewOptions{ServedBy: servedBy})
+	if err != nil || len(features) != 3 {
+		t.Fatalf("unexpected features %v, %v", features, err)
+	}
+	for _, feature := range features {
+		if _, ok := feature[dao.ServedByKey]; ok {
+			t.Fatalf("expect the store removed from the features, got %v", feature)
+		}
+	}
+	if stores := servedBy.Stores("item_fea"); len(stores) != 2 || stores["featuredb"] != 2 || stores["hologres/holo"] != 1 {
+		t.Fatalf("unexpected stores %v", stores)
+	}
+
+	// the store is removed without a ServedBy too
+	features, err = featureView.GetOnlineFeatures([]interface{}{"bad"}, []string{"price"}, nil)
+	if err != nil || len(features) != 1 || features[0][dao.ServedByKey] != nil {
+		t.Fatalf("unexpected features %v, %v", features, err)
+	}
+}
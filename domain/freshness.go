@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+)
+
+// Default names of the freshness fields of the rows, see Freshness
+const (
+	DefaultFreshnessEventTimeField = "__event_time__"
+	DefaultFreshnessAgeField       = "__age__"
+	DefaultFreshnessStaleField     = "__stale__"
+)
+
+// Freshness adds the event time of each row of a base feature view and its age to the row, the age of the rows is
+// recorded in the metrics.FeatureAgeSeconds histogram of the feature view. The event time is the value of the event
+// time field of the feature view, the rows without one have no freshness fields and are never stale. In a batch the age is an
+// int64 column of the nanoseconds of the time.Duration
+type Freshness struct {
+	// EventTimeField is the name of the event time as a time.Time in the rows, default is DefaultFreshnessEventTimeField
+	EventTimeField string
+	// AgeField is the name of the age as a time.Duration in the rows, default is DefaultFreshnessAgeField
+	AgeField string
+	// StaleField is the name of the bool flag of the rows older than StaleAfter, default is DefaultFreshnessStaleField
+	StaleField string
+
+	// StaleAfter is the age the rows are stale after, 0 means they are never stale
+	StaleAfter time.Duration
+	// DropStale drops the stale rows instead of flagging them, their join ids are missing in the result
+	DropStale bool
+}
+
+func (fr *Freshness) fieldNames() (eventTimeField, ageField, staleField string) {
+	eventTimeField, ageField, staleField = fr.EventTimeField, fr.AgeField, fr.StaleField
+	if eventTimeField == "" {
+		eventTimeField = DefaultFreshnessEventTimeField
+	}
+	if ageField == "" {
+		ageField = DefaultFreshnessAgeField
+	}
+	if staleField == "" {
+		staleField = DefaultFreshnessStaleField
+	}
+
+	return
+}
+
+// forFeatureView returns the freshness of a feature view of a model, its fields are prefixed with the name of the
+// feature view and a colon such as item_fea:__age__ so the fields of the feature views do not overwrite each other
+func (fr *Freshness) forFeatureView(name string) *Freshness {
+	if fr == nil {
+		return nil
+	}
+
+	eventTimeField, ageField, staleField := fr.fieldNames()
+	prefixed := *fr
+	prefixed.EventTimeField = name + ":" + eventTimeField
+	prefixed.AgeField = name + ":" + ageField
+	prefixed.StaleField = name + ":" + staleField
+
+	return &prefixed
+}
+
+// columns returns the names and the types of the freshness fields in a batch
+func (fr *Freshness) columns() ([]string, []constants.FSType) {
+	eventTimeField, ageField, staleField := fr.fieldNames()
+	names := []string{eventTimeField, ageField}
+	types := []constants.FSType{constants.FS_TIMESTAMP, constants.FS_INT64}
+	if fr.StaleAfter > 0 {
+		names = append(names, staleField)
+		types = append(types, constants.FS_BOOLEAN)
+	}
+
+	return names, types
+}
+
+// readFields returns the fields to read for the freshness of the feature view, the event time is read even when it is
+// not selected. The event time field is empty when the feature view has no event time
+func (fr *Freshness) readFields(f *BaseFeatureView, selectFields []string) (fields []string, eventTimeField string, selected bool) {
+	eventTimeField = f.eventTimeField.Name
+	if eventTimeField == "" || slices.Contains(selectFields, eventTimeField) {
+		return selectFields, eventTimeField, true
+	}
+	fields = make([]string, len(selectFields), len(selectFields)+1)
+	copy(fields, selectFields)
+
+	return append(fields, eventTimeField), eventTimeField, false
+}
+
+// apply adds the freshness fields to the rows read with the event time field and returns the rows not dropped as
+// stale, the event time field is removed from the rows when it is not selected
+func (fr *Freshness) apply(f *BaseFeatureView, rows []map[string]interface{}, timeField string, selected bool) []map[string]interface{} {
+	eventTimeField, ageField, staleField := fr.fieldNames()
+
+	var collector metrics.MetricsCollector
+	var labels metrics.Labels
+	if f.Project != nil && f.Project.metrics != nil {
+		collector = f.Project.metrics
+		labels = metrics.Labels{Project: f.Project.ProjectName, FeatureView: f.Name}
+	}
+
+	now := time.Now()
+	result := rows[:0]
+	stale := 0
+	for _, row := range rows {
+		eventTime, ok := dao.EventTime(row[timeField])
+		if !selected {
+			delete(row, timeField)
+		}
+		if !ok {
+			result = append(result, row)
+			continue
+		}
+
+		age := now.Sub(eventTime)
+		if collector != nil {
+			collector.ObserveHistogram(metrics.FeatureAgeSeconds, labels, age.Seconds())
+		}
+		isStale := fr.StaleAfter > 0 && age > fr.StaleAfter
+		if isStale {
+			stale++
+			if fr.DropStale {
+				continue
+			}
+		}
+
+		row[eventTimeField] = eventTime
+		row[ageField] = age
+		if fr.StaleAfter > 0 {
+			row[staleField] = isStale
+		}
+		result = append(result, row)
+	}
+	if collector != nil && stale > 0 {
+		collector.AddCounter(metrics.StaleRowsTotal, labels, float64(stale))
+	}
+
+	return result
+}
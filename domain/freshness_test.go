@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+)
+
+func TestFreshness(t *testing.T) {
+	collector := metrics.NewInMemoryCollector()
+	featureView := &BaseFeatureView{
+		FeatureView:     &api.FeatureView{Name: "item_fea"},
+		Project:         &Project{Project: &api.Project{ProjectName: "fs_test"}, metrics: collector},
+		FeatureEntity:   &FeatureEntity{FeatureEntity: &api.FeatureEntity{FeatureEntityJoinid: "item_id"}},
+		featureFields:   []string{"price", "event_time"},
+		primaryKeyField: api.FeatureViewFields{Name: "item_id"},
+		eventTimeField:  api.FeatureViewFields{Name: "event_time"},
+		featureViewDao:  &fakeFeatureViewDao{},
+	}
+	joinIds := []interface{}{"1", "2", "3"}
+
+	freshness := &Freshness{StaleAfter: time.Hour}
+	features, err := featureView.GetOnlineFeaturesWithOptions(joinIds, []string{"price"}, nil, FeatureViewOptions{Freshness: freshness})
+	if err != nil || len(features) != 3 {
+		t.Fatalf("unexpected features %v, %v", features, err)
+	}
+	for _, feature := range features {
+		if _, ok := feature["event_time"]; ok {
+			t.Fatalf("expect the event time not selected removed, got %v", feature)
+		}
+		switch feature["item_id"] {
+		case "1":
+			if age, ok := feature[DefaultFreshnessAgeField].(time.Duration); !ok || age < time.Minute || feature[DefaultFreshnessStaleField] != false {
+				t.Fatalf("expect the fresh row, got %v", feature)
+			}
+		case "2":
+			if _, ok := feature[DefaultFreshnessEventTimeField].(time.Time); !ok || feature[DefaultFreshnessStaleField] != true {
+				t.Fatalf("expect the stale row flagged, got %v", feature)
+			}
+		case "3":
+			if _, ok := feature[DefaultFreshnessAgeField]; ok {
+				t.Fatalf("expect no freshness without event time, got %v", feature)
+			}
+		}
+	}
+
+	labels := metrics.Labels{Project: "fs_test", FeatureView: "item_fea"}
+	if h, ok := collector.Histogram(metrics.FeatureAgeSeconds, labels); !ok || h.Count != 2 || h.Quantile(0.5) > 300 {
+		t.Fatalf("unexpected feature age histogram %+v", h)
+	}
+	if v := collector.Counter(metrics.StaleRowsTotal, labels); v != 1 {
+		t.Fatalf("expect 1 stale row, got %v", v)
+	}
+
+	freshness = &Freshness{StaleAfter: time.Hour, DropStale: true, AgeField: "age"}
+	features, err = featureView.GetOnlineFeaturesWithOptions(joinIds, []string{"price", "event_time"}, nil, FeatureViewOptions{Freshness: freshness})
+	if err != nil || len(features) != 2 {
+		t.Fatalf("expect the stale row dropped, got %v, %v", features, err)
+	}
+	for _, feature := range features {
+		if feature["item_id"] == "1" && (feature["event_time"] == nil || feature["age"] == nil) {
+			t.Fatalf("expect the selected event time and the age, got %v", feature)
+		}
+	}
+}
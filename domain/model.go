@@ -3,10 +3,14 @@ package domain
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/utils"
 )
 
@@ -23,6 +27,9 @@ type Model struct {
 	childEntitiesMap          map[string][]string               // parent joinid : children's joinid
 	joinIdFeatureViewCountMap map[string]int                    // joinid: feature view count
 	labelTable                *LabelTable
+	defaultValues             *DefaultValues
+	defaultFields             []defaultField
+	structDecoders            structDecoders
 }
 
 func NewModel(model *api.Model, p *Project, lt *LabelTable) *Model {
@@ -65,6 +72,25 @@ func NewModel(model *api.Model, p *Project, lt *LabelTable) *Model {
 
 	}
 
+	m.defaultValues = p.defaultValues
+	if defaultValues, ok := p.modelDefaultValues[m.Name]; ok {
+		m.defaultValues = defaultValues
+	}
+	if m.defaultValues != nil {
+		for _, feature := range m.Features {
+			featureView := m.featureViewMap[feature.FeatureViewName]
+			if featureView.GetType() == constants.Feature_View_Type_Sequence {
+				continue
+			}
+			featureEntity := m.featureEntityMap[featureView.GetFeatureEntityName()]
+			field := defaultField{name: feature.Name, fsType: constants.FSType(feature.Type), joinId: featureEntity.FeatureEntityJoinid}
+			if feature.AliasName != "" {
+				field.name = feature.AliasName
+			}
+			m.defaultFields = append(m.defaultFields, field)
+		}
+	}
+
 	for _, entity := range m.featureEntityMap {
 		if entity.ParentFeatureEntityId == 0 {
 			m.featureEntityJoinIdList = append(m.featureEntityJoinIdList, entity.FeatureEntityJoinid)
@@ -98,6 +124,25 @@ func (m *Model) GetOnlineFeaturesWithContext(ctx context.Context, joinIds map[st
 }
 
 func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, opts ModelOptions) ([]map[string]interface{}, error) {
+	if m.project == nil || (m.project.metrics == nil && m.project.tracer == nil) {
+		return m.getOnlineFeaturesWithOptions(joinIds, opts)
+	}
+
+	start := time.Now()
+	keys := 0
+	if len(m.featureEntityJoinIdList) > 0 {
+		keys = len(joinIds[m.featureEntityJoinIdList[0]])
+	}
+	ctx, span := m.startSpan(opts.Ctx, "Model.GetOnlineFeatures", keys)
+	opts.Ctx = ctx
+	features, err := m.getOnlineFeaturesWithOptions(joinIds, opts)
+	tracing.End(span, err)
+	m.observeRead(metrics.OperationModelGetOnlineFeatures, start, keys, err)
+
+	return features, err
+}
+
+func (m *Model) getOnlineFeaturesWithOptions(joinIds map[string][]interface{}, opts ModelOptions) ([]map[string]interface{}, error) {
 	ctx := opts.Ctx
 	if ctx == nil {
 		ctx = context.Background()
@@ -106,19 +151,9 @@ func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, o
 		return nil, err
 	}
 
-	size := -1
-	for _, joinid := range m.featureEntityJoinIdList {
-		keys, ok := joinIds[joinid]
-		if !ok {
-			return nil, fmt.Errorf("join id:%s not found", joinid)
-		}
-		if size == -1 {
-			size = len(keys)
-		} else {
-			if size != len(keys) {
-				return nil, fmt.Errorf("join id:%s length not equal", joinid)
-			}
-		}
+	size, err := m.rootJoinIdSize(joinIds)
+	if err != nil {
+		return nil, err
 	}
 
 	var mu sync.Mutex
@@ -139,7 +174,9 @@ func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, o
 				defer wg.Done()
 				var features []map[string]interface{}
 				var err error
-				features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: ctx, DlrmHSTU: opts.DlrmHSTU, count: featureViewCount})
+				fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "root", len(keys))
+				features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: fvCtx, DlrmHSTU: opts.DlrmHSTU, FailurePolicy: opts.FailurePolicy, PartialFailures: opts.PartialFailures, ServedBy: opts.ServedBy, TTL: opts.TTL, Freshness: opts.Freshness.forFeatureView(featureView.GetName()), count: featureViewCount, noDefaultValues: true})
+				tracing.End(span, err)
 				if err != nil {
 					errOnce.Do(func() { firstErr = err })
 					return
@@ -197,7 +234,9 @@ func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, o
 						defer childWg.Done()
 						var features []map[string]interface{}
 						var err error
-						features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: ctx, DlrmHSTU: opts.DlrmHSTU, count: featureViewCount})
+						fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "child", len(keys))
+						features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: fvCtx, DlrmHSTU: opts.DlrmHSTU, FailurePolicy: opts.FailurePolicy, PartialFailures: opts.PartialFailures, ServedBy: opts.ServedBy, TTL: opts.TTL, Freshness: opts.Freshness.forFeatureView(featureView.GetName()), count: featureViewCount, noDefaultValues: true})
+						tracing.End(span, err)
 						if err != nil {
 							childErrOnce.Do(func() { childFirstErr = err })
 							return
@@ -244,6 +283,9 @@ func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, o
 		}
 	}
 	if len(m.childEntitiesMap) == 0 {
+		if !opts.noDefaultValues {
+			m.fillDefaultValues(featuresResult)
+		}
 		return featuresResult, nil
 	}
 
@@ -293,9 +335,66 @@ func (m *Model) GetOnlineFeaturesWithOptions(joinIds map[string][]interface{}, o
 		}
 	}
 
+	if !opts.noDefaultValues {
+		m.fillDefaultValues(featuresResult)
+	}
 	return featuresResult, nil
 }
 
+// GetOnlineFeaturesInto reads the features of the model into the structs of dest by their fs tags, the tags are the
+// alias names of the features or the join ids. dest is a pointer to a slice of structs or struct pointers and gets an
+// element per join id of the root feature entities. The struct is validated against the model features at first use
+func (m *Model) GetOnlineFeaturesInto(ctx context.Context, joinIds map[string][]interface{}, dest interface{}) error {
+	decoder, err := m.structDecoders.decoderOf(dest, "model "+m.Name, m.structSchema)
+	if err != nil {
+		return err
+	}
+
+	featuresResult, err := m.GetOnlineFeaturesWithOptions(joinIds, ModelOptions{Ctx: ctx})
+	if err != nil {
+		return err
+	}
+
+	return decoder.decode(featuresResult, dest)
+}
+
+// structSchema returns the types of the join ids and the features by their name in the rows
+func (m *Model) structSchema() map[string]constants.FSType {
+	schema := make(map[string]constants.FSType, len(m.Features)+len(m.featureEntityJoinIdMap))
+	for joinId := range m.featureEntityJoinIdMap {
+		schema[joinId] = m.joinIdType(joinId)
+	}
+	for _, feature := range m.Features {
+		name := feature.Name
+		if feature.AliasName != "" {
+			name = feature.AliasName
+		}
+		schema[name] = constants.FSType(feature.Type)
+	}
+
+	return schema
+}
+
+// rootJoinIdSize returns the number of the join ids of the root feature entities, they must have the same number
+func (m *Model) rootJoinIdSize(joinIds map[string][]interface{}) (int, error) {
+	size := -1
+	for _, joinid := range m.featureEntityJoinIdList {
+		keys, ok := joinIds[joinid]
+		if !ok {
+			return 0, fmt.Errorf("join id:%s not found", joinid)
+		}
+		if size == -1 {
+			size = len(keys)
+		} else {
+			if size != len(keys) {
+				return 0, fmt.Errorf("join id:%s length not equal", joinid)
+			}
+		}
+	}
+
+	return size, nil
+}
+
 func (m *Model) GetOnlineFeaturesWithEntity(joinIds map[string][]interface{}, featureEntityName string) ([]map[string]interface{}, error) {
 	return m.GetOnlineFeaturesWithEntityWithOptions(joinIds, featureEntityName, ModelOptions{})
 }
@@ -305,6 +404,25 @@ func (m *Model) GetOnlineFeaturesWithEntityWithContext(ctx context.Context, join
 }
 
 func (m *Model) GetOnlineFeaturesWithEntityWithOptions(joinIds map[string][]interface{}, featureEntityName string, opts ModelOptions) ([]map[string]interface{}, error) {
+	if m.project == nil || (m.project.metrics == nil && m.project.tracer == nil) {
+		return m.getOnlineFeaturesWithEntityWithOptions(joinIds, featureEntityName, opts)
+	}
+
+	start := time.Now()
+	keys := 0
+	if featureEntity, ok := m.featureEntityMap[featureEntityName]; ok {
+		keys = len(joinIds[featureEntity.FeatureEntityJoinid])
+	}
+	ctx, span := m.startSpan(opts.Ctx, "Model.GetOnlineFeaturesWithEntity", keys, tracing.String(tracing.AttrFeatureEntity, featureEntityName))
+	opts.Ctx = ctx
+	features, err := m.getOnlineFeaturesWithEntityWithOptions(joinIds, featureEntityName, opts)
+	tracing.End(span, err)
+	m.observeRead(metrics.OperationModelGetOnlineFeaturesWithEntity, start, keys, err)
+
+	return features, err
+}
+
+func (m *Model) getOnlineFeaturesWithEntityWithOptions(joinIds map[string][]interface{}, featureEntityName string, opts ModelOptions) ([]map[string]interface{}, error) {
 	ctx := opts.Ctx
 	if ctx == nil {
 		ctx = context.Background()
@@ -340,7 +458,9 @@ func (m *Model) GetOnlineFeaturesWithEntityWithOptions(joinIds map[string][]inte
 			defer wg.Done()
 			var features []map[string]interface{}
 			var err error
-			features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: ctx, DlrmHSTU: opts.DlrmHSTU, count: featureViewCount})
+			fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "root", len(keys))
+			features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: fvCtx, DlrmHSTU: opts.DlrmHSTU, FailurePolicy: opts.FailurePolicy, PartialFailures: opts.PartialFailures, ServedBy: opts.ServedBy, TTL: opts.TTL, Freshness: opts.Freshness.forFeatureView(featureView.GetName()), count: featureViewCount, noDefaultValues: true})
+			tracing.End(span, err)
 			if err != nil {
 				errOnce.Do(func() { firstErr = err })
 				return
@@ -418,7 +538,9 @@ func (m *Model) GetOnlineFeaturesWithEntityWithOptions(joinIds map[string][]inte
 							defer childWg.Done()
 							var features []map[string]interface{}
 							var err error
-							features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: ctx, DlrmHSTU: opts.DlrmHSTU, count: featureViewCount})
+							fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "child", len(keys))
+							features, err = featureView.GetOnlineFeaturesWithOptions(keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: fvCtx, DlrmHSTU: opts.DlrmHSTU, FailurePolicy: opts.FailurePolicy, PartialFailures: opts.PartialFailures, ServedBy: opts.ServedBy, TTL: opts.TTL, Freshness: opts.Freshness.forFeatureView(featureView.GetName()), count: featureViewCount, noDefaultValues: true})
+							tracing.End(span, err)
 							if err != nil {
 								childErrOnce.Do(func() { childFirstErr = err })
 								return
@@ -481,6 +603,7 @@ func (m *Model) GetOnlineFeaturesWithEntityWithOptions(joinIds map[string][]inte
 		}
 	}
 
+	m.fillDefaultValues(featuresResult, append([]string{joinId}, m.childEntitiesMap[joinId]...)...)
 	return featuresResult, nil
 }
 
@@ -488,7 +611,12 @@ func (m *Model) GetOnlineFeaturesWithAggregatedSequence(userId interface{}, sequ
 	return m.GetOnlineFeaturesWithAggregatedSequenceWithContext(context.Background(), userId, sequenceUserIds, featureEntityName)
 }
 
-func (m *Model) GetOnlineFeaturesWithAggregatedSequenceWithContext(ctx context.Context, userId interface{}, sequenceUserIds []interface{}, featureEntityName string) (map[string]interface{}, error) {
+func (m *Model) GetOnlineFeaturesWithAggregatedSequenceWithContext(ctx context.Context, userId interface{}, sequenceUserIds []interface{}, featureEntityName string) (features map[string]interface{}, err error) {
+	ctx, span := m.startSpan(ctx, "Model.GetOnlineFeaturesWithAggregatedSequence", len(sequenceUserIds), tracing.String(tracing.AttrFeatureEntity, featureEntityName))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -517,9 +645,13 @@ func (m *Model) GetOnlineFeaturesWithAggregatedSequenceWithContext(ctx context.C
 			var currentFeatures []map[string]interface{}
 			var err error
 			if featureView.GetType() == constants.Feature_View_Type_Sequence {
-				features, err = featureView.GetOnlineAggregatedFeaturesWithContext(ctx, sequenceUserIds, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()])
+				fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "root", len(sequenceUserIds))
+				features, err = featureView.GetOnlineAggregatedFeaturesWithContext(fvCtx, sequenceUserIds, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()])
+				tracing.End(span, err)
 			} else {
-				currentFeatures, err = featureView.GetOnlineFeaturesWithContext(ctx, []interface{}{userId}, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()])
+				fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "root", 1)
+				currentFeatures, err = featureView.GetOnlineFeaturesWithContext(fvCtx, []interface{}{userId}, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()])
+				tracing.End(span, err)
 				if len(currentFeatures) > 0 {
 					features = currentFeatures[0]
 				}
@@ -597,7 +729,9 @@ func (m *Model) GetOnlineFeaturesWithAggregatedSequenceWithContext(ctx context.C
 						childWg.Add(1)
 						go func(featureView FeatureView, joinId string, keys []interface{}, featureViewCount int) {
 							defer childWg.Done()
-							features, err := featureView.getOnlineFeaturesWithCountWithContext(ctx, keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], featureViewCount)
+							fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "child", len(keys))
+							features, err := featureView.getOnlineFeaturesWithCountWithContext(fvCtx, keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], featureViewCount)
+							tracing.End(span, err)
 							if err != nil {
 								childErrOnce.Do(func() { childFirstErr = err })
 								return
@@ -658,6 +792,67 @@ func (m *Model) GetOnlineFeaturesWithAggregatedSequenceWithContext(ctx context.C
 	return featuresResult, nil
 }
 
+// startSpan opens the span of a read of the model
+func (m *Model) startSpan(ctx context.Context, spanName string, keys int, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if m.project == nil || m.project.tracer == nil {
+		return tracing.Nop().Start(ctx, spanName)
+	}
+
+	attrs = append(attrs, tracing.String(tracing.AttrProject, m.project.ProjectName), tracing.String(tracing.AttrModel, m.Name),
+		tracing.String(tracing.AttrDatasourceType, m.project.OnlineDatasourceType), tracing.Int(tracing.AttrKeyCount, keys))
+	return m.project.tracer.Start(ctx, spanName, attrs...)
+}
+
+// startFeatureViewSpan opens the span of a feature view read of the model fan-out, stage is root or child entity
+func (m *Model) startFeatureViewSpan(ctx context.Context, featureView FeatureView, joinId, stage string, keys int) (context.Context, tracing.Span) {
+	if m.project == nil || m.project.tracer == nil {
+		return tracing.Nop().Start(ctx, "FeatureView.GetOnlineFeatures")
+	}
+
+	return m.project.tracer.Start(ctx, "FeatureView.GetOnlineFeatures", tracing.String(tracing.AttrModel, m.Name),
+		tracing.String(tracing.AttrFeatureView, featureView.GetName()), tracing.String(tracing.AttrJoinId, joinId),
+		tracing.String(tracing.AttrStage, stage), tracing.Int(tracing.AttrKeyCount, keys))
+}
+
+// observeRead records the metrics of a read of the model, the hits are recorded by the feature views
+func (m *Model) observeRead(operation string, start time.Time, keys int, err error) {
+	if m.project.metrics == nil {
+		return
+	}
+
+	labels := metrics.Labels{
+		Project:        m.project.ProjectName,
+		Model:          m.Name,
+		DatasourceType: m.project.OnlineDatasourceType,
+		Operation:      operation,
+	}
+	metrics.ObserveRead(m.project.metrics, labels, start, keys, -1, err)
+}
+
+// fillDefaultValues fills the missing features of the feature entities of the join ids in the rows, all of them without join ids
+func (m *Model) fillDefaultValues(featuresResult []map[string]interface{}, joinIds ...string) {
+	if m.defaultValues == nil {
+		return
+	}
+
+	fields := m.defaultFields
+	if len(joinIds) > 0 {
+		fields = nil
+		for _, field := range m.defaultFields {
+			if slices.Contains(joinIds, field.joinId) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	for _, featureMap := range featuresResult {
+		m.defaultValues.fill(featureMap, fields)
+	}
+}
+
 func (m *Model) GetLabelPriorityLevel() int {
 	return m.LabelPriorityLevel
 }
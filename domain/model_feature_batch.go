@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
+
+// GetOnlineFeaturesBatch reads the features of the model into a batch of one row per join id of the root feature
+// entities, the first columns are the root join ids and the features are named by their alias. The features of
+// the missing join ids are null, the default values are not filled. The batches of the feature views are merged
+// when the model has no child feature entities and no sequence feature views, otherwise the rows are converted
+func (m *Model) GetOnlineFeaturesBatch(joinIds map[string][]interface{}, opts ModelOptions) (*columnar.FeatureBatch, error) {
+	if m.project == nil || (m.project.metrics == nil && m.project.tracer == nil) {
+		return m.getOnlineFeaturesBatch(joinIds, opts)
+	}
+
+	start := time.Now()
+	keys := 0
+	if len(m.featureEntityJoinIdList) > 0 {
+		keys = len(joinIds[m.featureEntityJoinIdList[0]])
+	}
+	ctx, span := m.startSpan(opts.Ctx, "Model.GetOnlineFeaturesBatch", keys)
+	opts.Ctx = ctx
+	batch, err := m.getOnlineFeaturesBatch(joinIds, opts)
+	tracing.End(span, err)
+	m.observeRead(metrics.OperationModelGetOnlineFeaturesBatch, start, keys, err)
+
+	return batch, err
+}
+
+func (m *Model) getOnlineFeaturesBatch(joinIds map[string][]interface{}, opts ModelOptions) (*columnar.FeatureBatch, error) {
+	ctx := opts.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	size, err := m.rootJoinIdSize(joinIds)
+	if err != nil {
+		return nil, err
+	}
+
+	featureViewNames := m.batchFeatureViewNames()
+	if featureViewNames == nil {
+		opts.noDefaultValues = true
+		featuresResult, err := m.getOnlineFeaturesWithOptions(joinIds, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return m.featureBatchFromRows(featuresResult), nil
+	}
+
+	batches := make([]*columnar.FeatureBatch, len(featureViewNames))
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	for i, featureViewName := range featureViewNames {
+		featureView := m.featureViewMap[featureViewName]
+		joinId := m.featureEntityMap[featureView.GetFeatureEntityName()].FeatureEntityJoinid
+		keys := joinIds[joinId]
+		featureViewCount := len(m.featureEntityJoinIdMap[joinId])
+
+		wg.Add(1)
+		go func(i int, featureView FeatureView, joinId string, keys []interface{}, featureViewCount int) {
+			defer wg.Done()
+			fvCtx, span := m.startFeatureViewSpan(ctx, featureView, joinId, "root", len(keys))
+			batch, err := GetOnlineFeaturesBatch(featureView, keys, m.featureNamesMap[featureView.GetName()], m.aliasNamesMap[featureView.GetName()], FeatureViewOptions{Ctx: fvCtx, DlrmHSTU: opts.DlrmHSTU, FailurePolicy: opts.FailurePolicy, PartialFailures: opts.PartialFailures, ServedBy: opts.ServedBy, TTL: opts.TTL, Freshness: opts.Freshness.forFeatureView(featureView.GetName()), count: featureViewCount})
+			tracing.End(span, err)
+			if err != nil {
+				errOnce.Do(func() { firstErr = err })
+				return
+			}
+			batches[i] = batch
+		}(i, featureView, joinId, keys, featureViewCount)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	result := &columnar.FeatureBatch{}
+	for _, joinId := range m.featureEntityJoinIdList {
+		result.AddColumn(columnar.ColumnFromValues(joinId, m.joinIdType(joinId), joinIds[joinId]))
+	}
+	for _, batch := range batches {
+		if err := result.Merge(batch); err != nil {
+			return nil, err
+		}
+	}
+	for result.NumRows() < size {
+		result.EndRow()
+	}
+
+	return result, nil
+}
+
+// batchFeatureViewNames returns the feature views of the model in the order of its features, nil if the batches
+// of the feature views can not be merged
+func (m *Model) batchFeatureViewNames() []string {
+	if len(m.childEntitiesMap) > 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(m.featureViewMap))
+	seenNames := make(map[string]bool, len(m.featureViewMap))
+	for _, feature := range m.Features {
+		if seenNames[feature.FeatureViewName] {
+			continue
+		}
+		seenNames[feature.FeatureViewName] = true
+		if m.featureViewMap[feature.FeatureViewName].GetType() == constants.Feature_View_Type_Sequence {
+			return nil
+		}
+		names = append(names, feature.FeatureViewName)
+	}
+
+	return names
+}
+
+// featureBatchFromRows converts the rows of the model to a batch with the types of the features, the other
+// values of the rows such as the sequence features follow them in the order of their names
+func (m *Model) featureBatchFromRows(featuresResult []map[string]interface{}) *columnar.FeatureBatch {
+	var names []string
+	var types []constants.FSType
+	seenNames := make(map[string]bool)
+	for _, joinId := range m.featureEntityJoinIdList {
+		if !seenNames[joinId] {
+			seenNames[joinId] = true
+			names = append(names, joinId)
+			types = append(types, m.joinIdType(joinId))
+		}
+	}
+	for _, feature := range m.Features {
+		name := feature.Name
+		if feature.AliasName != "" {
+			name = feature.AliasName
+		}
+		if !seenNames[name] {
+			seenNames[name] = true
+			names = append(names, name)
+			types = append(types, constants.FSType(feature.Type))
+		}
+	}
+
+	var otherNames []string
+	for _, featureMap := range featuresResult {
+		for name := range featureMap {
+			if !seenNames[name] {
+				seenNames[name] = true
+				otherNames = append(otherNames, name)
+			}
+		}
+	}
+	sort.Strings(otherNames)
+	for _, name := range otherNames {
+		names = append(names, name)
+		types = append(types, 0)
+	}
+
+	return columnar.FromRows(featuresResult, names, types)
+}
+
+// joinIdType returns the type of the primary key of the feature views of the join id
+func (m *Model) joinIdType(joinId string) constants.FSType {
+	for _, featureView := range m.featureEntityJoinIdMap[joinId] {
+		for _, field := range featureView.GetFields() {
+			if field.IsPrimaryKey {
+				return field.Type
+			}
+		}
+	}
+
+	return 0
+}
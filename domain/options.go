@@ -1,14 +1,147 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
 
 type FeatureViewOptions struct {
 	Ctx      context.Context
 	DlrmHSTU bool
-	count    int
+
+	// FailurePolicy reads the batches of the keys independently and decides whether the read fails when some of
+	// them failed, nil keeps the behaviour of the online store
+	FailurePolicy *FailurePolicy
+	// PartialFailures collects the failed keys the FailurePolicy tolerated, optional
+	PartialFailures *PartialFailures
+	// ServedBy collects the online stores that served the rows of the base feature views, optional
+	ServedBy *ServedBy
+	// TTL overrides the ttl of the feature view, such as a longer one to tolerate the stale features during an
+	// incident. 0 keeps the ttl of the feature view and a negative value returns the expired rows too. The reads with
+	// it bypass the cache of the feature view
+	TTL time.Duration
+	// Freshness adds the event time and the age to the rows of the base feature views, optional
+	Freshness *Freshness
+
+	count int
+
+	// noDefaultValues is set by the model, it fills the default values of the merged rows
+	noDefaultValues bool
 }
 
 type ModelOptions struct {
 	Ctx      context.Context
 	DlrmHSTU bool
+
+	// FailurePolicy applies to the read of each feature view of the model, see FeatureViewOptions
+	FailurePolicy *FailurePolicy
+	// PartialFailures collects the failed keys the FailurePolicy tolerated by feature view, optional
+	PartialFailures *PartialFailures
+	// ServedBy collects the online stores that served the rows by feature view, optional
+	ServedBy *ServedBy
+	// TTL overrides the ttl of every feature view of the model, see FeatureViewOptions
+	TTL time.Duration
+	// Freshness adds the event time and the age of the rows of each base feature view of the model, the fields are
+	// prefixed with the name of the feature view and a colon such as item_fea:__age__, optional
+	Freshness *Freshness
+
+	// noDefaultValues is set by GetOnlineFeaturesBatch, the missing features of a batch are null
+	noDefaultValues bool
+}
+
+type ProjectOption func(p *Project)
+
+// WithDatasourceRegistry sets the registry of the online store clients, default is datasource.DefaultRegistry()
+func WithDatasourceRegistry(registry datasource.Registry) ProjectOption {
+	return func(p *Project) {
+		p.registry = registry
+	}
+}
+
+// WithReadTracker sets the tracker of the in-flight reads of the feature views
+func WithReadTracker(tracker *dao.ReadTracker) ProjectOption {
+	return func(p *Project) {
+		p.readTracker = tracker
+	}
+}
+
+// WithLogger sets the logger of the project and its feature views, default is logging.Default()
+func WithLogger(logger logging.Logger) ProjectOption {
+	return func(p *Project) {
+		p.logger = logger
+	}
+}
+
+// WithMetricsCollector sets the collector of the metrics of the online reads, the reads are not measured without it
+func WithMetricsCollector(collector metrics.MetricsCollector) ProjectOption {
+	return func(p *Project) {
+		p.metrics = collector
+	}
+}
+
+// WithTracer sets the tracer of the reads of the models and the feature views, no span is opened without it
+func WithTracer(tracer tracing.Tracer) ProjectOption {
+	return func(p *Project) {
+		p.tracer = tracer
+	}
+}
+
+// WithFeatureCaches sets the caches of the online features of the feature views, the features are not cached without it
+func WithFeatureCaches(caches *FeatureCaches) ProjectOption {
+	return func(p *Project) {
+		p.featureCaches = caches
+	}
+}
+
+// WithCoalesceConfig sets the coalescing of the concurrent reads of the feature views, the reads are not coalesced without it
+func WithCoalesceConfig(config *dao.CoalesceConfig) ProjectOption {
+	return func(p *Project) {
+		p.coalesce = config
+	}
+}
+
+// WithCircuitBreakers sets the circuit breakers of the reads of the datasources and the feature views,
+// the reads have no circuit breaker without it
+func WithCircuitBreakers(breakers *dao.CircuitBreakers) ProjectOption {
+	return func(p *Project) {
+		p.circuitBreakers = breakers
+	}
+}
+
+// WithFeatureViewFallbacks sets the chains of the online stores of the base feature views by feature view name,
+// see FallbackConfig
+func WithFeatureViewFallbacks(fallbacks map[string]FallbackConfig) ProjectOption {
+	return func(p *Project) {
+		p.featureViewFallbacks = fallbacks
+	}
+}
+
+// WithFeatureDBExecutorConfig sets the retry and the hedging of the requests to FeatureDB, the client keeps its
+// executor without it, see featuredb.ExecutorConfig
+func WithFeatureDBExecutorConfig(config *featuredb.ExecutorConfig) ProjectOption {
+	return func(p *Project) {
+		p.featureDBExecutor = config
+	}
+}
+
+// WithDefaultValues sets the default values of the missing features of the feature views and the models
+func WithDefaultValues(defaultValues *DefaultValues) ProjectOption {
+	return func(p *Project) {
+		p.defaultValues = defaultValues
+	}
+}
+
+// WithModelDefaultValues sets the default values of the missing features of the models by model name,
+// they replace the default values of WithDefaultValues for the model
+func WithModelDefaultValues(modelDefaultValues map[string]*DefaultValues) ProjectOption {
+	return func(p *Project) {
+		p.modelDefaultValues = modelDefaultValues
+	}
 }
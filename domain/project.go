@@ -1,39 +1,86 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/sync/singleflight"
 
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
-	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/hologres"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/igraph"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/tablestore"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 )
 
 type Project struct {
 	*api.Project
-	OnlineStore      OnlineStore
-	FeatureViewMap   sync.Map
+	OnlineStore    OnlineStore
+	FeatureViewMap sync.Map
+	// FeatureEntityMap is the feature entities by name when the project is published, it must not be modified.
+	//
+	// Deprecated: use FeatureEntities or GetFeatureEntity
 	FeatureEntityMap map[string]*FeatureEntity
 	ModelMap         sync.Map
 	LabelTableMap    sync.Map
 
+	// featureEntities is copied on write, so a published project is read while entities are added
+	featureEntities   atomic.Pointer[map[string]*FeatureEntity]
+	featureEntitiesMu sync.Mutex
+
 	featureViewLoader singleflight.Group
 	modelLoader       singleflight.Group
 	labelTableLoader  singleflight.Group
 
 	apiClient *api.APIClient
+
+	registry datasource.Registry
+
+	// featureDBClient is held from the registry by the project until Close
+	featureDBClient *featuredb.FeatureDBClient
+
+	readTracker *dao.ReadTracker
+
+	logger logging.Logger
+
+	metrics metrics.MetricsCollector
+
+	tracer tracing.Tracer
+
+	featureCaches *FeatureCaches
+
+	coalesce *dao.CoalesceConfig
+
+	circuitBreakers *dao.CircuitBreakers
+
+	featureViewFallbacks map[string]FallbackConfig
+
+	featureDBExecutor *featuredb.ExecutorConfig
+
+	defaultValues      *DefaultValues
+	modelDefaultValues map[string]*DefaultValues
 }
 
-func NewProject(p *api.Project, isInitClient, isTestMode bool) *Project {
+func NewProject(p *api.Project, isInitClient, isTestMode bool, opts ...ProjectOption) (*Project, error) {
 	project := Project{
 		Project:          p,
 		FeatureEntityMap: make(map[string]*FeatureEntity),
+		registry:         datasource.DefaultRegistry(),
+		logger:           logging.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(&project)
 	}
 
 	switch p.OnlineDatasourceType {
@@ -44,7 +91,9 @@ func NewProject(p *api.Project, isInitClient, isTestMode bool) *Project {
 		if isInitClient {
 			dsn := onlineStore.Datasource.GenerateDSN(constants.Datasource_Type_Hologres)
 			useCustomAuth := onlineStore.Datasource.HologresAuth != ""
-			hologres.RegisterHologres(onlineStore.Name, dsn, useCustomAuth)
+			if err := project.registry.RegisterHologres(onlineStore.Name, dsn, useCustomAuth); err != nil {
+				return nil, err
+			}
 		}
 		project.OnlineStore = onlineStore
 	case constants.Datasource_Type_IGraph:
@@ -55,10 +104,10 @@ func NewProject(p *api.Project, isInitClient, isTestMode bool) *Project {
 		if isInitClient {
 			if p.OnlineDataSource.TestMode {
 				client := igraph.NewGraphClient(p.OnlineDataSource.PublicAddress, p.OnlineDataSource.User, p.OnlineDataSource.Pwd)
-				igraph.RegisterGraphClient(onlineStore.Name, client)
+				project.registry.RegisterGraphClient(onlineStore.Name, client)
 			} else {
 				client := igraph.NewGraphClient(p.OnlineDataSource.VpcAddress, p.OnlineDataSource.User, p.OnlineDataSource.Pwd)
-				igraph.RegisterGraphClient(onlineStore.Name, client)
+				project.registry.RegisterGraphClient(onlineStore.Name, client)
 			}
 		}
 		project.OnlineStore = onlineStore
@@ -68,8 +117,9 @@ func NewProject(p *api.Project, isInitClient, isTestMode bool) *Project {
 		}
 
 		if isInitClient {
-			client := onlineStore.Datasource.NewTableStoreClient()
-			tablestore.RegisterTableStoreClient(onlineStore.Name, client)
+			transport := tablestore.NewTransport()
+			client := onlineStore.Datasource.NewTableStoreClientWithTransport(transport)
+			project.registry.RegisterTableStoreClient(onlineStore.Name, tablestore.NewTableStoreClient(client, transport))
 		}
 		project.OnlineStore = onlineStore
 	case constants.Datasource_Type_FeatureDB:
@@ -79,20 +129,70 @@ func NewProject(p *api.Project, isInitClient, isTestMode bool) *Project {
 
 		project.OnlineStore = onlineStore
 	default:
-		panic("not support onlinestore type")
+		return nil, fmt.Errorf("not support onlinestore type, type:%s", p.OnlineDatasourceType)
 	}
 
 	if p.FeatureDBAddress != "" && p.FeatureDBToken != "" {
-		featuredb.InitFeatureDBClient(p.FeatureDBAddress, p.FeatureDBToken, p.FeatureDBVpcAddress, isTestMode)
+		project.featureDBClient = project.registry.InitFeatureDBClient(p.FeatureDBAddress, p.FeatureDBToken, p.FeatureDBVpcAddress, isTestMode)
+		if project.featureDBExecutor != nil && project.featureDBClient != nil {
+			project.featureDBClient.SetExecutorConfig(*project.featureDBExecutor)
+		}
 	}
 
-	return &project
+	return &project, nil
 }
 
 func (p *Project) SetApiClient(apiClient *api.APIClient) {
 	p.apiClient = apiClient
 }
 
+// Close releases the resources of the feature views and the FeatureDB client of the project, the other datasource
+// clients are owned by the registry and not closed
+func (p *Project) Close() error {
+	var errs []error
+	p.FeatureViewMap.Range(func(key, value any) bool {
+		closer, ok := value.(io.Closer)
+		if !ok {
+			return true
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close feature view error, name:%v, err=%v", key, err))
+		}
+		return true
+	})
+	if p.featureDBClient != nil {
+		p.registry.ReleaseFeatureDBClient(p.featureDBClient)
+		p.featureDBClient = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// Drain rejects the new reads of the project and waits until its in-flight reads finish or ctx is done, the project
+// is drained only when it is built with WithReadTracker
+func (p *Project) Drain(ctx context.Context) error {
+	if p.readTracker == nil {
+		return nil
+	}
+
+	return p.readTracker.Close(ctx)
+}
+
+// FeatureDBClient returns the FeatureDB client of the project, it is resolved in the registry by the FeatureDB address,
+// token and vpc address of the project
+func (p *Project) FeatureDBClient() (*featuredb.FeatureDBClient, error) {
+	if p.featureDBClient != nil {
+		return p.featureDBClient, nil
+	}
+
+	return p.registry.GetFeatureDBClient(p.FeatureDBAddress, p.FeatureDBToken, p.FeatureDBVpcAddress)
+}
+
+// DatasourceRegistry returns the registry of the online store clients used by the project
+func (p *Project) DatasourceRegistry() datasource.Registry {
+	return p.registry
+}
+
 func (p *Project) GetFeatureView(name string) FeatureView {
 	if value, exists := p.FeatureViewMap.Load(name); exists {
 		return value.(FeatureView)
@@ -118,7 +218,30 @@ func (p *Project) GetFeatureView(name string) FeatureView {
 }
 
 func (p *Project) GetFeatureEntity(name string) *FeatureEntity {
-	return p.FeatureEntityMap[name]
+	return p.FeatureEntities()[name]
+}
+
+// FeatureEntities returns the feature entities by name, the map must not be modified
+func (p *Project) FeatureEntities() map[string]*FeatureEntity {
+	if entities := p.featureEntities.Load(); entities != nil {
+		return *entities
+	}
+
+	return nil
+}
+
+// AddFeatureEntity adds or replaces the feature entity of its name
+func (p *Project) AddFeatureEntity(entity *FeatureEntity) {
+	p.featureEntitiesMu.Lock()
+	defer p.featureEntitiesMu.Unlock()
+
+	current := p.FeatureEntities()
+	entities := make(map[string]*FeatureEntity, len(current)+1)
+	for name, e := range current {
+		entities[name] = e
+	}
+	entities[entity.FeatureEntityName] = entity
+	p.featureEntities.Store(&entities)
 }
 
 func (p *Project) GetLabelTable(labelTableId int) *LabelTable {
@@ -194,33 +317,35 @@ func (p *Project) GetModelFeature(name string) *Model {
 }
 
 func (p *Project) loadFeatureView(featureViewName string) error {
+	if p.apiClient == nil {
+		return fmt.Errorf("feature view not exist, name=%s", featureViewName)
+	}
 	pageNumber := 1
 	pageSize := 100
 	for {
 		listFeatureViews, err := p.apiClient.FeatureViewApi.ListFeatureViewsByName(int32(pageSize), int32(pageNumber), strconv.Itoa(p.ProjectId), featureViewName)
 		if err != nil {
-			fmt.Printf("list feature views error, err=%v", err)
+			p.logger.Error("list feature views error", logging.F("project", p.ProjectName), logging.F("feature_view", featureViewName), logging.Err(err))
 			return err
 		}
 		for _, view := range listFeatureViews.FeatureViews {
 			getFeatureViewResponse, err := p.apiClient.FeatureViewApi.GetFeatureViewByID(strconv.Itoa(int(view.FeatureViewId)))
 			if err != nil {
-				fmt.Printf("get feature view error, err=%v", err)
+				p.logger.Error("get feature view error", logging.F("project", p.ProjectName), logging.F("feature_view", view.Name), logging.Err(err))
 				return err
 			}
 			featureView := getFeatureViewResponse.FeatureView
 			if featureView.RegisterDatasourceId > 0 {
 				getDataSourceResponse, err := p.apiClient.DatasourceApi.DatasourceDatasourceIdGet(featureView.RegisterDatasourceId, 0, "")
 				if err != nil {
-					fmt.Printf("get datasource error, err=%v", err)
+					p.logger.Error("get datasource error", logging.F("project", p.ProjectName), logging.F("feature_view", featureView.Name), logging.Err(err))
 					return err
 				}
 				featureView.RegisterDataSource = getDataSourceResponse.Datasource
 			}
 
-			entity, exist := p.FeatureEntityMap[featureView.FeatureEntityName]
-			if !exist {
-				fmt.Printf("feature entity not exist, name=%s", featureView.FeatureEntityName)
+			entity := p.GetFeatureEntity(featureView.FeatureEntityName)
+			if entity == nil {
 				return fmt.Errorf("feature entity not exist, name=%s", featureView.FeatureEntityName)
 			}
 			featureViewDomain := NewFeatureView(featureView, p, entity)
@@ -238,9 +363,12 @@ func (p *Project) loadFeatureView(featureViewName string) error {
 }
 
 func (p *Project) loadLabelTable(labelTableId int) error {
+	if p.apiClient == nil {
+		return fmt.Errorf("label table not exist, id=%d", labelTableId)
+	}
 	getLabelTableResponse, err := p.apiClient.LabelTableApi.GetLabelTableByID(strconv.Itoa(labelTableId))
 	if err != nil {
-		fmt.Printf("get label table error, err=%v", err)
+		p.logger.Error("get label table error", logging.F("project", p.ProjectName), logging.F("label_table_id", labelTableId), logging.Err(err))
 		return err
 	}
 	labelTableDomain := NewLabelTable(getLabelTableResponse.LabelTable)
@@ -250,24 +378,26 @@ func (p *Project) loadLabelTable(labelTableId int) error {
 }
 
 func (p *Project) loadModelFeature(modelFeatureName string) error {
+	if p.apiClient == nil {
+		return fmt.Errorf("model not exist, name=%s", modelFeatureName)
+	}
 	pageNumber := 1
 	pageSize := 100
 	for {
 		listModelFeatures, err := p.apiClient.FsModelApi.ListModelsByName(pageSize, pageNumber, strconv.Itoa(p.ProjectId), modelFeatureName)
 		if err != nil {
-			fmt.Printf("list model features error, err=%v", err)
+			p.logger.Error("list model features error", logging.F("project", p.ProjectName), logging.F("model", modelFeatureName), logging.Err(err))
 			return err
 		}
 		for _, m := range listModelFeatures.Models {
 			getModelFeatureResponse, err := p.apiClient.FsModelApi.GetModelByID(strconv.Itoa(m.ModelId))
 			if err != nil {
-				fmt.Printf("get model feature error, err=%v", err)
+				p.logger.Error("get model feature error", logging.F("project", p.ProjectName), logging.F("model", m.Name), logging.Err(err))
 				return err
 			}
 			model := getModelFeatureResponse.Model
 			labelTableDomain := p.GetLabelTable(model.LabelTableId)
 			if labelTableDomain == nil {
-				fmt.Printf("label table not exist, id=%d", model.LabelTableId)
 				return fmt.Errorf("label table not exist, id=%d", model.LabelTableId)
 			}
 			modelDomain := NewModel(model, p, labelTableDomain)
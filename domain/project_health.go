@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+const (
+	AddressTypeVpc    = "vpc"
+	AddressTypePublic = "public"
+)
+
+// ComponentHealth is the result of the health check of a component, such as the control plane or an online store
+type ComponentHealth struct {
+	Name        string        `json:"name"`
+	Healthy     bool          `json:"healthy"`
+	Error       string        `json:"error,omitempty"`
+	Address     string        `json:"address,omitempty"`
+	AddressType string        `json:"address_type,omitempty"`
+	Latency     time.Duration `json:"latency"`
+}
+
+// NewComponentHealth returns the health of the component checked since start, it is unhealthy when err is not nil
+func NewComponentHealth(name string, start time.Time, err error) ComponentHealth {
+	health := ComponentHealth{
+		Name:    name,
+		Healthy: err == nil,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	return health
+}
+
+// CheckHealth checks the online store of the project and the FeatureDB if the project reads from it.
+// The tablestore tables checked are the ones of the loaded feature views
+func (p *Project) CheckHealth(ctx context.Context) []ComponentHealth {
+	var checks []ComponentHealth
+	if p.OnlineDatasourceType != constants.Datasource_Type_FeatureDB {
+		checks = append(checks, p.checkOnlineStore(ctx))
+	}
+
+	if p.usesFeatureDB() {
+		checks = append(checks, p.checkFeatureDB(ctx))
+	}
+
+	return checks
+}
+
+func (p *Project) checkOnlineStore(ctx context.Context) ComponentHealth {
+	start := time.Now()
+	name := p.OnlineStore.GetDatasourceName()
+	switch p.OnlineDatasourceType {
+	case constants.Datasource_Type_Hologres:
+		hologres, err := p.registry.GetHologres(name)
+		if err == nil {
+			err = hologres.Ping(ctx)
+		}
+		return NewComponentHealth(constants.Datasource_Type_Hologres, start, err)
+	case constants.Datasource_Type_IGraph:
+		client, err := p.registry.GetGraphClient(name)
+		if err != nil {
+			return NewComponentHealth(constants.Datasource_Type_IGraph, start, err)
+		}
+
+		health := NewComponentHealth(constants.Datasource_Type_IGraph, start, client.Ping(ctx))
+		health.Address = client.GraphClient.Endpoint
+		return health
+	case constants.Datasource_Type_TableStore:
+		client, err := p.registry.GetTableStoreClient(name)
+		if err != nil {
+			return NewComponentHealth(constants.Datasource_Type_TableStore, start, err)
+		}
+
+		tables := p.tableStoreTables()
+		if len(tables) == 0 {
+			return NewComponentHealth(constants.Datasource_Type_TableStore, start, client.ListTable(ctx))
+		}
+
+		var errs []error
+		for _, table := range tables {
+			if err := client.DescribeTable(ctx, table); err != nil {
+				errs = append(errs, fmt.Errorf("describe table error, table:%s, err=%v", table, err))
+			}
+		}
+		return NewComponentHealth(constants.Datasource_Type_TableStore, start, errors.Join(errs...))
+	default:
+		return NewComponentHealth(p.OnlineDatasourceType, start, fmt.Errorf("not support onlinestore type:%s", p.OnlineDatasourceType))
+	}
+}
+
+// tableStoreTables returns the online tables of the loaded feature views reading from tablestore
+func (p *Project) tableStoreTables() []string {
+	onlineStore, ok := p.OnlineStore.(*TableStoreOnlineStore)
+	if !ok {
+		return nil
+	}
+
+	var tables []string
+	p.FeatureViewMap.Range(func(key, value any) bool {
+		switch featureView := value.(type) {
+		case *BaseFeatureView:
+			if !featureView.WriteToFeatureDB {
+				tables = append(tables, onlineStore.GetTableName(featureView))
+			}
+		case *SequenceFeatureView:
+			if !featureView.WriteToFeatureDB {
+				tables = append(tables, onlineStore.GetSeqOnlineTableName(featureView))
+			}
+		}
+		return true
+	})
+
+	return tables
+}
+
+// usesFeatureDB reports whether the project or one of the loaded feature views reads from FeatureDB
+func (p *Project) usesFeatureDB() bool {
+	if p.OnlineDatasourceType == constants.Datasource_Type_FeatureDB {
+		return true
+	}
+
+	usesFeatureDB := false
+	p.FeatureViewMap.Range(func(key, value any) bool {
+		usesFeatureDB = value.(FeatureView).GetIsWriteToFeatureDB()
+		return !usesFeatureDB
+	})
+
+	return usesFeatureDB
+}
+
+func (p *Project) checkFeatureDB(ctx context.Context) ComponentHealth {
+	start := time.Now()
+	client, err := p.FeatureDBClient()
+	if err != nil {
+		return NewComponentHealth(constants.Datasource_Type_FeatureDB, start, err)
+	}
+
+	address, isVpc, err := client.CheckHealth(ctx)
+	if p.Signature == "" {
+		err = errors.Join(err, errors.New("FeatureDB login is not set, see WithFeatureDBLogin"))
+	}
+
+	health := NewComponentHealth(constants.Datasource_Type_FeatureDB, start, err)
+	health.Address = address
+	health.AddressType = AddressTypePublic
+	if isVpc {
+		health.AddressType = AddressTypeVpc
+	}
+
+	return health
+}
@@ -112,6 +112,14 @@ func NewSequenceFeatureView(view *api.FeatureView, p *Project, entity *FeatureEn
 
 	daoConfig := dao.DaoConfig{
 		DatasourceType:  p.OnlineDatasourceType,
+		Registry:        p.registry,
+		ReadTracker:     p.readTracker,
+		Logger:          p.logger,
+		Metrics:         p.metrics,
+		Tracer:          p.tracer,
+		CircuitBreakers: p.circuitBreakers,
+		ProjectName:     p.ProjectName,
+		FeatureViewName: view.Name,
 		PrimaryKeyField: sequenceFeatureView.userIdField,
 	}
 
@@ -125,6 +133,7 @@ func NewSequenceFeatureView(view *api.FeatureView, p *Project, entity *FeatureEn
 			daoConfig.FeatureDBTableName = sequenceFeatureView.sequenceConfig.ReferencedFeatureViewName
 		}
 		daoConfig.FeatureDBSignature = p.Signature
+		daoConfig.FeatureDBClient, _ = p.FeatureDBClient()
 
 		fieldTypeMap := make(map[string]constants.FSType, len(view.Fields))
 		for _, field := range view.Fields {
@@ -273,7 +282,10 @@ func (f *SequenceFeatureView) GetOnlineFeaturesWithOptions(joinIds []interface{}
 
 	sequenceFeatureResults, err := f.featureViewDao.GetUserSequenceFeatureWithContext(ctx, joinIds, f.userIdField, sequenceConfig, onlineConfig)
 	if err != nil {
-		return nil, err
+		sequenceFeatureResults, err = f.tolerateFailures(ctx, joinIds, sequenceFeatureResults, err, opts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if f.userIdField != f.FeatureEntity.FeatureEntityJoinid {
@@ -286,6 +298,39 @@ func (f *SequenceFeatureView) GetOnlineFeaturesWithOptions(joinIds []interface{}
 	return sequenceFeatureResults, err
 }
 
+// tolerateFailures applies the FailurePolicy of opts to the error of a sequence read. A *dao.PartialError comes with
+// the sequences of the keys that were read, without a policy its failed keys are missing as they always were
+func (f *SequenceFeatureView) tolerateFailures(ctx context.Context, joinIds []interface{}, sequenceFeatureResults []map[string]interface{}, err error, opts FeatureViewOptions) ([]map[string]interface{}, error) {
+	var partialErr *dao.PartialError
+	if !errors.As(err, &partialErr) {
+		if opts.FailurePolicy == nil || ctx.Err() != nil {
+			return nil, err
+		}
+		partialErr = &dao.PartialError{Failures: []*dao.BatchError{{Keys: joinIds, Err: err}}}
+		sequenceFeatureResults = nil
+	}
+	if opts.FailurePolicy == nil {
+		return sequenceFeatureResults, nil
+	}
+
+	partialResult := &dao.PartialResult{Rows: sequenceFeatureResults, Failures: partialErr.Failures}
+	if err := opts.FailurePolicy.check(partialResult, len(joinIds)); err != nil {
+		return nil, fmt.Errorf("read feature view %s failed: %w", f.Name, err)
+	}
+	opts.PartialFailures.add(f.Name, partialErr.Failures)
+
+	return sequenceFeatureResults, nil
+}
+
+func (f *SequenceFeatureView) GetOnlineFeaturesAligned(joinIds []interface{}, features []string, alias map[string]string, opts FeatureViewOptions) (*AlignedFeatures, error) {
+	featureResult, err := f.GetOnlineFeaturesWithOptions(joinIds, features, alias, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return alignFeatures(joinIds, featureResult, f.FeatureEntity.FeatureEntityJoinid), nil
+}
+
 func (f *SequenceFeatureView) GetOnlineAggregatedFeatures(joinIds []interface{}, features []string, alias map[string]string) (map[string]interface{}, error) {
 	return f.GetOnlineAggregatedFeaturesWithContext(context.Background(), joinIds, features, alias)
 }
@@ -438,3 +483,8 @@ func (f *SequenceFeatureView) RowCountIds(string) ([]string, int, error) {
 func (f *SequenceFeatureView) ScanAndIterateData(filter string, ch chan<- string) ([]string, error) {
 	return nil, errors.New("unimplemented")
 }
+
+// Close implements FeatureView.
+func (f *SequenceFeatureView) Close() error {
+	return f.featureViewDao.Close()
+}
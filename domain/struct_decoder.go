@@ -0,0 +1,312 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+)
+
+// StructTag is the tag of the struct fields decoded by GetOnlineFeaturesInto, `fs:"name"` is the feature name
+// or the alias name of the field and `fs:"-"` skips it
+const StructTag = "fs"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var fsTypeNames = map[constants.FSType]string{
+	constants.FS_INT32:             "FS_INT32",
+	constants.FS_INT64:             "FS_INT64",
+	constants.FS_FLOAT:             "FS_FLOAT",
+	constants.FS_DOUBLE:            "FS_DOUBLE",
+	constants.FS_STRING:            "FS_STRING",
+	constants.FS_BOOLEAN:           "FS_BOOLEAN",
+	constants.FS_TIMESTAMP:         "FS_TIMESTAMP",
+	constants.FS_ARRAY_INT32:       "FS_ARRAY_INT32",
+	constants.FS_ARRAY_INT64:       "FS_ARRAY_INT64",
+	constants.FS_ARRAY_FLOAT:       "FS_ARRAY_FLOAT",
+	constants.FS_ARRAY_DOUBLE:      "FS_ARRAY_DOUBLE",
+	constants.FS_ARRAY_STRING:      "FS_ARRAY_STRING",
+	constants.FS_ARRAY_ARRAY_FLOAT: "FS_ARRAY_ARRAY_FLOAT",
+	constants.FS_MAP_INT32_INT32:   "FS_MAP_INT32_INT32",
+	constants.FS_MAP_INT32_INT64:   "FS_MAP_INT32_INT64",
+	constants.FS_MAP_INT32_FLOAT:   "FS_MAP_INT32_FLOAT",
+	constants.FS_MAP_INT32_DOUBLE:  "FS_MAP_INT32_DOUBLE",
+	constants.FS_MAP_INT32_STRING:  "FS_MAP_INT32_STRING",
+	constants.FS_MAP_INT64_INT32:   "FS_MAP_INT64_INT32",
+	constants.FS_MAP_INT64_INT64:   "FS_MAP_INT64_INT64",
+	constants.FS_MAP_INT64_FLOAT:   "FS_MAP_INT64_FLOAT",
+	constants.FS_MAP_INT64_DOUBLE:  "FS_MAP_INT64_DOUBLE",
+	constants.FS_MAP_INT64_STRING:  "FS_MAP_INT64_STRING",
+	constants.FS_MAP_STRING_INT32:  "FS_MAP_STRING_INT32",
+	constants.FS_MAP_STRING_INT64:  "FS_MAP_STRING_INT64",
+	constants.FS_MAP_STRING_FLOAT:  "FS_MAP_STRING_FLOAT",
+	constants.FS_MAP_STRING_DOUBLE: "FS_MAP_STRING_DOUBLE",
+	constants.FS_MAP_STRING_STRING: "FS_MAP_STRING_STRING",
+}
+
+// arrayElemTypes are the element types of the array types
+var arrayElemTypes = map[constants.FSType]constants.FSType{
+	constants.FS_ARRAY_INT32:       constants.FS_INT32,
+	constants.FS_ARRAY_INT64:       constants.FS_INT64,
+	constants.FS_ARRAY_FLOAT:       constants.FS_FLOAT,
+	constants.FS_ARRAY_DOUBLE:      constants.FS_DOUBLE,
+	constants.FS_ARRAY_STRING:      constants.FS_STRING,
+	constants.FS_ARRAY_ARRAY_FLOAT: constants.FS_ARRAY_FLOAT,
+}
+
+// mapKeyValueTypes are the key and the value types of the map types
+var mapKeyValueTypes = map[constants.FSType][2]constants.FSType{
+	constants.FS_MAP_INT32_INT32:   {constants.FS_INT32, constants.FS_INT32},
+	constants.FS_MAP_INT32_INT64:   {constants.FS_INT32, constants.FS_INT64},
+	constants.FS_MAP_INT32_FLOAT:   {constants.FS_INT32, constants.FS_FLOAT},
+	constants.FS_MAP_INT32_DOUBLE:  {constants.FS_INT32, constants.FS_DOUBLE},
+	constants.FS_MAP_INT32_STRING:  {constants.FS_INT32, constants.FS_STRING},
+	constants.FS_MAP_INT64_INT32:   {constants.FS_INT64, constants.FS_INT32},
+	constants.FS_MAP_INT64_INT64:   {constants.FS_INT64, constants.FS_INT64},
+	constants.FS_MAP_INT64_FLOAT:   {constants.FS_INT64, constants.FS_FLOAT},
+	constants.FS_MAP_INT64_DOUBLE:  {constants.FS_INT64, constants.FS_DOUBLE},
+	constants.FS_MAP_INT64_STRING:  {constants.FS_INT64, constants.FS_STRING},
+	constants.FS_MAP_STRING_INT32:  {constants.FS_STRING, constants.FS_INT32},
+	constants.FS_MAP_STRING_INT64:  {constants.FS_STRING, constants.FS_INT64},
+	constants.FS_MAP_STRING_FLOAT:  {constants.FS_STRING, constants.FS_FLOAT},
+	constants.FS_MAP_STRING_DOUBLE: {constants.FS_STRING, constants.FS_DOUBLE},
+	constants.FS_MAP_STRING_STRING: {constants.FS_STRING, constants.FS_STRING},
+}
+
+func fsTypeName(fsType constants.FSType) string {
+	if name, ok := fsTypeNames[fsType]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("FSType(%d)", fsType)
+}
+
+// compatibleType reports whether the values of the feature type can be decoded into the go type. The integers
+// widen to int64 and int, the floats to float64, timestamps are time.Time and a pointer is nil for a missing value
+func compatibleType(t reflect.Type, fsType constants.FSType) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		return true
+	}
+
+	switch fsType {
+	case constants.FS_INT32:
+		return t.Kind() == reflect.Int32 || t.Kind() == reflect.Int64 || t.Kind() == reflect.Int
+	case constants.FS_INT64:
+		return t.Kind() == reflect.Int64 || t.Kind() == reflect.Int
+	case constants.FS_FLOAT:
+		return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+	case constants.FS_DOUBLE:
+		return t.Kind() == reflect.Float64
+	case constants.FS_STRING:
+		return t.Kind() == reflect.String
+	case constants.FS_BOOLEAN:
+		return t.Kind() == reflect.Bool
+	case constants.FS_TIMESTAMP:
+		return t == timeType
+	}
+
+	if elemType, ok := arrayElemTypes[fsType]; ok {
+		return t.Kind() == reflect.Slice && compatibleType(t.Elem(), elemType)
+	}
+	if keyValueTypes, ok := mapKeyValueTypes[fsType]; ok {
+		return t.Kind() == reflect.Map && compatibleType(t.Key(), keyValueTypes[0]) && compatibleType(t.Elem(), keyValueTypes[1])
+	}
+
+	return false
+}
+
+// structDecoder decodes the rows of a read into a struct type, err is the validation error of the struct type
+type structDecoder struct {
+	structType reflect.Type
+	fields     []structField
+	err        error
+}
+
+type structField struct {
+	name  string
+	index int
+}
+
+// structDecoders caches the decoders of the struct types, they are validated against the schema at first use
+type structDecoders struct {
+	decoders sync.Map
+}
+
+// get returns the decoder of the struct type, schema returns the types of the features by their name in the rows
+func (d *structDecoders) get(structType reflect.Type, owner string, schema func() map[string]constants.FSType) *structDecoder {
+	if decoder, ok := d.decoders.Load(structType); ok {
+		return decoder.(*structDecoder)
+	}
+
+	decoder, _ := d.decoders.LoadOrStore(structType, newStructDecoder(structType, owner, schema()))
+	return decoder.(*structDecoder)
+}
+
+func newStructDecoder(structType reflect.Type, owner string, schema map[string]constants.FSType) *structDecoder {
+	decoder := &structDecoder{structType: structType}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, ok := field.Tag.Lookup(StructTag)
+		if !ok || name == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			decoder.err = fmt.Errorf("field %s.%s with tag %s:%q is not exported", structType.Name(), field.Name, StructTag, name)
+			return decoder
+		}
+
+		fsType, ok := schema[name]
+		if !ok {
+			decoder.err = fmt.Errorf("field %s.%s: feature %s not found in %s", structType.Name(), field.Name, name, owner)
+			return decoder
+		}
+		if !compatibleType(field.Type, fsType) {
+			decoder.err = fmt.Errorf("field %s.%s of type %s is not compatible with feature %s of type %s in %s",
+				structType.Name(), field.Name, field.Type, name, fsTypeName(fsType), owner)
+			return decoder
+		}
+
+		decoder.fields = append(decoder.fields, structField{name: name, index: i})
+	}
+
+	return decoder
+}
+
+// decoderOf returns the decoder of the struct type of dest, a pointer to a slice of structs or struct pointers
+func (d *structDecoders) decoderOf(dest interface{}, owner string, schema func() map[string]constants.FSType) (*structDecoder, error) {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Pointer || destValue.IsNil() || destValue.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+	}
+	structType := destValue.Elem().Type().Elem()
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+	}
+
+	decoder := d.get(structType, owner, schema)
+	return decoder, decoder.err
+}
+
+// featureNames returns the names of the tagged fields
+func (d *structDecoder) featureNames() []string {
+	names := make([]string, len(d.fields))
+	for i, field := range d.fields {
+		names[i] = field.name
+	}
+
+	return names
+}
+
+// decode decodes the rows into dest, dest gets an element per row and a nil row is a nil pointer or a zero struct
+func (d *structDecoder) decode(rows []map[string]interface{}, dest interface{}) error {
+	sliceValue := reflect.ValueOf(dest).Elem()
+	isPointer := sliceValue.Type().Elem().Kind() == reflect.Pointer
+
+	slice := reflect.MakeSlice(sliceValue.Type(), len(rows), len(rows))
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+
+		elem := slice.Index(i)
+		if isPointer {
+			elem.Set(reflect.New(d.structType))
+			elem = elem.Elem()
+		}
+		for _, field := range d.fields {
+			if err := assignValue(elem.Field(field.index), row[field.name]); err != nil {
+				return fmt.Errorf("field %s.%s: feature %s %v", d.structType.Name(), d.structType.Field(field.index).Name, field.name, err)
+			}
+		}
+	}
+	sliceValue.Set(slice)
+
+	return nil
+}
+
+// assignValue sets the value to the field, the numbers are converted, the timestamps of unix milliseconds or of the
+// "2006-01-02 15:04:05" layout are parsed and the slices and the maps are converted by element
+func assignValue(field reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	switch {
+	case field.Type() == timeType && v.Kind() == reflect.Int64:
+		field.Set(reflect.ValueOf(time.UnixMilli(v.Int())))
+		return nil
+	case field.Type() == timeType && v.Kind() == reflect.String:
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", v.String(), time.Local)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case isNumberKind(field.Kind()) && isNumberKind(v.Kind()):
+		field.Set(v.Convert(field.Type()))
+		return nil
+	case field.Kind() == reflect.String && v.Kind() == reflect.String:
+		field.SetString(v.String())
+		return nil
+	case field.Kind() == reflect.Slice && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		slice := reflect.MakeSlice(field.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := assignValue(slice.Index(i), v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	case field.Kind() == reflect.Map && v.Kind() == reflect.Map:
+		m := reflect.MakeMapWithSize(field.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := reflect.New(field.Type().Key()).Elem()
+			if err := assignValue(key, iter.Key().Interface()); err != nil {
+				return err
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := assignValue(elem, iter.Value().Interface()); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		field.Set(m)
+		return nil
+	}
+
+	return fmt.Errorf("value of type %T can not be decoded into %s", value, field.Type())
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package featurestore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+)
+
+// apiMetadataSource loads the project metadata from the PAI-FeatureStore server
+type apiMetadataSource struct {
+	client *api.APIClient
+
+	testMode              bool
+	hologresPort          int
+	hologresPublicAddress string
+}
+
+func (s *apiMetadataSource) Validate() error {
+	// check instance
+	return s.client.InstanceApi.GetInstance()
+}
+
+func (s *apiMetadataSource) LoadProject(name string, lazy bool) (*ProjectMetadata, error) {
+	var (
+		pagesize   = 100
+		pagenumber = 1
+	)
+	var project *api.Project
+	for {
+		listProjectsResponse, err := s.client.FsProjectApi.ListProjects(int32(pagesize), int32(pagenumber))
+		if err != nil {
+			return nil, fmt.Errorf("list projects error, err=%v", err)
+		}
+		for _, p := range listProjectsResponse.Projects {
+			if p.ProjectName == name {
+				project = p
+				break
+			}
+		}
+
+		if project != nil || len(listProjectsResponse.Projects) == 0 || pagesize*pagenumber > listProjectsResponse.TotalCount {
+			break
+		}
+
+		pagenumber++
+	}
+
+	if project == nil {
+		return nil, fmt.Errorf("%w, name:%s", ErrProjectNotFound, name)
+	}
+
+	meta := &ProjectMetadata{
+		Project: project,
+	}
+
+	// get datasource
+	getDataSourceResponse, err := s.client.DatasourceApi.DatasourceDatasourceIdGet(project.OnlineDatasourceId, s.hologresPort, s.hologresPublicAddress)
+	if err != nil {
+		return nil, fmt.Errorf("get datasource error, err=%v", err)
+	}
+	project.OnlineDataSource = getDataSourceResponse.Datasource
+
+	getDataSourceResponse, err = s.client.DatasourceApi.DatasourceDatasourceIdGet(project.OfflineDatasourceId, s.hologresPort, s.hologresPublicAddress)
+	if err != nil {
+		return nil, fmt.Errorf("get datasource error, err=%v", err)
+	}
+	project.OfflineDataSource = getDataSourceResponse.Datasource
+
+	// get featuredb datasource
+	featureDB := &FeatureDBMetadata{}
+	featureDB.Address, featureDB.Token, featureDB.VpcAddress, err = s.client.DatasourceApi.GetFeatureDBDatasourceInfo(s.testMode, project.OfflineDataSource.WorkspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("get featuredb datasource, err=%v", err)
+	}
+	meta.FeatureDB = featureDB
+
+	// get feature entities
+	pagenumber = 1
+	for {
+		listFeatureEntitiesResponse, err := s.client.FeatureEntityApi.ListFeatureEntities(int32(pagesize), int32(pagenumber), strconv.Itoa(project.ProjectId))
+		if err != nil {
+			return nil, fmt.Errorf("list feature entities error, err=%v", err)
+		}
+
+		meta.FeatureEntities = append(meta.FeatureEntities, listFeatureEntitiesResponse.FeatureEntities...)
+
+		if len(listFeatureEntitiesResponse.FeatureEntities) == 0 || pagesize*pagenumber > listFeatureEntitiesResponse.TotalCount {
+			break
+		}
+
+		pagenumber++
+	}
+
+	if lazy {
+		return meta, nil
+	}
+
+	// get feature views
+	pagenumber = 1
+	for {
+		listFeatureViews, err := s.client.FeatureViewApi.ListFeatureViews(int32(pagesize), int32(pagenumber), strconv.Itoa(project.ProjectId))
+		if err != nil {
+			return nil, fmt.Errorf("list feature views error, err=%v", err)
+		}
+
+		for _, view := range listFeatureViews.FeatureViews {
+			getFeatureViewResponse, err := s.client.FeatureViewApi.GetFeatureViewByID(strconv.Itoa(int(view.FeatureViewId)))
+			if err != nil {
+				return nil, fmt.Errorf("get feature view error, err=%v", err)
+			}
+			featureView := getFeatureViewResponse.FeatureView
+			if featureView.RegisterDatasourceId > 0 {
+				getDataSourceResponse, err := s.client.DatasourceApi.DatasourceDatasourceIdGet(featureView.RegisterDatasourceId, s.hologresPort, s.hologresPublicAddress)
+				if err != nil {
+					return nil, fmt.Errorf("get datasource error, err=%v", err)
+				}
+				featureView.RegisterDataSource = getDataSourceResponse.Datasource
+			}
+
+			meta.FeatureViews = append(meta.FeatureViews, featureView)
+		}
+
+		if len(listFeatureViews.FeatureViews) == 0 || pagesize*pagenumber > listFeatureViews.TotalCount {
+			break
+		}
+
+		pagenumber++
+	}
+
+	// get models and their label tables
+	labelTableIds := make(map[int]bool)
+	pagenumber = 1
+	for {
+		listModelsResponse, err := s.client.FsModelApi.ListModels(pagesize, pagenumber, strconv.Itoa(project.ProjectId))
+		if err != nil {
+			return nil, fmt.Errorf("list models error, err=%v", err)
+		}
+
+		for _, m := range listModelsResponse.Models {
+			getModelResponse, err := s.client.FsModelApi.GetModelByID(strconv.Itoa(m.ModelId))
+			if err != nil {
+				return nil, fmt.Errorf("get model error, err=%v", err)
+			}
+			model := getModelResponse.Model
+			if !labelTableIds[model.LabelTableId] {
+				getLabelTableResponse, err := s.client.LabelTableApi.GetLabelTableByID(strconv.Itoa(model.LabelTableId))
+				if err != nil {
+					return nil, fmt.Errorf("get label table error, labelTableId:%d, err=%v", model.LabelTableId, err)
+				}
+				labelTableIds[model.LabelTableId] = true
+				meta.LabelTables = append(meta.LabelTables, getLabelTableResponse.LabelTable)
+			}
+
+			meta.Models = append(meta.Models, model)
+		}
+
+		if len(listModelsResponse.Models) == 0 || pagenumber*pagesize > int(listModelsResponse.TotalCount) {
+			break
+		}
+
+		pagenumber++
+	}
+
+	return meta, nil
+}
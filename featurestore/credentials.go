@@ -0,0 +1,163 @@
+package featurestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliyun/credentials-go/credentials"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+)
+
+const (
+	// credentialsRefreshBefore is how long before the expiration the credentials are refreshed
+	credentialsRefreshBefore = 10 * time.Minute
+
+	// credentialsPollInterval is the refresh interval of credentials without expiration
+	credentialsPollInterval = time.Minute
+
+	credentialsRetryInterval = 10 * time.Second
+)
+
+// WithCredentialsProvider set the provider of the credentials instead of the access key of NewFeatureStoreClient.
+// The credentials are refreshed before they expire and pushed to the featurestore server api, the hologres pools
+// and the tablestore clients, see NewDefaultCredentialsProvider
+func WithCredentialsProvider(provider api.CredentialsProvider) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.credentialsProvider = provider
+	}
+}
+
+type defaultCredentialsProvider struct {
+	credential credentials.Credential
+}
+
+// NewDefaultCredentialsProvider returns the provider of the default credential chain of the aliyun credentials sdk,
+// such as the environment variables, the RAM role of the ECS instance or the OIDC token of the pod
+func NewDefaultCredentialsProvider() (api.CredentialsProvider, error) {
+	credential, err := credentials.NewCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &defaultCredentialsProvider{credential: credential}, nil
+}
+
+func (p *defaultCredentialsProvider) GetCredentials(ctx context.Context) (*api.Credentials, error) {
+	credential, err := p.credential.GetCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &api.Credentials{}
+	if credential.AccessKeyId != nil {
+		c.AccessKeyId = *credential.AccessKeyId
+	}
+	if credential.AccessKeySecret != nil {
+		c.AccessKeySecret = *credential.AccessKeySecret
+	}
+	if credential.SecurityToken != nil {
+		c.SecurityToken = *credential.SecurityToken
+	}
+
+	return c, nil
+}
+
+func (c *FeatureStoreClient) getCredentials() (*api.Credentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	credentials, err := c.credentialsProvider.GetCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get credentials error, err=%v", err)
+	}
+
+	return credentials, nil
+}
+
+// refreshCredentials gets the credentials from the provider, the clients signing every request use them at once.
+// When they change the published projects are rebuilt so the hologres pools are connected with them, the credentials
+// are marked as pushed only once every project is rebuilt and the rebuild is retried otherwise
+func (c *FeatureStoreClient) refreshCredentials() error {
+	credentials, err := c.getCredentials()
+	if err != nil {
+		return err
+	}
+
+	c.credentials.Set(credentials)
+	if pushed := c.pushedCredentials.Load(); pushed != nil && pushed.AccessKeyId == credentials.AccessKeyId &&
+		pushed.AccessKeySecret == credentials.AccessKeySecret && pushed.SecurityToken == credentials.SecurityToken {
+		return nil
+	}
+
+	if err := c.rebuildProjects(); err != nil {
+		return fmt.Errorf("push credentials error, err=%v", err)
+	}
+	c.pushedCredentials.Store(credentials)
+
+	return nil
+}
+
+// rebuildProjects rebuilds the published projects from their own metadata with the current credentials, the metadata
+// source is not read
+func (c *FeatureStoreClient) rebuildProjects() error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	var errs []error
+	for name, old := range c.projects() {
+		project, err := c.newProject(newProjectMetadata(old, true))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rebuild project error, name:%s, err=%v", name, err))
+			continue
+		}
+		if !c.replaceProject(old, project) {
+			project.Close()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *FeatureStoreClient) loopRefreshCredentials() {
+	defer c.loopWg.Done()
+
+	wait := nextCredentialsRefresh(c.credentials.Get())
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(wait):
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					c.logError(fmt.Errorf("refresh credentials error, err=%v", r))
+				}
+			}()
+
+			if err := c.refreshCredentials(); err != nil {
+				c.logError(err)
+				wait = credentialsRetryInterval
+				return
+			}
+			wait = nextCredentialsRefresh(c.credentials.Get())
+		}()
+	}
+}
+
+func nextCredentialsRefresh(credentials *api.Credentials) time.Duration {
+	if credentials.Expiration.IsZero() {
+		return credentialsPollInterval
+	}
+
+	wait := time.Until(credentials.Expiration) - credentialsRefreshBefore
+	if wait < credentialsRetryInterval {
+		wait = credentialsRetryInterval
+	}
+
+	return wait
+}
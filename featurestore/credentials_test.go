@@ -0,0 +1,67 @@
+package featurestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+)
+
+type rotatingCredentialsProvider struct {
+	count atomic.Int32
+}
+
+func (p *rotatingCredentialsProvider) GetCredentials(ctx context.Context) (*api.Credentials, error) {
+	n := p.count.Add(1)
+	return &api.Credentials{
+		AccessKeyId:     "ak",
+		AccessKeySecret: "secret",
+		SecurityToken:   "token" + string(rune('0'+n)),
+		Expiration:      time.Now().Add(time.Hour),
+	}, nil
+}
+
+func TestCredentialsRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithCredentialsProvider(&rotatingCredentialsProvider{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token1", project.OnlineDataSource.Ak.SecurityToken)
+
+	// the projects are rebuilt from the published metadata, without the metadata source
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.refreshCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err = client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "token2", project.OnlineDataSource.Ak.SecurityToken)
+	assert.Equal(t, "token2", client.credentials.Get().SecurityToken)
+	assert.Equal(t, "token2", client.pushedCredentials.Load().SecurityToken)
+
+	wait := nextCredentialsRefresh(client.credentials.Get())
+	assert.True(t, wait > 45*time.Minute && wait <= 50*time.Minute)
+}
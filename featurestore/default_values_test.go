@@ -0,0 +1,54 @@
+package featurestore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+func TestDefaultValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(testMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	defer registry.Close()
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry), WithFeatureDBLogin("user", "pwd"),
+		WithDefaultValues(domain.DefaultValues{ZeroValues: true}),
+		WithModelDefaultValues("rank_v1", domain.DefaultValues{Values: map[string]interface{}{"user_city": "unknown"}, ReportDefaulted: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	features, err := project.GetModel("rank_v1").GetOnlineFeaturesWithContext(context.Background(), map[string][]interface{}{"user_id": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(features))
+	assert.Equal(t, "unknown", features[0]["user_city"])
+	if _, ok := features[0]["age"]; ok {
+		t.Fatalf("age has no default value of the model, %v", features[0])
+	}
+	assert.Equal(t, []string{"user_city"}, features[0][domain.DefaultedFeaturesKey].([]string))
+}
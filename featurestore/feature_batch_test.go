@@ -0,0 +1,166 @@
+package featurestore
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fortio.org/assert"
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/columnar"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb/fdbserverfb"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+func TestModelGetOnlineFeaturesBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(testMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	defer registry.Close()
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry), WithFeatureDBLogin("user", "pwd"),
+		WithDefaultValues(domain.DefaultValues{ZeroValues: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := project.GetModel("rank_v1").GetOnlineFeaturesBatch(map[string][]interface{}{"user_id": {"1", "2"}}, domain.ModelOptions{Ctx: context.Background()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, batch.NumRows())
+	ids, _, ok := columnar.Values[string](batch, "user_id")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"1", "2"}, ids)
+
+	// the missing features are null, the default values are not filled
+	city := batch.Column("user_city")
+	assert.True(t, city != nil)
+	assert.Equal(t, 2, city.NullCount())
+}
+
+// freshnessMetadataYaml has the event time field update_time in user_fea
+var freshnessMetadataYaml = strings.Replace(testMetadataYaml, `          - name: city
+            type: 5
+`, `          - name: city
+            type: 5
+          - name: update_time
+            type: 2
+            is_event_time: true
+`, 1)
+
+// featureDBUserRecords returns the record block of the users 1 and 2 of user_fea updated a minute and two hours ago
+func featureDBUserRecords() []byte {
+	builder := flatbuffers.NewBuilder(0)
+	var columns []flatbuffers.UOffsetT
+	for i, age := range []int64{30, 40} {
+		updateTime := time.Now().Add(-time.Minute)
+		if i == 1 {
+			updateTime = time.Now().Add(-2 * time.Hour)
+		}
+		value := binary.LittleEndian.AppendUint64([]byte{'F', '1', 0}, uint64(age))
+		value = append(binary.LittleEndian.AppendUint32(append(value, 0), 2), "hz"...)
+		value = binary.LittleEndian.AppendUint64(append(value, 0), uint64(updateTime.Unix()))
+		data := builder.CreateByteVector(value)
+		fdbserverfb.UInt8ValueColumnStart(builder)
+		fdbserverfb.UInt8ValueColumnAddValue(builder, data)
+		columns = append(columns, fdbserverfb.UInt8ValueColumnEnd(builder))
+	}
+	fdbserverfb.RecordBlockStartValuesVector(builder, len(columns))
+	for i := len(columns) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(columns[i])
+	}
+	values := builder.EndVector(len(columns))
+	fdbserverfb.RecordBlockStart(builder)
+	fdbserverfb.RecordBlockAddValues(builder, values)
+	builder.Finish(fdbserverfb.RecordBlockEnd(builder))
+
+	block := builder.FinishedBytes()
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(block))), block...)
+}
+
+func TestModelFreshness(t *testing.T) {
+	body := featureDBUserRecords()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(freshnessMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	defer registry.Close()
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry), WithFeatureDBLogin("user", "pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := project.GetModel("rank_v1")
+	joinIds := map[string][]interface{}{"user_id": {"1", "2"}}
+	opts := domain.ModelOptions{Ctx: context.Background(), Freshness: &domain.Freshness{StaleAfter: time.Hour}}
+
+	// the age is the one of the event time field of user_fea, it is not selected by the model
+	features, err := model.GetOnlineFeaturesWithOptions(joinIds, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(features))
+	for _, feature := range features {
+		age, ok := feature["user_fea:"+domain.DefaultFreshnessAgeField].(time.Duration)
+		assert.True(t, ok)
+		assert.Equal(t, feature["user_id"] == "2", feature["user_fea:"+domain.DefaultFreshnessStaleField])
+		assert.Equal(t, feature["user_id"] == "2", age > time.Hour)
+		_, ok = feature["update_time"]
+		assert.False(t, ok)
+	}
+
+	features, err = model.GetOnlineFeaturesWithEntityWithOptions(joinIds, "user", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(features))
+	assert.True(t, features[0]["user_fea:"+domain.DefaultFreshnessAgeField] != nil)
+
+	batch, err := model.GetOnlineFeaturesBatch(joinIds, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, _, ok := columnar.Values[bool](batch, "user_fea:"+domain.DefaultFreshnessStaleField)
+	assert.True(t, ok)
+	assert.Equal(t, []bool{false, true}, stale)
+	ages, _, ok := columnar.Values[int64](batch, "user_fea:"+domain.DefaultFreshnessAgeField)
+	assert.True(t, ok)
+	assert.True(t, time.Duration(ages[1]) > time.Hour)
+}
@@ -1,14 +1,25 @@
 package featurestore
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"math/rand"
-	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/sync/singleflight"
+
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource/featuredb"
 	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
 )
 
 type ClientOption func(c *FeatureStoreClient)
@@ -25,6 +36,113 @@ func WithErrorLogger(l Logger) ClientOption {
 	}
 }
 
+// WithLeveledLogger set the leveled logger of the client, it is passed down to the feature views and their daos.
+// Without it the lines are written to Logger and ErrorLogger, or to logging.Default() if neither is set
+func WithLeveledLogger(l logging.Logger) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.leveledLogger = l
+	}
+}
+
+// WithMetricsCollector set the collector of the metrics of the online reads, such as metrics.NewPrometheusCollector()
+func WithMetricsCollector(collector metrics.MetricsCollector) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.metrics = collector
+	}
+}
+
+// WithTracer set the tracer of the online reads. Spans are opened per model call, per feature view of the model,
+// per dao read, per datasource batch and per request attempt, the parent span is taken from the context of the read
+func WithTracer(tracer tracing.Tracer) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.tracer = tracer
+	}
+}
+
+// WithFeatureViewCache set the in-process cache of the online features of the feature view of every project.
+// Only the join ids missing in the cache are read from the online store, see domain.FeatureCacheConfig
+func WithFeatureViewCache(featureViewName string, config domain.FeatureCacheConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		if e.featureCacheConfigs == nil {
+			e.featureCacheConfigs = make(map[string]domain.FeatureCacheConfig)
+		}
+		e.featureCacheConfigs[featureViewName] = config
+	}
+}
+
+// WithRequestCoalescing set the coalescing of the concurrent reads of the feature views. The keys read by the concurrent
+// callers within the window are de-duplicated and read in one call, each caller waits for its rows until its context is done
+func WithRequestCoalescing(config dao.CoalesceConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.coalesce = &config
+	}
+}
+
+// WithCircuitBreaker set the circuit breakers of the reads of every datasource and every feature view, see
+// dao.CircuitBreakerConfig. A read is rejected with a *dao.CircuitOpenError while a circuit is open, the states of
+// the circuits are in the HealthReport
+func WithCircuitBreaker(config dao.CircuitBreakerConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.circuitBreaker = &config
+	}
+}
+
+// WithNamedCircuitBreaker set the config of the circuit breaker of the name, such as hologres/holo_ds for a datasource
+// or project/feature_view for a feature view, it replaces the config of WithCircuitBreaker
+func WithNamedCircuitBreaker(name string, config dao.CircuitBreakerConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		if e.circuitBreakerConfigs == nil {
+			e.circuitBreakerConfigs = make(map[string]dao.CircuitBreakerConfig)
+		}
+		e.circuitBreakerConfigs[name] = config
+	}
+}
+
+// WithHealthCheckCircuitBreakers makes the HealthReport unhealthy while a circuit breaker is open, see WithCircuitBreaker
+func WithHealthCheckCircuitBreakers() ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.healthCheckCircuitBreakers = true
+	}
+}
+
+// WithFeatureViewFallback set the chain of the online stores the base feature view of the name reads from, such as
+// FeatureDB then the online store of the project, see domain.FallbackConfig. The stores that served the rows are
+// collected by the ServedBy of the options
+func WithFeatureViewFallback(featureViewName string, config domain.FallbackConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		if e.featureViewFallbacks == nil {
+			e.featureViewFallbacks = make(map[string]domain.FallbackConfig)
+		}
+		e.featureViewFallbacks[featureViewName] = config
+	}
+}
+
+// WithFeatureDBRequestExecutor set the retry, the backoff and the hedging of the requests to FeatureDB, see
+// featuredb.ExecutorConfig. By default a request is retried once after a transport error or a status 429 or 503
+func WithFeatureDBRequestExecutor(config featuredb.ExecutorConfig) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.featureDBExecutor = &config
+	}
+}
+
+// WithDefaultValues set the default values of the missing features of the feature views and the models,
+// such as the zero values of the feature types, see domain.DefaultValues
+func WithDefaultValues(defaultValues domain.DefaultValues) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.defaultValues = &defaultValues
+	}
+}
+
+// WithModelDefaultValues set the default values of the missing features of the model, they replace the ones of WithDefaultValues
+func WithModelDefaultValues(modelName string, defaultValues domain.DefaultValues) ClientOption {
+	return func(e *FeatureStoreClient) {
+		if e.modelDefaultValues == nil {
+			e.modelDefaultValues = make(map[string]*domain.DefaultValues)
+		}
+		e.modelDefaultValues[modelName] = &defaultValues
+	}
+}
+
 // WithDomain set custom domain
 func WithDomain(domian string) ClientOption {
 	return func(e *FeatureStoreClient) {
@@ -90,6 +208,50 @@ func WithHologresPrefix(hologresPrefix string) ClientOption {
 	}
 }
 
+// WithMetadataSource set custom metadata source instead of the featurestore server, see NewFileMetadataSource
+func WithMetadataSource(source MetadataSource) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.metadataSource = source
+	}
+}
+
+// WithSnapshotFallback set the metadata snapshot file, the client boots from it when the metadata can not be loaded.
+// The snapshot is saved after every successful refresh of the project data, see ExportSnapshot
+func WithSnapshotFallback(path string) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.snapshotPath = path
+	}
+}
+
+// WithFeatureDBToken set the token of FeatureDB used when the project metadata has none, such as the projects loaded
+// from a snapshot, which does not keep the token
+func WithFeatureDBToken(token string) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.featureDBToken = token
+	}
+}
+
+// SecretsResolver sets the secrets of the datasource in place, such as the password of iGraph and the token of FeatureDB.
+// It is called for the datasources of the projects loaded from a snapshot, which does not keep the secrets
+type SecretsResolver func(projectName string, datasource *api.Datasource) error
+
+// WithSecretsResolver set the resolver of the datasource secrets of the projects loaded from a snapshot.
+// Loading the snapshot fails when the password of iGraph or the token of FeatureDB is still missing
+func WithSecretsResolver(resolver SecretsResolver) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.secretsResolver = resolver
+	}
+}
+
+// WithDatasourceRegistry set the registry of the online store clients, by default the clients are shared by the process.
+// Use datasource.NewRegistry() when several clients in one process connect to different instances or with different credentials.
+// A registry shared by several clients is closed by the Close of the last one
+func WithDatasourceRegistry(registry datasource.Registry) ClientOption {
+	return func(e *FeatureStoreClient) {
+		e.registry = registry
+	}
+}
+
 type FeatureStoreClient struct {
 	// loopLoadData flag to invoke loopLoadProjectData  function
 	loopLoadData bool
@@ -99,9 +261,39 @@ type FeatureStoreClient struct {
 
 	domain string
 
+	cfg *api.Configuration
+
 	client *api.APIClient
 
-	projectMap map[string]*domain.Project
+	// registry of the online store clients
+	registry datasource.Registry
+
+	// featureDBToken is the FeatureDB token of the project metadata without one
+	featureDBToken string
+
+	// metadataSource to load project metadata, default is the featurestore server
+	metadataSource MetadataSource
+
+	// metadata is the current version of the loaded projects, replaced as a whole on every refresh
+	metadata atomic.Pointer[metadataVersion]
+
+	// publishMu serializes the publishing of metadata versions
+	publishMu sync.Mutex
+
+	// refreshMu serializes the refreshes of the project data
+	refreshMu sync.Mutex
+
+	// projectLoader deduplicates the first loading of a project
+	projectLoader singleflight.Group
+
+	listenerMu        sync.Mutex
+	metadataListeners []func(MetadataDiff)
+
+	// snapshotPath to save the metadata snapshot and boot from it when loading fails
+	snapshotPath string
+
+	// secretsResolver sets the datasource secrets of the projects loaded from the snapshot
+	secretsResolver SecretsResolver
 
 	// Logger specifies a logger used to report internal changes within the writer
 	Logger Logger
@@ -109,6 +301,41 @@ type FeatureStoreClient struct {
 	// ErrorLogger is the logger to report errors
 	ErrorLogger Logger
 
+	// leveledLogger is used by the client and the daos of the projects
+	leveledLogger logging.Logger
+
+	// metrics collects the metrics of the online reads, nil if they are not measured
+	metrics metrics.MetricsCollector
+
+	// tracer opens the spans of the online reads, nil if they are not traced
+	tracer tracing.Tracer
+
+	featureCacheConfigs map[string]domain.FeatureCacheConfig
+
+	// coalesce the concurrent reads of the feature views, nil if they are not coalesced
+	coalesce *dao.CoalesceConfig
+
+	// circuit breakers of the datasources and the feature views, nil if neither option is set
+	circuitBreaker        *dao.CircuitBreakerConfig
+	circuitBreakerConfigs map[string]dao.CircuitBreakerConfig
+	circuitBreakers       *dao.CircuitBreakers
+
+	// healthCheckCircuitBreakers makes the health report unhealthy while a circuit breaker is open
+	healthCheckCircuitBreakers bool
+
+	// fallback online stores of the feature views by feature view name
+	featureViewFallbacks map[string]domain.FallbackConfig
+
+	// retry and hedging of the requests to FeatureDB, nil if the defaults are used
+	featureDBExecutor *featuredb.ExecutorConfig
+
+	// default values of the missing features
+	defaultValues      *domain.DefaultValues
+	modelDefaultValues map[string]*domain.DefaultValues
+
+	// featureCaches of the feature views, kept across the refreshes of the project data
+	featureCaches *domain.FeatureCaches
+
 	// testMode to get features by public address
 	testMode bool
 
@@ -132,6 +359,59 @@ type FeatureStoreClient struct {
 
 	// stopChan to stop loopLoadProjectData
 	stopChan chan struct{}
+	stopOnce sync.Once
+
+	// loopWg waits for loopLoadProjectData and loopRefreshCredentials to exit
+	loopWg sync.WaitGroup
+
+	// credentialsProvider to rotate the credentials, nil if the access key is fixed
+	credentialsProvider api.CredentialsProvider
+
+	// credentials used by the project datasources
+	credentials *api.CredentialsHolder
+
+	// pushedCredentials are the credentials the hologres pools of the projects are connected with
+	pushedCredentials atomic.Pointer[api.Credentials]
+
+	// readTracker counts the in-flight reads of the feature views, each project counts its own with a child tracker
+	readTracker *dao.ReadTracker
+
+	// notFoundProjects are the names of the projects not found by the metadata source with the time they are looked up
+	// again, see NotFoundProjectTTL
+	notFoundProjects sync.Map
+
+	// retiredProjects are the replaced projects with the timers closing them, see RetireGracePeriod
+	retiredMu       sync.Mutex
+	retiredProjects map[*domain.Project]*time.Timer
+
+	// registryReleased is set once Close releases the datasource registry
+	registryReleased atomic.Bool
+}
+
+// registryClients counts the open FeatureStoreClients by their datasource registry, a registry is closed with the last one
+var (
+	registryClientsMu sync.Mutex
+	registryClients   = make(map[datasource.Registry]int)
+)
+
+func acquireRegistry(registry datasource.Registry) {
+	registryClientsMu.Lock()
+	defer registryClientsMu.Unlock()
+
+	registryClients[registry]++
+}
+
+// releaseRegistry reports whether the registry is released by its last client
+func releaseRegistry(registry datasource.Registry) bool {
+	registryClientsMu.Lock()
+	defer registryClientsMu.Unlock()
+
+	if registryClients[registry]--; registryClients[registry] > 0 {
+		return false
+	}
+	delete(registryClients, registry)
+
+	return true
 }
 
 func NewFeatureStoreClient(regionId, accessKeyId, accessKeySecret, projectName string, opts ...ClientOption) (fsclient *FeatureStoreClient, err error) {
@@ -141,17 +421,38 @@ func NewFeatureStoreClient(regionId, accessKeyId, accessKeySecret, projectName s
 		}
 	}()
 	client := FeatureStoreClient{
-		projectMap:           make(map[string]*domain.Project, 0),
 		loopLoadData:         true,
 		datasourceInitClient: true,
 		hologresPort:         80,
+		registry:             datasource.DefaultRegistry(),
 		stopChan:             make(chan struct{}),
+		readTracker:          dao.NewReadTracker(),
+		retiredProjects:      make(map[*domain.Project]*time.Timer),
 	}
 
+	client.metadata.Store(&metadataVersion{
+		projects:    make(map[string]*domain.Project, 0),
+		fullyLoaded: make(map[string]bool, 0),
+	})
+
 	for _, opt := range opts {
 		opt(&client)
 	}
 
+	if client.leveledLogger == nil {
+		client.leveledLogger = client.printfLeveledLogger()
+	}
+	if len(client.featureCacheConfigs) > 0 {
+		client.featureCaches = domain.NewFeatureCaches(client.featureCacheConfigs)
+	}
+	if client.circuitBreaker != nil || len(client.circuitBreakerConfigs) > 0 {
+		config := dao.CircuitBreakerConfig{}
+		if client.circuitBreaker != nil {
+			config = *client.circuitBreaker
+		}
+		client.circuitBreakers = dao.NewCircuitBreakers(config, client.circuitBreakerConfigs)
+	}
+
 	cfg := api.NewConfiguration(regionId, accessKeyId, accessKeySecret, client.token, projectName)
 
 	if client.testMode {
@@ -160,347 +461,239 @@ func NewFeatureStoreClient(regionId, accessKeyId, accessKeySecret, projectName s
 	if client.domain != "" {
 		cfg.SetDomain(client.domain)
 	}
+	client.cfg = cfg
 
-	apiClient, err := api.NewAPIClient(cfg)
-	if err != nil {
-		return nil, err
-	}
+	if client.credentialsProvider != nil {
+		credentials, err := client.getCredentials()
+		if err != nil {
+			return nil, err
+		}
+		client.credentials = api.NewCredentialsHolder(credentials)
+		client.pushedCredentials.Store(credentials)
+		cfg.Credentials = client.credentials
+	} else {
+		client.credentials = api.NewCredentialsHolder(&api.Credentials{
+			AccessKeyId:     accessKeyId,
+			AccessKeySecret: accessKeySecret,
+			SecurityToken:   client.token,
+		})
+	}
+
+	if client.metadataSource == nil {
+		apiClient, err := api.NewAPIClient(cfg)
+		if err != nil {
+			return nil, err
+		}
 
-	client.client = apiClient
+		client.client = apiClient
+		client.metadataSource = &apiMetadataSource{
+			client:                apiClient,
+			testMode:              client.testMode,
+			hologresPort:          client.hologresPort,
+			hologresPublicAddress: client.hologresPublicAddress,
+		}
+	}
 
-	if err = client.Validate(); err != nil {
-		return nil, err
+	if err = client.Validate(); err == nil {
+		err = client.lazyLoadProjectData()
 	}
+	if err != nil {
+		if client.snapshotPath == "" {
+			return nil, err
+		}
 
-	if err = client.lazyLoadProjectData(); err != nil {
-		return nil, err
+		client.logError(fmt.Errorf("load project data error, boot from snapshot:%s, err=%v", client.snapshotPath, err))
+		if snapshotErr := client.loadSnapshot(); snapshotErr != nil {
+			return nil, fmt.Errorf("load snapshot error, err=%v, load project data error, err=%v", snapshotErr, err)
+		}
+		err = nil
 	}
 
 	if client.loopLoadData {
+		client.loopWg.Add(1)
 		go client.loopLoadProjectData()
 	}
 
+	if client.credentialsProvider != nil {
+		client.loopWg.Add(1)
+		go client.loopRefreshCredentials()
+	}
+
+	acquireRegistry(client.registry)
+
 	return &client, nil
 }
 
 // Validate check the  FeatureStoreClient value
 func (e *FeatureStoreClient) Validate() error {
-	// check instance
-	if err := e.client.InstanceApi.GetInstance(); err != nil {
-		return err
-	}
-
-	return nil
+	return e.metadataSource.Validate()
 }
 
+// GetProject returns the project of the current metadata version.
+// Any project of the instance can be used, it is loaded on first use and refreshed with the others afterwards
 func (c *FeatureStoreClient) GetProject(name string) (*domain.Project, error) {
-	project, ok := c.projectMap[name]
-	if ok {
-		return project, nil
+	handle, err := c.GetProjectHandle(name)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("not found project, name:%s", name)
+	return handle.Project, nil
 }
 
 func (c *FeatureStoreClient) logError(err error) {
-	if c.ErrorLogger != nil {
-		c.ErrorLogger.Printf(err.Error())
-		return
-	}
-
-	if c.Logger != nil {
-		c.Logger.Printf(err.Error())
-	}
+	c.leveledLogger.Error(err.Error())
 }
 
-// LoadProjectData specifies a function to load data from featurestore server
+// LoadProjectData specifies a function to load data of all the used projects from featurestore server
 func (c *FeatureStoreClient) LoadProjectData() error {
-	ak := api.Ak{
-		AccesskeyId:     c.client.GetConfig().AccessKeyId,
-		AccesskeySecret: c.client.GetConfig().AccessKeySecret,
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	c.notFoundProjects.Clear()
+
+	var names []string
+	for name := range c.projects() {
+		names = append(names, name)
 	}
-	if c.client.GetConfig().Token != "" {
-		ak.SecurityToken = c.client.GetConfig().Token
+	if _, ok := c.projects()[c.cfg.ProjectName]; !ok && c.cfg.ProjectName != "" {
+		names = append(names, c.cfg.ProjectName)
 	}
-	projectData := make(map[string]*domain.Project, 0)
 
-	var (
-		pagesize   = 100
-		pagenumber = 1
-	)
-	projects := make([]*api.Project, 0)
-	for {
-		listProjectsResponse, err := c.client.FsProjectApi.ListProjects(int32(pagesize), int32(pagenumber))
-		if err != nil {
-			c.logError(fmt.Errorf("list projects error, err=%v", err))
-			return err
-		}
-		projects = append(projects, listProjectsResponse.Projects...)
-
-		if len(listProjectsResponse.Projects) == 0 || pagesize*pagenumber > listProjectsResponse.TotalCount {
-			break
+	var errs []error
+	for _, name := range names {
+		if err := c.loadProject(name, false); err != nil && !errors.Is(err, ErrProjectNotFound) {
+			errs = append(errs, err)
 		}
-
-		pagenumber++
 	}
 
-	for _, p := range projects {
-		if p.ProjectName != c.client.GetConfig().ProjectName {
-			continue
-		}
-		// get datasource
-		getDataSourceResponse, err := c.client.DatasourceApi.DatasourceDatasourceIdGet(p.OnlineDatasourceId, c.hologresPort, c.hologresPublicAddress)
-		if err != nil {
-			c.logError(fmt.Errorf("get datasource error, err=%v", err))
-			return err
-		}
-
-		p.OnlineDataSource = getDataSourceResponse.Datasource
-		p.OnlineDataSource.Ak = ak
-		p.OnlineDataSource.TestMode = c.testMode
-		p.OnlineDataSource.HologresPrefix = c.hologresPrefix
-		p.OnlineDataSource.HologresAuth = c.hologresAuth
-
-		getDataSourceResponse, err = c.client.DatasourceApi.DatasourceDatasourceIdGet(p.OfflineDatasourceId, c.hologresPort, c.hologresPublicAddress)
-		if err != nil {
-			c.logError(fmt.Errorf("get datasource error, err=%v", err))
-			return err
-		}
-
-		p.OfflineDataSource = getDataSourceResponse.Datasource
-		p.OfflineDataSource.Ak = ak
-		p.OfflineDataSource.TestMode = c.testMode
-
-		// get featuredb datasource
-		p.FeatureDBAddress, p.FeatureDBToken, p.FeatureDBVpcAddress, err = c.client.DatasourceApi.GetFeatureDBDatasourceInfo(c.testMode, p.OfflineDataSource.WorkspaceId)
-		if err != nil {
-			c.logError(fmt.Errorf("get featuredb datasource, err=%v", err))
-			return err
-		}
-
-		p.Signature = c.signature
-
-		project := domain.NewProject(p, c.datasourceInitClient, c.testMode)
-		project.SetApiClient(c.client)
-		projectData[project.ProjectName] = project
-
-		pagenumber = 1
-
-		// get feature entities
-		for {
-			listFeatureEntitiesResponse, err := c.client.FeatureEntityApi.ListFeatureEntities(int32(pagesize), int32(pagenumber), strconv.Itoa(p.ProjectId))
-			if err != nil {
-				c.logError(fmt.Errorf("list feature entities error, err=%v", err))
-				return err
-			}
-
-			for _, entity := range listFeatureEntitiesResponse.FeatureEntities {
-				project.FeatureEntityMap[entity.FeatureEntityName] = domain.NewFeatureEntity(entity)
-			}
-
-			if len(listFeatureEntitiesResponse.FeatureEntities) == 0 || pagesize*pagenumber > listFeatureEntitiesResponse.TotalCount {
-				break
-			}
-
-			pagenumber++
-
-		}
-
-		pagenumber = 1
-		// get feature views
-		for {
-			listFeatureViews, err := c.client.FeatureViewApi.ListFeatureViews(int32(pagesize), int32(pagenumber), strconv.Itoa(p.ProjectId))
-			if err != nil {
-				c.logError(fmt.Errorf("list feature views error, err=%v", err))
-				return err
-			}
-
-			for _, view := range listFeatureViews.FeatureViews {
-				getFeatureViewResponse, err := c.client.FeatureViewApi.GetFeatureViewByID(strconv.Itoa(int(view.FeatureViewId)))
-				if err != nil {
-					c.logError(fmt.Errorf("get feature view error, err=%v", err))
-					return err
-				}
-				featureView := getFeatureViewResponse.FeatureView
-				if featureView.RegisterDatasourceId > 0 {
-					getDataSourceResponse, err := c.client.DatasourceApi.DatasourceDatasourceIdGet(featureView.RegisterDatasourceId, c.hologresPort, c.hologresPublicAddress)
-					if err != nil {
-						c.logError(fmt.Errorf("get datasource error, err=%v", err))
-						return err
-					}
-					featureView.RegisterDataSource = getDataSourceResponse.Datasource
-				}
-
-				featureViewDomain := domain.NewFeatureView(featureView, project, project.FeatureEntityMap[featureView.FeatureEntityName])
-				project.FeatureViewMap.Store(featureView.Name, featureViewDomain)
-
-			}
-
-			if len(listFeatureViews.FeatureViews) == 0 || pagesize*pagenumber > listFeatureViews.TotalCount {
-				break
-			}
-
-			pagenumber++
-
-		}
-
-		pagenumber = 1
-		// get model
-		for {
-			listModelsResponse, err := c.client.FsModelApi.ListModels(pagesize, pagenumber, strconv.Itoa(project.ProjectId))
-			if err != nil {
-				c.logError(fmt.Errorf("list models error, err=%v", err))
-				return err
-			}
-
-			for _, m := range listModelsResponse.Models {
-				getModelResponse, err := c.client.FsModelApi.GetModelByID(strconv.Itoa(m.ModelId))
-				if err != nil {
-					c.logError(fmt.Errorf("get model error, err=%v", err))
-					return err
-				}
-				model := getModelResponse.Model
-				labelTable := project.GetLabelTable(model.LabelTableId)
-				if labelTable == nil {
-					c.logError(fmt.Errorf("not found label table, labelTableId:%d", model.LabelTableId))
-					return fmt.Errorf("not found label table, labelTableId:%d", model.LabelTableId)
-				}
-				modelDomain := domain.NewModel(model, project, labelTable)
-				project.ModelMap.Store(model.Name, modelDomain)
-
-			}
-
-			if len(listModelsResponse.Models) == 0 || pagenumber*pagesize > int(listModelsResponse.TotalCount) {
-				break
-			}
-
-			pagenumber++
-
-		}
+	return errors.Join(errs...)
+}
 
+func (c *FeatureStoreClient) lazyLoadProjectData() error {
+	if c.cfg.ProjectName == "" {
+		return nil
 	}
 
-	if len(projectData) > 0 {
-		c.projectMap = projectData
+	if err := c.loadProject(c.cfg.ProjectName, true); err != nil && !errors.Is(err, ErrProjectNotFound) {
+		return err
 	}
 
 	return nil
 }
 
-func (c *FeatureStoreClient) lazyLoadProjectData() error {
-	ak := api.Ak{
-		AccesskeyId:     c.client.GetConfig().AccessKeyId,
-		AccesskeySecret: c.client.GetConfig().AccessKeySecret,
+// loadProject loads the project from the metadata source and publishes it
+func (c *FeatureStoreClient) loadProject(name string, lazy bool) error {
+	meta, err := c.metadataSource.LoadProject(name, lazy)
+	if err != nil {
+		if !errors.Is(err, ErrProjectNotFound) {
+			c.logError(err)
+		}
+		return err
 	}
-	if c.client.GetConfig().Token != "" {
-		ak.SecurityToken = c.client.GetConfig().Token
+
+	project, err := c.newProject(meta)
+	if err != nil {
+		c.logError(err)
+		return err
 	}
-	projectData := make(map[string]*domain.Project, 0)
 
-	var (
-		pagesize   = 100
-		pagenumber = 1
-	)
-	projects := make([]*api.Project, 0)
-	for {
-		listProjectsResponse, err := c.client.FsProjectApi.ListProjects(int32(pagesize), int32(pagenumber))
-		if err != nil {
-			c.logError(fmt.Errorf("list projects error, err=%v", err))
-			return err
-		}
-		projects = append(projects, listProjectsResponse.Projects...)
+	if diff := c.publishProject(project, !lazy); diff != nil && !diff.IsEmpty() {
+		c.notifyMetadataChange(diff)
+	}
 
-		if len(listProjectsResponse.Projects) == 0 || pagesize*pagenumber > listProjectsResponse.TotalCount {
-			break
-		}
+	return nil
+}
 
-		pagenumber++
+// newProject builds the domain project from the metadata
+func (c *FeatureStoreClient) newProject(meta *ProjectMetadata) (*domain.Project, error) {
+	if meta.Project == nil || meta.Project.OnlineDataSource == nil || meta.Project.OfflineDataSource == nil {
+		return nil, errors.New("invalid project metadata, project and its datasources are required")
 	}
 
-	for _, p := range projects {
-		if p.ProjectName != c.client.GetConfig().ProjectName {
-			continue
-		}
-		// get datasource
-		getDataSourceResponse, err := c.client.DatasourceApi.DatasourceDatasourceIdGet(p.OnlineDatasourceId, c.hologresPort, c.hologresPublicAddress)
-		if err != nil {
-			c.logError(fmt.Errorf("get datasource error, err=%v", err))
-			return err
-		}
+	ak := c.credentials.Ak()
 
-		p.OnlineDataSource = getDataSourceResponse.Datasource
-		p.OnlineDataSource.Ak = ak
-		p.OnlineDataSource.TestMode = c.testMode
-		p.OnlineDataSource.HologresPrefix = c.hologresPrefix
-		p.OnlineDataSource.HologresAuth = c.hologresAuth
+	p := meta.Project
+	p.OnlineDataSource.Ak = ak
+	p.OnlineDataSource.TestMode = c.testMode
+	p.OnlineDataSource.HologresPrefix = c.hologresPrefix
+	p.OnlineDataSource.HologresAuth = c.hologresAuth
 
-		getDataSourceResponse, err = c.client.DatasourceApi.DatasourceDatasourceIdGet(p.OfflineDatasourceId, c.hologresPort, c.hologresPublicAddress)
-		if err != nil {
-			c.logError(fmt.Errorf("get datasource error, err=%v", err))
-			return err
-		}
+	p.OfflineDataSource.Ak = ak
+	p.OfflineDataSource.TestMode = c.testMode
 
-		p.OfflineDataSource = getDataSourceResponse.Datasource
-		p.OfflineDataSource.Ak = ak
-		p.OfflineDataSource.TestMode = c.testMode
+	if c.credentialsProvider != nil {
+		p.OnlineDataSource.Credentials = c.credentials
+		p.OfflineDataSource.Credentials = c.credentials
+	}
 
-		// get featuredb datasource
-		p.FeatureDBAddress, p.FeatureDBToken, p.FeatureDBVpcAddress, err = c.client.DatasourceApi.GetFeatureDBDatasourceInfo(c.testMode, p.OfflineDataSource.WorkspaceId)
-		if err != nil {
-			c.logError(fmt.Errorf("get featuredb datasource, err=%v", err))
-			return err
+	if meta.FeatureDB != nil {
+		p.FeatureDBAddress = meta.FeatureDB.Address
+		p.FeatureDBToken = meta.FeatureDB.Token
+		p.FeatureDBVpcAddress = meta.FeatureDB.VpcAddress
+		if p.FeatureDBToken == "" {
+			p.FeatureDBToken = c.featureDBToken
 		}
+	}
 
-		p.Signature = c.signature
+	p.Signature = c.signature
 
-		project := domain.NewProject(p, c.datasourceInitClient, c.testMode)
+	project, err := domain.NewProject(p, c.datasourceInitClient, c.testMode, domain.WithDatasourceRegistry(c.registry),
+		domain.WithReadTracker(c.readTracker.NewChild()), domain.WithLogger(c.leveledLogger), domain.WithMetricsCollector(c.metrics), domain.WithTracer(c.tracer),
+		domain.WithFeatureCaches(c.featureCaches), domain.WithCoalesceConfig(c.coalesce),
+		domain.WithFeatureDBExecutorConfig(c.featureDBExecutor), domain.WithCircuitBreakers(c.circuitBreakers),
+		domain.WithFeatureViewFallbacks(c.featureViewFallbacks),
+		domain.WithDefaultValues(c.defaultValues), domain.WithModelDefaultValues(c.modelDefaultValues))
+	if err != nil {
+		return nil, fmt.Errorf("build project error, name:%s, err=%v", p.ProjectName, err)
+	}
+	if c.client != nil {
 		project.SetApiClient(c.client)
-		projectData[project.ProjectName] = project
-
-		pagenumber = 1
-
-		// get feature entities
-		for {
-			listFeatureEntitiesResponse, err := c.client.FeatureEntityApi.ListFeatureEntities(int32(pagesize), int32(pagenumber), strconv.Itoa(p.ProjectId))
-			if err != nil {
-				c.logError(fmt.Errorf("list feature entities error, err=%v", err))
-				return err
-			}
-
-			for _, entity := range listFeatureEntitiesResponse.FeatureEntities {
-				project.FeatureEntityMap[entity.FeatureEntityName] = domain.NewFeatureEntity(entity)
-			}
+	}
 
-			if len(listFeatureEntitiesResponse.FeatureEntities) == 0 || pagesize*pagenumber > listFeatureEntitiesResponse.TotalCount {
-				break
-			}
+	for _, entity := range meta.FeatureEntities {
+		project.AddFeatureEntity(domain.NewFeatureEntity(entity))
+	}
 
-			pagenumber++
+	for _, labelTable := range meta.LabelTables {
+		project.LabelTableMap.Store(labelTable.LabelTableId, domain.NewLabelTable(labelTable))
+	}
 
-		}
+	for _, featureView := range meta.FeatureViews {
+		featureViewDomain := domain.NewFeatureView(featureView, project, project.GetFeatureEntity(featureView.FeatureEntityName))
+		project.FeatureViewMap.Store(featureView.Name, featureViewDomain)
 	}
 
-	if len(projectData) > 0 {
-		c.projectMap = projectData
+	for _, model := range meta.Models {
+		labelTable := project.GetLabelTable(model.LabelTableId)
+		if labelTable == nil {
+			project.Close()
+			return nil, fmt.Errorf("not found label table, labelTableId:%d", model.LabelTableId)
+		}
+		modelDomain := domain.NewModel(model, project, labelTable)
+		project.ModelMap.Store(model.Name, modelDomain)
 	}
 
-	return nil
+	return project, nil
 }
 
 func (c *FeatureStoreClient) loopLoadProjectData() {
+	defer c.loopWg.Done()
 
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Println("Recovered from panic:", r)
+				c.leveledLogger.Error("recovered from panic", logging.F("panic", r))
 			}
 		}()
 
-		c.LoadProjectData()
+		c.refreshProjectData()
 	}()
 
 	randomSeconds := rand.Intn(60)
-	time.Sleep(time.Duration(randomSeconds) * time.Second)
+	select {
+	case <-c.stopChan:
+		return
+	case <-time.After(time.Duration(randomSeconds) * time.Second):
+	}
 
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
@@ -512,16 +705,77 @@ func (c *FeatureStoreClient) loopLoadProjectData() {
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
-						fmt.Println("Recovered from panic:", r)
+						c.leveledLogger.Error("recovered from panic", logging.F("panic", r))
 					}
 				}()
 
-				c.LoadProjectData()
+				c.refreshProjectData()
 			}()
 		}
 	}
 }
 
+// refreshProjectData loads the project data and saves the snapshot on success
+func (c *FeatureStoreClient) refreshProjectData() {
+	if err := c.LoadProjectData(); err != nil {
+		return
+	}
+
+	if err := c.saveSnapshot(); err != nil {
+		c.logError(err)
+	}
+}
+
 func (c *FeatureStoreClient) Stop() {
-	close(c.stopChan)
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+	})
+}
+
+// Close stops the refresh loop, rejects new reads and waits for the in-flight reads to finish or ctx to be done,
+// then closes the projects, the replaced ones too, and the datasource clients. The clients of a registry shared by
+// several FeatureStoreClients, such as datasource.DefaultRegistry(), are closed by the last one using them.
+// All the errors met are returned together, the resources are released even if ctx is done.
+func (c *FeatureStoreClient) Close(ctx context.Context) error {
+	c.Stop()
+
+	var errs []error
+	loopDone := make(chan struct{})
+	go func() {
+		c.loopWg.Wait()
+		close(loopDone)
+	}()
+	select {
+	case <-loopDone:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("wait background loops error, err=%v", ctx.Err()))
+	}
+
+	if err := c.readTracker.Close(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("wait in-flight reads error, err=%v", err))
+	}
+
+	for name, project := range c.projects() {
+		if err := project.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close project error, name:%s, err=%v", name, err))
+		}
+	}
+	c.retiredMu.Lock()
+	retiredProjects := c.retiredProjects
+	c.retiredProjects = make(map[*domain.Project]*time.Timer)
+	c.retiredMu.Unlock()
+	for project, timer := range retiredProjects {
+		timer.Stop()
+		if err := project.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close retired project error, name:%s, err=%v", project.ProjectName, err))
+		}
+	}
+
+	if c.registryReleased.CompareAndSwap(false, true) && releaseRegistry(c.registry) {
+		if err := c.registry.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close datasource registry error, err=%v", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
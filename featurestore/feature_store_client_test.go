@@ -195,12 +195,16 @@ func TestWriteBloomKV(t *testing.T) {
 	if featureView == nil {
 		t.Fatal("feature view not exist")
 	}
+	fdbClient, err := project.FeatureDBClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	request := fdbserverpb.BatchWriteKVReqeust{}
 	for i := 0; i < 100; i++ {
 		request.Kvs = append(request.Kvs, &fdbserverpb.KVData{Key: "106", Value: []byte(fmt.Sprintf("item_%d", i))})
 	}
-	err = fdbserverpb.BatchWriteBloomKV(project, featureView, &request)
+	err = fdbserverpb.BatchWriteBloomKV(fdbClient, project, featureView, &request)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -224,12 +228,16 @@ func TestBloomItems(t *testing.T) {
 	if featureView == nil {
 		t.Fatal("feature view not exist")
 	}
+	fdbClient, err := project.FeatureDBClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	request := fdbserverpb.TestBloomItemsRequest{Key: "106"}
 	for i := 0; i < 100; i++ {
 		request.Items = append(request.Items, fmt.Sprintf("item_%d", i))
 	}
-	tests, err := fdbserverpb.TestBloomItems(project, featureView, &request)
+	tests, err := fdbserverpb.TestBloomItems(fdbClient, project, featureView, &request)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -259,8 +267,12 @@ func TestDeleteBloomByKey(t *testing.T) {
 	if featureView == nil {
 		t.Fatal("feature view not exist")
 	}
+	fdbClient, err := project.FeatureDBClient()
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	err = fdbserverpb.DeleteBloomByKey(project, featureView, "106")
+	err = fdbserverpb.DeleteBloomByKey(fdbClient, project, featureView, "106")
 	if err != nil {
 		t.Fatal(err)
 	}
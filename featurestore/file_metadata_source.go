@@ -0,0 +1,77 @@
+package featurestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFile is the layout of a local metadata file
+type MetadataFile struct {
+	Projects []*ProjectMetadata `json:"projects"`
+}
+
+// FileMetadataSource loads the project metadata from a local JSON or YAML file,
+// so the client can boot without the PAI-FeatureStore server.
+// The file is read on every load, changes are picked up by the refresh loop.
+type FileMetadataSource struct {
+	path string
+}
+
+// NewFileMetadataSource creates a FileMetadataSource, files with the .yaml or .yml extension are parsed as YAML,
+// others as JSON. Both use the json field names of the api package.
+func NewFileMetadataSource(path string) *FileMetadataSource {
+	return &FileMetadataSource{
+		path: path,
+	}
+}
+
+func (s *FileMetadataSource) Validate() error {
+	_, err := s.readFile()
+	return err
+}
+
+func (s *FileMetadataSource) LoadProject(name string, lazy bool) (*ProjectMetadata, error) {
+	metadataFile, err := s.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, meta := range metadataFile.Projects {
+		if meta.Project != nil && meta.Project.ProjectName == name {
+			return meta, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w, name:%s", ErrProjectNotFound, name)
+}
+
+func (s *FileMetadataSource) readFile() (*MetadataFile, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata file error, path:%s, err=%v", s.path, err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(s.path))
+	if ext == ".yaml" || ext == ".yml" {
+		// yaml has no knowledge of the json tags, convert it to json first
+		var content interface{}
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("parse metadata file error, path:%s, err=%v", s.path, err)
+		}
+		if data, err = json.Marshal(content); err != nil {
+			return nil, fmt.Errorf("parse metadata file error, path:%s, err=%v", s.path, err)
+		}
+	}
+
+	metadataFile := &MetadataFile{}
+	if err := json.Unmarshal(data, metadataFile); err != nil {
+		return nil, fmt.Errorf("parse metadata file error, path:%s, err=%v", s.path, err)
+	}
+
+	return metadataFile, nil
+}
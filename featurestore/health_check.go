@@ -0,0 +1,105 @@
+package featurestore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+// HealthReport is the result of HealthCheck, Healthy is true when the online stores of every project are healthy.
+// The control plane is reported on its own, the loaded projects keep serving reads while it is down
+type HealthReport struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	// ControlPlane is the health of the metadata source, it does not change Healthy
+	ControlPlane domain.ComponentHealth `json:"control_plane"`
+	Projects     []ProjectHealth        `json:"projects"`
+	// CircuitBreakers are the states of the circuit breakers of the reads, see WithCircuitBreaker
+	CircuitBreakers []dao.CircuitBreakerStats `json:"circuit_breakers,omitempty"`
+}
+
+// ProjectHealth is the health of the online stores of a project
+type ProjectHealth struct {
+	ProjectName          string                   `json:"project_name"`
+	OnlineDatasourceType string                   `json:"online_datasource_type"`
+	Healthy              bool                     `json:"healthy"`
+	Checks               []domain.ComponentHealth `json:"checks"`
+}
+
+// HealthCheck checks the metadata source and the online stores of the loaded projects, such as a hologres ping,
+// a tablestore describe table, an igraph dial and a FeatureDB /health request, see domain.Project.CheckHealth.
+// The FeatureDB check fails when the login is not set by WithFeatureDBLogin. With WithHealthCheckCircuitBreakers an
+// open circuit breaker makes the report unhealthy too. ctx bounds the time of the checks, use it as the readiness
+// probe of the service
+func (c *FeatureStoreClient) HealthCheck(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Healthy:   true,
+		CheckedAt: time.Now(),
+	}
+
+	projects := c.projects()
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report.Projects = make([]ProjectHealth, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, project *domain.Project) {
+			defer wg.Done()
+			health := ProjectHealth{
+				ProjectName:          project.ProjectName,
+				OnlineDatasourceType: project.OnlineDatasourceType,
+				Healthy:              true,
+				Checks:               project.CheckHealth(ctx),
+			}
+			for _, check := range health.Checks {
+				health.Healthy = health.Healthy && check.Healthy
+			}
+			report.Projects[i] = health
+		}(i, projects[name])
+	}
+
+	report.ControlPlane = c.checkControlPlane(ctx)
+	if c.circuitBreakers != nil {
+		report.CircuitBreakers = c.circuitBreakers.Stats()
+	}
+	wg.Wait()
+
+	for _, project := range report.Projects {
+		report.Healthy = report.Healthy && project.Healthy
+	}
+	if c.healthCheckCircuitBreakers {
+		for _, stats := range report.CircuitBreakers {
+			report.Healthy = report.Healthy && stats.State != dao.CircuitOpen
+		}
+	}
+
+	return report
+}
+
+// checkControlPlane validates the metadata source, the validation takes no context so it keeps running
+// in the background when ctx is done first
+func (c *FeatureStoreClient) checkControlPlane(ctx context.Context) domain.ComponentHealth {
+	start := time.Now()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- c.metadataSource.Validate()
+	}()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return domain.NewComponentHealth("control_plane", start, err)
+}
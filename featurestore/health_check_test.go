@@ -0,0 +1,76 @@
+package featurestore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+func TestHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(testMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newClient := func(opts ...ClientOption) *FeatureStoreClient {
+		registry := datasource.NewRegistry()
+		t.Cleanup(func() { registry.Close() })
+
+		opts = append(opts, WithMetadataSource(NewFileMetadataSource(path)), WithNoDatasourceInitClient(), WithLoopData(false),
+			WithDatasourceRegistry(registry))
+		client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report := newClient(WithFeatureDBLogin("user", "pwd")).HealthCheck(ctx)
+	assert.True(t, report.Healthy)
+	assert.True(t, report.ControlPlane.Healthy)
+	assert.Equal(t, 1, len(report.Projects))
+	assert.Equal(t, "fs_local", report.Projects[0].ProjectName)
+	checks := report.Projects[0].Checks
+	assert.Equal(t, 1, len(checks))
+	assert.Equal(t, "featuredb", checks[0].Name)
+	assert.Equal(t, server.URL, checks[0].Address)
+	assert.Equal(t, domain.AddressTypePublic, checks[0].AddressType)
+
+	report = newClient().HealthCheck(ctx)
+	assert.False(t, report.Healthy)
+	assert.True(t, report.ControlPlane.Healthy)
+	checks = report.Projects[0].Checks
+	assert.False(t, checks[0].Healthy)
+	assert.True(t, strings.Contains(checks[0].Error, "WithFeatureDBLogin"), checks[0].Error)
+
+	// the loaded projects keep serving reads while the control plane is down
+	client := newClient(WithFeatureDBLogin("user", "pwd"))
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	report = client.HealthCheck(ctx)
+	assert.True(t, report.Healthy)
+	assert.False(t, report.ControlPlane.Healthy)
+}
@@ -1,5 +1,9 @@
 package featurestore
 
+import (
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+)
+
 // Logger interface API for log.Logger
 type Logger interface {
 	Printf(string, ...interface{})
@@ -16,3 +20,21 @@ type Logger interface {
 type LoggerFunc func(string, ...interface{})
 
 func (f LoggerFunc) Printf(msg string, args ...interface{}) { f(msg, args...) }
+
+// printfLeveledLogger bridges the leveled logging to Logger and ErrorLogger, the errors are written to ErrorLogger if it is set
+func (c *FeatureStoreClient) printfLeveledLogger() logging.Logger {
+	if c.Logger == nil && c.ErrorLogger == nil {
+		return logging.Default()
+	}
+
+	return logging.New(logging.LevelInfo, func(level logging.Level, line string) {
+		if level >= logging.LevelError && c.ErrorLogger != nil {
+			c.ErrorLogger.Printf("%s", line)
+			return
+		}
+
+		if c.Logger != nil {
+			c.Logger.Printf("%s", line)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package featurestore
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+// MetadataDiff describes the changes of a project between two refreshes of the metadata
+type MetadataDiff struct {
+	ProjectName string
+
+	AddedFeatureViews    []string
+	RemovedFeatureViews  []string
+	ModifiedFeatureViews []*FeatureViewDiff
+
+	AddedModels    []string
+	RemovedModels  []string
+	ModifiedModels []string
+
+	AddedFeatureEntities    []string
+	RemovedFeatureEntities  []string
+	ModifiedFeatureEntities []string
+}
+
+// FeatureViewDiff describes the changes of a feature view
+type FeatureViewDiff struct {
+	Name string
+
+	OldTTL int
+	NewTTL int
+
+	AddedFields    []*api.FeatureViewFields
+	RemovedFields  []*api.FeatureViewFields
+	ModifiedFields []*FieldDiff
+
+	// Models that use the feature view after the change
+	Models []string
+}
+
+// FieldDiff describes the schema change of a feature view field
+type FieldDiff struct {
+	Name string
+	Old  *api.FeatureViewFields
+	New  *api.FeatureViewFields
+}
+
+// IsEmpty reports whether the metadata did not change
+func (d *MetadataDiff) IsEmpty() bool {
+	return len(d.AddedFeatureViews) == 0 && len(d.RemovedFeatureViews) == 0 && len(d.ModifiedFeatureViews) == 0 &&
+		len(d.AddedModels) == 0 && len(d.RemovedModels) == 0 && len(d.ModifiedModels) == 0 &&
+		len(d.AddedFeatureEntities) == 0 && len(d.RemovedFeatureEntities) == 0 && len(d.ModifiedFeatureEntities) == 0
+}
+
+// TTLChanged reports whether the ttl of the feature view changed
+func (d *FeatureViewDiff) TTLChanged() bool {
+	return d.OldTTL != d.NewTTL
+}
+
+// SchemaChanged reports whether the fields of the feature view changed
+func (d *FeatureViewDiff) SchemaChanged() bool {
+	return len(d.AddedFields) > 0 || len(d.RemovedFields) > 0 || len(d.ModifiedFields) > 0
+}
+
+// OnMetadataChange registers a function called after every refresh that changes the metadata of the project.
+// The function is called from the refresh goroutine after the new metadata is published.
+func (c *FeatureStoreClient) OnMetadataChange(fn func(MetadataDiff)) {
+	c.listenerMu.Lock()
+	defer c.listenerMu.Unlock()
+
+	c.metadataListeners = append(c.metadataListeners, fn)
+}
+
+func (c *FeatureStoreClient) notifyMetadataChange(diff *MetadataDiff) {
+	c.listenerMu.Lock()
+	listeners := c.metadataListeners
+	c.listenerMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(*diff)
+	}
+}
+
+func diffProject(oldProject, newProject *domain.Project) *MetadataDiff {
+	oldMeta := newProjectMetadata(oldProject, false)
+	newMeta := newProjectMetadata(newProject, false)
+
+	diff := &MetadataDiff{
+		ProjectName: newProject.ProjectName,
+	}
+
+	oldEntities := make(map[string]*api.FeatureEntity, len(oldMeta.FeatureEntities))
+	for _, entity := range oldMeta.FeatureEntities {
+		oldEntities[entity.FeatureEntityName] = entity
+	}
+	for _, entity := range newMeta.FeatureEntities {
+		oldEntity, ok := oldEntities[entity.FeatureEntityName]
+		if !ok {
+			diff.AddedFeatureEntities = append(diff.AddedFeatureEntities, entity.FeatureEntityName)
+		} else if !reflect.DeepEqual(oldEntity, entity) {
+			diff.ModifiedFeatureEntities = append(diff.ModifiedFeatureEntities, entity.FeatureEntityName)
+		}
+		delete(oldEntities, entity.FeatureEntityName)
+	}
+	for name := range oldEntities {
+		diff.RemovedFeatureEntities = append(diff.RemovedFeatureEntities, name)
+	}
+	sort.Strings(diff.RemovedFeatureEntities)
+
+	featureViewModels := make(map[string][]string)
+	oldModels := make(map[string]*api.Model, len(oldMeta.Models))
+	for _, model := range oldMeta.Models {
+		oldModels[model.Name] = model
+	}
+	for _, model := range newMeta.Models {
+		oldModel, ok := oldModels[model.Name]
+		if !ok {
+			diff.AddedModels = append(diff.AddedModels, model.Name)
+		} else if !reflect.DeepEqual(oldModel, model) {
+			diff.ModifiedModels = append(diff.ModifiedModels, model.Name)
+		}
+		delete(oldModels, model.Name)
+
+		for _, feature := range model.Features {
+			models := featureViewModels[feature.FeatureViewName]
+			if len(models) == 0 || models[len(models)-1] != model.Name {
+				featureViewModels[feature.FeatureViewName] = append(models, model.Name)
+			}
+		}
+	}
+	for name := range oldModels {
+		diff.RemovedModels = append(diff.RemovedModels, name)
+	}
+	sort.Strings(diff.RemovedModels)
+
+	oldViews := make(map[string]*api.FeatureView, len(oldMeta.FeatureViews))
+	for _, view := range oldMeta.FeatureViews {
+		oldViews[view.Name] = view
+	}
+	for _, view := range newMeta.FeatureViews {
+		oldView, ok := oldViews[view.Name]
+		if !ok {
+			diff.AddedFeatureViews = append(diff.AddedFeatureViews, view.Name)
+		} else if viewDiff := diffFeatureView(oldView, view); viewDiff != nil {
+			viewDiff.Models = featureViewModels[view.Name]
+			diff.ModifiedFeatureViews = append(diff.ModifiedFeatureViews, viewDiff)
+		}
+		delete(oldViews, view.Name)
+	}
+	for name := range oldViews {
+		diff.RemovedFeatureViews = append(diff.RemovedFeatureViews, name)
+	}
+	sort.Strings(diff.RemovedFeatureViews)
+
+	return diff
+}
+
+// diffFeatureView returns nil when the feature view did not change
+func diffFeatureView(oldView, newView *api.FeatureView) *FeatureViewDiff {
+	diff := &FeatureViewDiff{
+		Name:   newView.Name,
+		OldTTL: oldView.Ttl,
+		NewTTL: newView.Ttl,
+	}
+
+	oldFields := make(map[string]*api.FeatureViewFields, len(oldView.Fields))
+	for _, field := range oldView.Fields {
+		oldFields[field.Name] = field
+	}
+	for _, field := range newView.Fields {
+		oldField, ok := oldFields[field.Name]
+		if !ok {
+			diff.AddedFields = append(diff.AddedFields, field)
+		} else if *oldField != *field {
+			diff.ModifiedFields = append(diff.ModifiedFields, &FieldDiff{Name: field.Name, Old: oldField, New: field})
+		}
+		delete(oldFields, field.Name)
+	}
+	for _, field := range oldView.Fields {
+		if _, ok := oldFields[field.Name]; ok {
+			diff.RemovedFields = append(diff.RemovedFields, field)
+		}
+	}
+
+	if diff.TTLChanged() || diff.SchemaChanged() {
+		return diff
+	}
+
+	// other attributes, such as the config or the register table
+	o, n := *oldView, *newView
+	o.Fields, n.Fields = nil, nil
+	if !reflect.DeepEqual(o, n) {
+		return diff
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package featurestore
+
+import (
+	"errors"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+)
+
+// ErrProjectNotFound is returned by a MetadataSource when the requested project does not exist
+var ErrProjectNotFound = errors.New("not found project")
+
+// MetadataSource specifies where FeatureStoreClient loads the project metadata from
+type MetadataSource interface {
+	// Validate checks the metadata source is available
+	Validate() error
+
+	// LoadProject loads the metadata of the named project.
+	// When lazy is true, the source may only return the project and its feature entities,
+	// feature views, label tables and models are then loaded on demand.
+	LoadProject(name string, lazy bool) (*ProjectMetadata, error)
+}
+
+// ProjectMetadata holds everything needed to build a domain.Project
+type ProjectMetadata struct {
+	Project         *api.Project         `json:"project"`
+	FeatureDB       *FeatureDBMetadata   `json:"featuredb,omitempty"`
+	FeatureEntities []*api.FeatureEntity `json:"feature_entities,omitempty"`
+	FeatureViews    []*api.FeatureView   `json:"feature_views,omitempty"`
+	LabelTables     []*api.LabelTable    `json:"label_tables,omitempty"`
+	Models          []*api.Model         `json:"models,omitempty"`
+}
+
+// FeatureDBMetadata is the featuredb datasource info of the project workspace
+type FeatureDBMetadata struct {
+	Address    string `json:"address"`
+	Token      string `json:"token"`
+	VpcAddress string `json:"vpc_address,omitempty"`
+}
@@ -0,0 +1,589 @@
+package featurestore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/dao"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+const testMetadataYaml = `
+projects:
+  - project:
+      project_id: 1
+      project_name: fs_local
+      online_datasource_type: featuredb
+      offline_datasource_type: maxcompute
+      instance_id: featurestore-cn-test
+      online_datasource:
+        datasource_id: 1
+        type: featuredb
+        name: fdb
+        workspace_id: "100"
+        token: secret
+      offline_datasource:
+        datasource_id: 2
+        type: maxcompute
+        name: odps
+        workspace_id: "100"
+    feature_entities:
+      - feature_entity_id: 1
+        project_id: 1
+        feature_entity_name: user
+        feature_entity_joinid: user_id
+    feature_views:
+      - feature_view_id: 1
+        project_id: 1
+        name: user_fea
+        feature_entity_name: user
+        type: Batch
+        ttl: 86400
+        fields:
+          - name: user_id
+            type: 5
+            is_primary_key: true
+          - name: age
+            type: 2
+          - name: city
+            type: 5
+    label_tables:
+      - label_table_id: 1
+        name: rank_label
+        project_id: 1
+        fields:
+          - name: user_id
+            type: 5
+          - name: label
+            type: 1
+            is_label_field: true
+    models:
+      - model_id: 1
+        project_id: 1
+        name: rank_v1
+        label_table_id: 1
+        features:
+          - feature_view_name: user_fea
+            name: age
+            type: 2
+          - feature_view_name: user_fea
+            name: city
+            alias_name: user_city
+            type: 5
+`
+
+// featureDBMetadataYaml adds the FeatureDB of the address to the project of the metadata
+func featureDBMetadataYaml(metadata, address string) string {
+	return metadata + fmt.Sprintf("    featuredb:\n      address: %s\n      token: token\n", address)
+}
+
+func TestFileMetadataSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "user_id", project.GetFeatureEntity("user").FeatureEntityJoinid)
+
+	featureView := project.GetFeatureView("user_fea")
+	if featureView == nil {
+		t.Fatal("feature view not exist")
+	}
+	assert.Equal(t, 86400, featureView.GetTTL())
+	assert.Equal(t, 3, len(featureView.GetFields()))
+
+	model := project.GetModel("rank_v1")
+	if model == nil {
+		t.Fatal("model not exist")
+	}
+	assert.Equal(t, "rank_label", model.GetLabelTable().Name)
+
+	// lazy loading is not available without the featurestore server
+	assert.True(t, project.GetFeatureView("not_exist") == nil)
+
+	if _, err := client.GetProject("not_exist"); err == nil {
+		t.Fatal("expect not found project error")
+	}
+}
+
+func TestSnapshotFallback(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.saveSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := client.ExportSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, !bytes.Contains(buf.Bytes(), []byte("secret")))
+
+	// the metadata source is unavailable, boot from the snapshot
+	client, err = NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(filepath.Join(dir, "not_exist.yaml"))),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureView := project.GetFeatureView("user_fea")
+	if featureView == nil {
+		t.Fatal("feature view not exist")
+	}
+	assert.Equal(t, 3, len(featureView.GetFields()))
+	assert.Equal(t, "rank_label", project.GetModel("rank_v1").GetLabelTable().Name)
+}
+
+func TestMetadataChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+
+	var diffs []MetadataDiff
+	client.OnMetadataChange(func(diff MetadataDiff) {
+		diffs = append(diffs, diff)
+	})
+
+	// nothing changed
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(diffs))
+
+	metadata := strings.Replace(testMetadataYaml, "ttl: 86400", "ttl: 3600", 1)
+	metadata = strings.Replace(metadata, `          - name: age
+            type: 2
+          - name: city`, `          - name: age
+            type: 1
+          - name: gender`, 1)
+	if err := os.WriteFile(path, []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(diffs))
+
+	diff := diffs[0]
+	assert.Equal(t, "fs_local", diff.ProjectName)
+	assert.Equal(t, 1, len(diff.ModifiedFeatureViews))
+
+	viewDiff := diff.ModifiedFeatureViews[0]
+	assert.Equal(t, "user_fea", viewDiff.Name)
+	assert.True(t, viewDiff.TTLChanged())
+	assert.Equal(t, 3600, viewDiff.NewTTL)
+	assert.Equal(t, "gender", viewDiff.AddedFields[0].Name)
+	assert.Equal(t, "city", viewDiff.RemovedFields[0].Name)
+	assert.Equal(t, "age", viewDiff.ModifiedFields[0].Name)
+	assert.Equal(t, []string{"rank_v1"}, viewDiff.Models)
+	assert.Equal(t, 0, len(diff.ModifiedModels))
+}
+
+func TestProjectHandle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle, err := client.GetProjectHandle("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := handle.Version()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := client.LoadProjectData(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		h, err := client.GetProjectHandle("fs_local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, h.Version() >= version)
+	}
+	<-done
+
+	assert.Equal(t, version+10, client.MetadataVersion())
+	// the pinned project keeps its own feature views
+	assert.True(t, handle.GetFeatureEntity("user") != nil)
+}
+
+// TestProjectRefreshRace is meant to run with -race, the projects are refreshed and their entities added while read
+func TestProjectRefreshRace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithDatasourceRegistry(datasource.NewRegistry()), WithNoDatasourceInitClient(), WithLoopData(false),
+		WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := client.LoadProjectData(); err != nil {
+				t.Error(err)
+			}
+			if err := client.saveSnapshot(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			project, err := client.GetProject("fs_local")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			project.AddFeatureEntity(domain.NewFeatureEntity(&api.FeatureEntity{FeatureEntityName: fmt.Sprintf("entity_%d", i)}))
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		handle, err := client.GetProjectHandle("fs_local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "user_id", handle.GetFeatureEntity("user").FeatureEntityJoinid)
+		assert.Equal(t, "user_id", handle.FeatureEntityMap["user"].FeatureEntityJoinid)
+		for name, entity := range handle.FeatureEntities() {
+			assert.Equal(t, name, entity.FeatureEntityName)
+		}
+	}
+	wg.Wait()
+}
+
+const testItemProjectYaml = `  - project:
+      project_id: 2
+      project_name: fs_item
+      online_datasource_type: featuredb
+      offline_datasource_type: maxcompute
+      online_datasource:
+        datasource_id: 1
+        type: featuredb
+        name: fdb
+      offline_datasource:
+        datasource_id: 2
+        type: maxcompute
+        name: odps
+    feature_entities:
+      - feature_entity_id: 2
+        project_id: 2
+        feature_entity_name: item
+        feature_entity_joinid: item_id
+`
+
+func TestMultiProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml+testItemProjectYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(client.projects()))
+
+	project, err := client.GetProject("fs_item")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "item_id", project.GetFeatureEntity("item").FeatureEntityJoinid)
+	assert.Equal(t, 2, len(client.projects()))
+
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, client.metadata.Load().fullyLoaded["fs_item"])
+	assert.True(t, client.metadata.Load().fullyLoaded["fs_local"])
+}
+
+func TestProjectNotFound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetProject("fs_item")
+	assert.True(t, err != nil)
+
+	// the project not found is not looked up again until the next refresh
+	if err := os.WriteFile(path, []byte(testMetadataYaml+testItemProjectYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetProject("fs_item")
+	assert.True(t, err != nil)
+
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetProject("fs_item"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithDatasourceRegistry(datasource.NewRegistry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureView := project.GetFeatureView("user_fea")
+	if featureView == nil {
+		t.Fatal("feature view not exist")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// closing twice is fine
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := featureView.GetOnlineFeatures([]interface{}{"1"}, []string{"*"}, nil); !errors.Is(err, dao.ErrClosed) {
+		t.Fatalf("expect closed error, err=%v", err)
+	}
+}
+
+func TestCloseDefaultRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(testMetadataYaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the clients of the other tests are not closed
+	registryClientsMu.Lock()
+	clients := registryClients[datasource.DefaultRegistry()]
+	delete(registryClients, datasource.DefaultRegistry())
+	registryClientsMu.Unlock()
+	defer func() {
+		registryClientsMu.Lock()
+		registryClients[datasource.DefaultRegistry()] += clients
+		registryClientsMu.Unlock()
+	}()
+
+	// the clients of a registry shared by several FeatureStoreClients are closed with the last one using them
+	for _, registry := range []datasource.Registry{datasource.DefaultRegistry(), datasource.NewRegistry()} {
+		featureDBClient := registry.InitFeatureDBClient("http://127.0.0.1:1", "token", "", false)
+		var fsClients []*FeatureStoreClient
+		for i := 0; i < 2; i++ {
+			client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+				WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fsClients = append(fsClients, client)
+		}
+
+		for i, client := range fsClients {
+			if err := client.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			_, err := registry.GetFeatureDBClient("http://127.0.0.1:1", "token", "")
+			assert.Equal(t, i == len(fsClients)-1, err != nil)
+		}
+		registry.ReleaseFeatureDBClient(featureDBClient)
+	}
+}
+
+func TestRetireProject(t *testing.T) {
+	defer func(gracePeriod time.Duration) {
+		RetireGracePeriod = gracePeriod
+	}(RetireGracePeriod)
+	RetireGracePeriod = 100 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	metadata := testMetadataYaml + `    featuredb:
+      address: http://127.0.0.1:1
+      token: token1
+`
+	if err := os.WriteFile(path, []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithDatasourceRegistry(registry), WithNoDatasourceInitClient(), WithLoopData(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := client.GetProject("fs_local")
+	oldClient, _ := old.FeatureDBClient()
+	// heldOldClient reports whether the registry still holds the FeatureDB client of the replaced project
+	heldOldClient := func() bool {
+		held := registry.InitFeatureDBClient("http://127.0.0.1:1", "token1", "", false)
+		defer registry.ReleaseFeatureDBClient(held)
+		return held == oldClient
+	}
+
+	// the rotated token replaces the project, the replaced one keeps serving the requests holding it
+	if err := os.WriteFile(path, []byte(strings.Replace(metadata, "token: token1", "token: token2", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	project, _ := client.GetProject("fs_local")
+	assert.True(t, project != old)
+	if _, err := old.GetFeatureView("user_fea").GetOnlineFeatures([]interface{}{"1"}, []string{"age"}, nil); errors.Is(err, dao.ErrClosed) {
+		t.Fatal("expect the replaced project open during the grace period")
+	}
+	assert.True(t, heldOldClient())
+
+	// the replaced project is closed after the grace period and releases its FeatureDB client
+	deadline := time.Now().Add(5 * time.Second)
+	for heldOldClient() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, heldOldClient())
+	if _, err := old.GetFeatureView("user_fea").GetOnlineFeatures([]interface{}{"1"}, []string{"age"}, nil); !errors.Is(err, dao.ErrClosed) {
+		t.Fatalf("expect closed error, err=%v", err)
+	}
+	if _, err := project.GetFeatureView("user_fea").GetOnlineFeatures([]interface{}{"1"}, []string{"age"}, nil); errors.Is(err, dao.ErrClosed) {
+		t.Fatal("expect the current project open")
+	}
+}
+
+func TestSnapshotFeatureDBToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metadata.yaml")
+	metadata := testMetadataYaml + `    featuredb:
+      address: http://127.0.0.1:1
+      token: secret
+`
+	if err := os.WriteFile(path, []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithDatasourceRegistry(datasource.NewRegistry()), WithNoDatasourceInitClient(), WithLoopData(false),
+		WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+	if err := client.LoadProjectData(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.saveSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot has no token, the boot fails without one from the client
+	_, err = NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(filepath.Join(dir, "not_exist.yaml"))),
+		WithDatasourceRegistry(datasource.NewRegistry()), WithNoDatasourceInitClient(), WithLoopData(false),
+		WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+	assert.True(t, err != nil)
+
+	resolver := func(projectName string, ds *api.Datasource) error {
+		if ds.Type == constants.Datasource_Type_FeatureDB {
+			ds.Token = "secret"
+		}
+		return nil
+	}
+	for _, option := range []ClientOption{WithFeatureDBToken("secret"), WithSecretsResolver(resolver)} {
+		client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(filepath.Join(dir, "not_exist.yaml"))),
+			WithDatasourceRegistry(datasource.NewRegistry()), WithNoDatasourceInitClient(), WithLoopData(false), option,
+			WithSnapshotFallback(filepath.Join(dir, "snapshot.json")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		project, _ := client.GetProject("fs_local")
+		featureDBClient, err := project.FeatureDBClient()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "secret", featureDBClient.Token)
+		client.Close(context.Background())
+	}
+}
@@ -0,0 +1,89 @@
+package featurestore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/logging"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/metrics"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"message":"table is loading"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(testMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	defer registry.Close()
+
+	var mu sync.Mutex
+	var lines []string
+	logger := logging.New(logging.LevelDebug, func(level logging.Level, line string) {
+		mu.Lock()
+		lines = append(lines, line)
+		mu.Unlock()
+	})
+
+	collector := metrics.NewInMemoryCollector()
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry), WithFeatureDBLogin("user", "pwd"),
+		WithMetricsCollector(collector), WithLeveledLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureView := project.GetFeatureView("user_fea")
+
+	ctx := logging.WithRequestId(context.Background(), "req-1")
+	if _, err := featureView.GetOnlineFeaturesWithContext(ctx, []interface{}{"1", "2"}, []string{"*"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := metrics.Labels{Project: "fs_local", FeatureView: "user_fea", DatasourceType: "featuredb", Operation: metrics.OperationGetFeatures}
+	assert.Equal(t, float64(1), collector.Counter(metrics.ReadsTotal, labels))
+	assert.Equal(t, float64(2), collector.Counter(metrics.ReadKeysTotal, labels))
+	assert.Equal(t, float64(0), collector.Counter(metrics.ReadHitsTotal, labels))
+	assert.Equal(t, float64(2), collector.Counter(metrics.ReadMissesTotal, labels))
+	if h, ok := collector.Histogram(metrics.ReadLatencySeconds, labels); !ok || h.Count != 1 {
+		t.Fatalf("latency not observed, %v", h)
+	}
+
+	if _, err := project.GetModel("rank_v1").GetOnlineFeaturesWithContext(ctx, map[string][]interface{}{"user_id": {"1"}}); err != nil {
+		t.Fatal(err)
+	}
+	modelLabels := metrics.Labels{Project: "fs_local", Model: "rank_v1", DatasourceType: "featuredb", Operation: metrics.OperationModelGetOnlineFeatures}
+	assert.Equal(t, float64(1), collector.Counter(metrics.ReadsTotal, modelLabels))
+	assert.Equal(t, float64(3), collector.Counter(metrics.ReadKeysTotal, labels))
+
+	mu.Lock()
+	defer mu.Unlock()
+	found := false
+	for _, line := range lines {
+		if strings.Contains(line, `msg="featuredb request failed" feature_view=user_fea datasource_type=featuredb request_id=req-1`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("featuredb error not logged, %v", lines)
+	}
+}
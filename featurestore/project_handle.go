@@ -0,0 +1,187 @@
+package featurestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+// RetireGracePeriod is how long a replaced project keeps serving the requests holding it, it is closed after its
+// in-flight reads drain then, waiting for up to another RetireGracePeriod
+var RetireGracePeriod = time.Minute
+
+// NotFoundProjectTTL is how long a project not found by the metadata source is not looked up again, the refresh of
+// the project data looks the projects up again before it expires
+var NotFoundProjectTTL = 10 * time.Second
+
+// metadataVersion is an immutable view of the loaded projects, a refresh publishes a new version instead of modifying it
+type metadataVersion struct {
+	version  uint64
+	projects map[string]*domain.Project
+
+	// fullyLoaded marks the projects loaded with all feature views and models, others are loaded lazily
+	fullyLoaded map[string]bool
+}
+
+// ProjectHandle is a project pinned to one version of the metadata.
+// Hold it for the duration of a request so all feature views and models come from the same refresh.
+type ProjectHandle struct {
+	*domain.Project
+
+	version uint64
+}
+
+// Version returns the metadata version of the project, it increases with every refresh
+func (h *ProjectHandle) Version() uint64 {
+	return h.version
+}
+
+// GetProjectHandle returns the project of the current metadata version, the project is loaded on first use.
+// A project not found is not looked up again for NotFoundProjectTTL or until the next refresh
+func (c *FeatureStoreClient) GetProjectHandle(name string) (*ProjectHandle, error) {
+	current := c.metadata.Load()
+	if project, ok := current.projects[name]; ok {
+		return &ProjectHandle{Project: project, version: current.version}, nil
+	}
+	if expiresAt, ok := c.notFoundProjects.Load(name); ok && time.Now().Before(expiresAt.(time.Time)) {
+		return nil, fmt.Errorf("not found project, name:%s", name)
+	}
+
+	_, err, _ := c.projectLoader.Do(name, func() (interface{}, error) {
+		if _, ok := c.projects()[name]; ok {
+			return nil, nil
+		}
+		err := c.loadProject(name, true)
+		if errors.Is(err, ErrProjectNotFound) {
+			c.notFoundProjects.Store(name, time.Now().Add(NotFoundProjectTTL))
+		}
+		return nil, err
+	})
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			return nil, fmt.Errorf("not found project, name:%s", name)
+		}
+		return nil, err
+	}
+
+	current = c.metadata.Load()
+	if project, ok := current.projects[name]; ok {
+		return &ProjectHandle{Project: project, version: current.version}, nil
+	}
+
+	return nil, fmt.Errorf("not found project, name:%s", name)
+}
+
+// MetadataVersion returns the current metadata version
+func (c *FeatureStoreClient) MetadataVersion() uint64 {
+	return c.metadata.Load().version
+}
+
+// projects returns the projects of the current metadata version, the map must not be modified
+func (c *FeatureStoreClient) projects() map[string]*domain.Project {
+	return c.metadata.Load().projects
+}
+
+// publishProject publishes a new metadata version with the project added or replaced, the project must be fully built before.
+// A lazily loaded project never replaces a published one. The diff against the replaced project is returned
+// when both are fully loaded.
+func (c *FeatureStoreClient) publishProject(project *domain.Project, full bool) *MetadataDiff {
+	c.publishMu.Lock()
+	defer c.publishMu.Unlock()
+
+	current := c.metadata.Load()
+	oldProject, exists := current.projects[project.ProjectName]
+	if exists && !full {
+		project.Close()
+		return nil
+	}
+
+	setFeatureEntityMap(project)
+	var diff *MetadataDiff
+	if exists && current.fullyLoaded[project.ProjectName] {
+		diff = diffProject(oldProject, project)
+	}
+
+	c.metadata.Store(current.withProject(project, full))
+	if exists && oldProject != project {
+		c.retireProject(oldProject)
+	}
+
+	return diff
+}
+
+// replaceProject publishes the project rebuilt from old unless old is replaced meanwhile, the project keeps the
+// loading state of old. It returns false when the project is not published
+func (c *FeatureStoreClient) replaceProject(old, project *domain.Project) bool {
+	c.publishMu.Lock()
+	defer c.publishMu.Unlock()
+
+	current := c.metadata.Load()
+	if current.projects[project.ProjectName] != old {
+		return false
+	}
+	setFeatureEntityMap(project)
+	c.metadata.Store(current.withProject(project, current.fullyLoaded[project.ProjectName]))
+	c.retireProject(old)
+
+	return true
+}
+
+// setFeatureEntityMap sets the deprecated FeatureEntityMap of a project before it is published, it is not modified after
+func setFeatureEntityMap(project *domain.Project) {
+	if entities := project.FeatureEntities(); entities != nil && len(project.FeatureEntityMap) == 0 {
+		project.FeatureEntityMap = entities
+	}
+}
+
+// retireProject closes a replaced project after RetireGracePeriod, once its in-flight reads drain
+func (c *FeatureStoreClient) retireProject(project *domain.Project) {
+	c.retiredMu.Lock()
+	defer c.retiredMu.Unlock()
+
+	c.retiredProjects[project] = time.AfterFunc(RetireGracePeriod, func() {
+		c.closeRetiredProject(project)
+	})
+}
+
+// closeRetiredProject closes a retired project unless the client is closed meanwhile
+func (c *FeatureStoreClient) closeRetiredProject(project *domain.Project) {
+	c.retiredMu.Lock()
+	_, ok := c.retiredProjects[project]
+	delete(c.retiredProjects, project)
+	c.retiredMu.Unlock()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RetireGracePeriod)
+	defer cancel()
+	if err := project.Drain(ctx); err != nil {
+		c.logError(fmt.Errorf("drain retired project error, name:%s, err=%v", project.ProjectName, err))
+	}
+	if err := project.Close(); err != nil {
+		c.logError(fmt.Errorf("close retired project error, name:%s, err=%v", project.ProjectName, err))
+	}
+}
+
+// withProject returns the next version with the project added or replaced
+func (v *metadataVersion) withProject(project *domain.Project, full bool) *metadataVersion {
+	next := &metadataVersion{
+		version:     v.version + 1,
+		projects:    make(map[string]*domain.Project, len(v.projects)+1),
+		fullyLoaded: make(map[string]bool, len(v.fullyLoaded)+1),
+	}
+	for name, p := range v.projects {
+		next.projects[name] = p
+	}
+	for name, loaded := range v.fullyLoaded {
+		next.fullyLoaded[name] = loaded
+	}
+	next.projects[project.ProjectName] = project
+	next.fullyLoaded[project.ProjectName] = full
+
+	return next
+}
@@ -0,0 +1,213 @@
+package featurestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/api"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/constants"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/domain"
+)
+
+// ExportSnapshot writes the loaded project metadata to w in the MetadataFile layout, so it can be loaded back by FileMetadataSource.
+// Datasource secrets (tokens and passwords) are not exported, they are taken from the client when the snapshot is loaded,
+// see WithSecretsResolver and WithFeatureDBToken.
+func (c *FeatureStoreClient) ExportSnapshot(w io.Writer) error {
+	metadataFile := MetadataFile{}
+
+	projects := c.projects()
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metadataFile.Projects = append(metadataFile.Projects, newProjectMetadata(projects[name], false))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&metadataFile)
+}
+
+// saveSnapshot writes the snapshot to snapshotPath, a temporary file is renamed so readers never see a partial file
+func (c *FeatureStoreClient) saveSnapshot() error {
+	if c.snapshotPath == "" || len(c.projects()) == 0 {
+		return nil
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(c.snapshotPath), filepath.Base(c.snapshotPath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("save snapshot error, path:%s, err=%v", c.snapshotPath, err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := c.ExportSnapshot(file); err != nil {
+		file.Close()
+		return fmt.Errorf("save snapshot error, path:%s, err=%v", c.snapshotPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("save snapshot error, path:%s, err=%v", c.snapshotPath, err)
+	}
+
+	if err := os.Rename(file.Name(), c.snapshotPath); err != nil {
+		return fmt.Errorf("save snapshot error, path:%s, err=%v", c.snapshotPath, err)
+	}
+
+	return nil
+}
+
+// loadSnapshot loads the projects from the last saved snapshot
+func (c *FeatureStoreClient) loadSnapshot() error {
+	metadataFile, err := NewFileMetadataSource(c.snapshotPath).readFile()
+	if err != nil {
+		return err
+	}
+
+	for _, meta := range metadataFile.Projects {
+		if err := c.resolveSecrets(meta); err != nil {
+			return err
+		}
+
+		project, err := c.newProject(meta)
+		if err != nil {
+			return err
+		}
+
+		c.publishProject(project, true)
+	}
+
+	return nil
+}
+
+// resolveSecrets sets the secrets the snapshot does not keep with the secrets resolver of the client, the loading fails
+// when a secret the online store needs is still missing
+func (c *FeatureStoreClient) resolveSecrets(meta *ProjectMetadata) error {
+	if meta.Project == nil {
+		return nil
+	}
+	name := meta.Project.ProjectName
+
+	if c.secretsResolver != nil {
+		datasources := []*api.Datasource{meta.Project.OnlineDataSource, meta.Project.OfflineDataSource}
+		for _, view := range meta.FeatureViews {
+			datasources = append(datasources, view.RegisterDataSource)
+		}
+		for _, ds := range datasources {
+			if ds == nil {
+				continue
+			}
+			if err := c.secretsResolver(name, ds); err != nil {
+				return fmt.Errorf("resolve secrets error, project:%s, datasource:%s, err=%v", name, ds.Name, err)
+			}
+		}
+
+		if meta.FeatureDB != nil && meta.FeatureDB.Token == "" {
+			ds := &api.Datasource{
+				Type:          constants.Datasource_Type_FeatureDB,
+				PublicAddress: meta.FeatureDB.Address,
+				VpcAddress:    meta.FeatureDB.VpcAddress,
+			}
+			if err := c.secretsResolver(name, ds); err != nil {
+				return fmt.Errorf("resolve secrets error, project:%s, datasource:featuredb, err=%v", name, err)
+			}
+			meta.FeatureDB.Token = ds.Token
+		}
+	}
+
+	if online := meta.Project.OnlineDataSource; online != nil && c.datasourceInitClient &&
+		online.Type == constants.Datasource_Type_IGraph && online.Pwd == "" {
+		return fmt.Errorf("load snapshot error, the password of the igraph datasource is missing, project:%s, datasource:%s, see WithSecretsResolver",
+			name, online.Name)
+	}
+	if meta.FeatureDB != nil && meta.FeatureDB.Token == "" && c.featureDBToken == "" {
+		return fmt.Errorf("load snapshot error, the token of featuredb is missing, project:%s, see WithSecretsResolver or WithFeatureDBToken", name)
+	}
+
+	return nil
+}
+
+// newProjectMetadata returns the metadata the project is built from, the datasources are copied so the metadata can
+// build a new project. The secrets are only kept with withSecrets
+func newProjectMetadata(project *domain.Project, withSecrets bool) *ProjectMetadata {
+	p := *project.Project
+	p.OnlineDataSource = copyDatasource(p.OnlineDataSource, withSecrets)
+	p.OfflineDataSource = copyDatasource(p.OfflineDataSource, withSecrets)
+
+	meta := &ProjectMetadata{
+		Project: &p,
+	}
+	if project.FeatureDBAddress != "" || project.FeatureDBVpcAddress != "" {
+		meta.FeatureDB = &FeatureDBMetadata{
+			Address:    project.FeatureDBAddress,
+			VpcAddress: project.FeatureDBVpcAddress,
+		}
+		if withSecrets {
+			meta.FeatureDB.Token = project.FeatureDBToken
+		}
+	}
+
+	for _, entity := range project.FeatureEntities() {
+		meta.FeatureEntities = append(meta.FeatureEntities, entity.FeatureEntity)
+	}
+	sort.Slice(meta.FeatureEntities, func(i, j int) bool {
+		return meta.FeatureEntities[i].FeatureEntityId < meta.FeatureEntities[j].FeatureEntityId
+	})
+
+	project.FeatureViewMap.Range(func(key, value any) bool {
+		var view *api.FeatureView
+		switch featureView := value.(type) {
+		case *domain.BaseFeatureView:
+			view = featureView.FeatureView
+		case *domain.SequenceFeatureView:
+			view = featureView.FeatureView
+		}
+		if view != nil {
+			v := *view
+			v.RegisterDataSource = copyDatasource(v.RegisterDataSource, withSecrets)
+			meta.FeatureViews = append(meta.FeatureViews, &v)
+		}
+		return true
+	})
+	// referenced sequence feature views must be loaded first
+	sort.Slice(meta.FeatureViews, func(i, j int) bool {
+		return meta.FeatureViews[i].FeatureViewId < meta.FeatureViews[j].FeatureViewId
+	})
+
+	project.LabelTableMap.Range(func(key, value any) bool {
+		meta.LabelTables = append(meta.LabelTables, value.(*domain.LabelTable).LabelTable)
+		return true
+	})
+	sort.Slice(meta.LabelTables, func(i, j int) bool {
+		return meta.LabelTables[i].LabelTableId < meta.LabelTables[j].LabelTableId
+	})
+
+	project.ModelMap.Range(func(key, value any) bool {
+		meta.Models = append(meta.Models, value.(*domain.Model).Model)
+		return true
+	})
+	sort.Slice(meta.Models, func(i, j int) bool {
+		return meta.Models[i].ModelId < meta.Models[j].ModelId
+	})
+
+	return meta
+}
+
+// copyDatasource returns a copy of the datasource, without secrets unless withSecrets
+func copyDatasource(datasource *api.Datasource, withSecrets bool) *api.Datasource {
+	if datasource == nil {
+		return nil
+	}
+
+	ds := *datasource
+	if !withSecrets {
+		ds.Token = ""
+		ds.Pwd = ""
+	}
+
+	return &ds
+}
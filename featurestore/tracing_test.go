@@ -0,0 +1,124 @@
+package featurestore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"fortio.org/assert"
+
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/datasource"
+	"github.com/aliyun/aliyun-pai-featurestore-go-sdk/v2/tracing"
+)
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...tracing.Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+func (s *testSpan) RecordError(err error) {}
+func (s *testSpan) End()                  { s.ended = true }
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, spanName string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	span := &testSpan{name: spanName, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	span.SetAttributes(attrs...)
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func (t *testTracer) find(name string) *testSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, span := range t.spans {
+		if span.name == name {
+			return span
+		}
+	}
+
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metadata.yaml")
+	if err := os.WriteFile(path, []byte(featureDBMetadataYaml(testMetadataYaml, server.URL)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := datasource.NewRegistry()
+	defer registry.Close()
+
+	tracer := &testTracer{}
+	client, err := NewFeatureStoreClient("cn-test", "", "", "fs_local", WithMetadataSource(NewFileMetadataSource(path)),
+		WithNoDatasourceInitClient(), WithLoopData(false), WithDatasourceRegistry(registry), WithFeatureDBLogin("user", "pwd"),
+		WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := client.GetProject("fs_local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, request := tracer.Start(context.Background(), "request")
+	if _, err := project.GetModel("rank_v1").GetOnlineFeaturesWithContext(ctx, map[string][]interface{}{"user_id": {"1", "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	request.End()
+
+	for _, expected := range []struct {
+		name   string
+		parent string
+	}{
+		{"Model.GetOnlineFeatures", "request"},
+		{"FeatureView.GetOnlineFeatures", "Model.GetOnlineFeatures"},
+		{"FeatureViewDao.GetFeatures", "FeatureView.GetOnlineFeatures"},
+		{"FeatureDB.batch_get_kv2", "FeatureViewDao.GetFeatures"},
+		{"FeatureDB.request", "FeatureDB.batch_get_kv2"},
+	} {
+		span := tracer.find(expected.name)
+		if span == nil {
+			t.Fatalf("span %s not opened", expected.name)
+		}
+		assert.Equal(t, expected.parent, span.parent)
+		assert.True(t, span.ended, expected.name)
+	}
+
+	assert.Equal(t, 2, tracer.find("Model.GetOnlineFeatures").attrs[tracing.AttrKeyCount])
+	assert.Equal(t, "root", tracer.find("FeatureView.GetOnlineFeatures").attrs[tracing.AttrStage])
+	assert.Equal(t, "featuredb", tracer.find("FeatureViewDao.GetFeatures").attrs[tracing.AttrDatasourceType])
+	assert.Equal(t, 2, tracer.find("FeatureDB.batch_get_kv2").attrs[tracing.AttrKeyCount])
+	assert.Equal(t, 0, tracer.find("FeatureDB.request").attrs[tracing.AttrAttempt])
+	assert.Equal(t, http.StatusServiceUnavailable, tracer.find("FeatureDB.request").attrs[tracing.AttrHTTPStatusCode])
+}
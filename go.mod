@@ -17,6 +17,7 @@ require (
 	github.com/lib/pq v1.10.9
 	golang.org/x/sync v0.7.0
 	google.golang.org/protobuf v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Level of a log line
+type Level int8
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// Field is a key value pair attached to a log line
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns the field of key and value
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err returns the field of the error
+func Err(err error) Field {
+	return Field{Key: "err", Value: err}
+}
+
+// Logger is a leveled logger with structured fields.
+// The implementations must be safe for concurrent use
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+
+	// With returns a logger adding the fields to every line
+	With(fields ...Field) Logger
+}
+
+var defaultLogger = NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), LevelInfo)
+
+// Default returns the logger used when none is configured, it writes the lines of info level and above to stderr
+func Default() Logger {
+	return defaultLogger
+}
+
+// New returns a logger writing the lines of level and above as key=value text to output
+func New(level Level, output func(level Level, line string)) Logger {
+	return &textLogger{level: level, output: output}
+}
+
+// NewStdLogger returns a logger writing the lines of level and above to l
+func NewStdLogger(l *log.Logger, level Level) Logger {
+	return New(level, func(_ Level, line string) {
+		l.Println(line)
+	})
+}
+
+// Nop returns a logger discarding every line
+func Nop() Logger {
+	return nopLogger{}
+}
+
+type textLogger struct {
+	level  Level
+	fields []Field
+	output func(level Level, line string)
+}
+
+func (l *textLogger) Debug(msg string, fields ...Field) { l.log(LevelDebug, msg, fields) }
+func (l *textLogger) Info(msg string, fields ...Field)  { l.log(LevelInfo, msg, fields) }
+func (l *textLogger) Warn(msg string, fields ...Field)  { l.log(LevelWarn, msg, fields) }
+func (l *textLogger) Error(msg string, fields ...Field) { l.log(LevelError, msg, fields) }
+
+func (l *textLogger) With(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	merged := make([]Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	merged = append(merged, fields...)
+
+	return &textLogger{level: l.level, fields: merged, output: l.output}
+}
+
+func (l *textLogger) log(level Level, msg string, fields []Field) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("level=")
+	b.WriteString(level.String())
+	b.WriteString(" msg=")
+	b.WriteString(formatValue(msg))
+	for _, field := range l.fields {
+		writeField(&b, field)
+	}
+	for _, field := range fields {
+		writeField(&b, field)
+	}
+
+	l.output(level, b.String())
+}
+
+func writeField(b *strings.Builder, field Field) {
+	b.WriteByte(' ')
+	b.WriteString(field.Key)
+	b.WriteByte('=')
+	b.WriteString(formatValue(fmt.Sprint(field.Value)))
+}
+
+func formatValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, fields ...Field) {}
+func (nopLogger) Info(msg string, fields ...Field)  {}
+func (nopLogger) Warn(msg string, fields ...Field)  {}
+func (nopLogger) Error(msg string, fields ...Field) {}
+func (n nopLogger) With(fields ...Field) Logger     { return n }
+
+type requestIdKey struct{}
+
+// WithRequestId returns a context carrying the request id, it is added to the lines logged while serving the request
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request id of ctx, empty if there is none
+func RequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// WithContext returns l with the request id of ctx, l itself if ctx has none
+func WithContext(l Logger, ctx context.Context) Logger {
+	if requestId := RequestIdFromContext(ctx); requestId != "" {
+		return l.With(F("request_id", requestId))
+	}
+
+	return l
+}
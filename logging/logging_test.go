@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var lines []string
+	logger := New(LevelInfo, func(level Level, line string) {
+		lines = append(lines, line)
+	})
+
+	logger.Debug("row count", F("sql", "select count(*) from t"))
+	if len(lines) != 0 {
+		t.Fatalf("debug line written at info level, %v", lines)
+	}
+
+	ctx := WithRequestId(context.Background(), "req-1")
+	daoLogger := logger.With(F("feature_view", "user_fv"), F("datasource_type", "tablestore"))
+	WithContext(daoLogger, ctx).Error("get range failed", Err(errors.New("timeout exceeded")))
+
+	expected := `level=error msg="get range failed" feature_view=user_fv datasource_type=tablestore request_id=req-1 err="timeout exceeded"`
+	if len(lines) != 1 || lines[0] != expected {
+		t.Fatalf("unexpected lines, %v", lines)
+	}
+
+	if WithContext(daoLogger, context.Background()) != daoLogger {
+		t.Fatal("logger without request id should not be copied")
+	}
+}
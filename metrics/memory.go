@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency histogram buckets
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// FeatureAgeBuckets are the upper bounds in seconds of the FeatureAgeSeconds histogram buckets, from a second to a week
+var FeatureAgeBuckets = []float64{1, 10, 60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 86400, 3 * 86400, 7 * 86400}
+
+type series struct {
+	name   string
+	labels Labels
+}
+
+// Histogram is a snapshot of a histogram, Counts[i] is the number of the values not greater than Buckets[i]
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+// InMemoryCollector keeps the metrics in memory
+type InMemoryCollector struct {
+	mu         sync.Mutex
+	buckets    []float64
+	counters   map[series]float64
+	histograms map[series]*Histogram
+}
+
+// NewInMemoryCollector creates a collector with the histogram buckets, DefaultBuckets if none is given
+func NewInMemoryCollector(buckets ...float64) *InMemoryCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &InMemoryCollector{
+		buckets:    buckets,
+		counters:   make(map[series]float64),
+		histograms: make(map[series]*Histogram),
+	}
+}
+
+func (c *InMemoryCollector) AddCounter(name string, labels Labels, value float64) {
+	c.mu.Lock()
+	c.counters[series{name: name, labels: labels}] += value
+	c.mu.Unlock()
+}
+
+func (c *InMemoryCollector) ObserveHistogram(name string, labels Labels, value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := series{name: name, labels: labels}
+	h, ok := c.histograms[key]
+	if !ok {
+		buckets := c.buckets
+		if name == FeatureAgeSeconds {
+			buckets = FeatureAgeBuckets
+		}
+		h = &Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+		c.histograms[key] = h
+	}
+
+	for i, bound := range h.Buckets {
+		if value <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += value
+}
+
+// Counter returns the value of the counter, 0 if it is not recorded
+func (c *InMemoryCollector) Counter(name string, labels Labels) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.counters[series{name: name, labels: labels}]
+}
+
+// Histogram returns a copy of the histogram, false if it is not recorded
+func (c *InMemoryCollector) Histogram(name string, labels Labels) (Histogram, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.histograms[series{name: name, labels: labels}]
+	if !ok {
+		return Histogram{}, false
+	}
+
+	return h.copy(), true
+}
+
+// Quantile returns the estimated q-quantile of the values, interpolated linearly in the bucket of the quantile like
+// histogram_quantile of Prometheus. The quantile in the values above the last bucket is the upper bound of it
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.Count)
+	lowerBound, lowerCount := 0.0, uint64(0)
+	for i, bound := range h.Buckets {
+		if float64(h.Counts[i]) >= rank {
+			if h.Counts[i] == lowerCount {
+				return bound
+			}
+			return lowerBound + (bound-lowerBound)*(rank-float64(lowerCount))/float64(h.Counts[i]-lowerCount)
+		}
+		lowerBound, lowerCount = bound, h.Counts[i]
+	}
+
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+func (h *Histogram) copy() Histogram {
+	return Histogram{
+		Buckets: h.Buckets,
+		Counts:  append([]uint64(nil), h.Counts...),
+		Count:   h.Count,
+		Sum:     h.Sum,
+	}
+}
+
+// Reset removes all the metrics
+func (c *InMemoryCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counters = make(map[series]float64)
+	c.histograms = make(map[series]*Histogram)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"time"
+)
+
+// Names of the metrics of the online feature reads
+const (
+	// ReadsTotal counts the reads
+	ReadsTotal = "featurestore_reads_total"
+	// ReadErrorsTotal counts the failed reads
+	ReadErrorsTotal = "featurestore_read_errors_total"
+	// ReadKeysTotal counts the keys requested by the reads
+	ReadKeysTotal = "featurestore_read_keys_total"
+	// ReadHitsTotal counts the keys found by the reads, only recorded by the operations returning a row per key
+	ReadHitsTotal = "featurestore_read_hits_total"
+	// ReadMissesTotal counts the keys not found by the reads, only recorded with ReadHitsTotal
+	ReadMissesTotal = "featurestore_read_misses_total"
+	// ReadLatencySeconds is the histogram of the read latency
+	ReadLatencySeconds = "featurestore_read_latency_seconds"
+	// FeatureAgeSeconds is the histogram of the age of the rows read with their freshness, see FeatureAgeBuckets
+	FeatureAgeSeconds = "featurestore_feature_age_seconds"
+	// StaleRowsTotal counts the rows read with their freshness older than the staleness threshold
+	StaleRowsTotal = "featurestore_stale_rows_total"
+)
+
+// Operations of the reads
+const (
+	OperationGetFeatures                      = "get_features"
+	OperationGetFeaturesBatch                 = "get_features_batch"
+	OperationGetUserSequenceFeature           = "get_user_sequence_feature"
+	OperationGetUserAggregatedSequenceFeature = "get_user_aggregated_sequence_feature"
+	OperationGetUserBehaviorFeature           = "get_user_behavior_feature"
+	OperationModelGetOnlineFeatures           = "model_get_online_features"
+	OperationModelGetOnlineFeaturesWithEntity = "model_get_online_features_with_entity"
+	OperationModelGetOnlineFeaturesBatch      = "model_get_online_features_batch"
+)
+
+// Labels of a metric, the fields not relevant to the read are empty
+type Labels struct {
+	Project        string
+	FeatureView    string
+	Model          string
+	DatasourceType string
+	Operation      string
+}
+
+// MetricsCollector receives the metrics of the reads. The implementations must be safe for concurrent use
+// and should not block, they are called on the read path
+type MetricsCollector interface {
+	AddCounter(name string, labels Labels, value float64)
+	ObserveHistogram(name string, labels Labels, value float64)
+}
+
+// Nop returns a collector discarding the metrics
+func Nop() MetricsCollector {
+	return nopCollector{}
+}
+
+type nopCollector struct{}
+
+func (nopCollector) AddCounter(name string, labels Labels, value float64)       {}
+func (nopCollector) ObserveHistogram(name string, labels Labels, value float64) {}
+
+// ObserveRead records a read started at start of keys keys. hits is the number of keys found, negative if the
+// operation does not return a row per key
+func ObserveRead(c MetricsCollector, labels Labels, start time.Time, keys, hits int, err error) {
+	c.AddCounter(ReadsTotal, labels, 1)
+	c.ObserveHistogram(ReadLatencySeconds, labels, time.Since(start).Seconds())
+	c.AddCounter(ReadKeysTotal, labels, float64(keys))
+	if err != nil {
+		c.AddCounter(ReadErrorsTotal, labels, 1)
+		return
+	}
+
+	if hits < 0 {
+		return
+	}
+	if hits > keys {
+		hits = keys
+	}
+	c.AddCounter(ReadHitsTotal, labels, float64(hits))
+	c.AddCounter(ReadMissesTotal, labels, float64(keys-hits))
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	c := NewPrometheusCollector(0.1, 1)
+	labels := Labels{Project: "fs", FeatureView: "user_fea", DatasourceType: "hologres", Operation: OperationGetFeatures}
+
+	ObserveRead(c, labels, time.Now(), 3, 2, nil)
+	ObserveRead(c, labels, time.Now(), 2, 0, errors.New("timeout"))
+	c.ObserveHistogram(ReadLatencySeconds, labels, 0.5)
+
+	if v := c.Counter(ReadsTotal, labels); v != 2 {
+		t.Fatalf("reads:%v", v)
+	}
+	if v := c.Counter(ReadMissesTotal, labels); v != 1 {
+		t.Fatalf("misses:%v", v)
+	}
+	if v := c.Counter(ReadErrorsTotal, labels); v != 1 {
+		t.Fatalf("errors:%v", v)
+	}
+
+	recorder := httptest.NewRecorder()
+	c.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	l := `project="fs",feature_view="user_fea",datasource_type="hologres",operation="get_features"`
+	for _, expected := range []string{
+		"# TYPE featurestore_read_errors_total counter\nfeaturestore_read_errors_total{" + l + "} 1\n",
+		"featurestore_read_keys_total{" + l + "} 5\n",
+		"featurestore_read_hits_total{" + l + "} 2\n",
+		"# TYPE featurestore_read_latency_seconds histogram\n",
+		"featurestore_read_latency_seconds_bucket{" + l + `,le="0.1"} 2` + "\n",
+		"featurestore_read_latency_seconds_bucket{" + l + `,le="1"} 3` + "\n",
+		"featurestore_read_latency_seconds_bucket{" + l + `,le="+Inf"} 3` + "\n",
+		"featurestore_read_latency_seconds_count{" + l + "} 3\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("%q not found in\n%s", expected, body)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if v := formatLabels(Labels{Project: "a\"b\\c\nd"}, ""); v != `{project="a\"b\\c\nd"}` {
+		t.Fatal(v)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	c := NewInMemoryCollector()
+	labels := Labels{Project: "fs", FeatureView: "user_fea"}
+	for _, age := range []float64{5, 5, 30, 30, 30, 30, 45, 120, 4000, 1e7} {
+		c.ObserveHistogram(FeatureAgeSeconds, labels, age)
+	}
+
+	h, ok := c.Histogram(FeatureAgeSeconds, labels)
+	if !ok || len(h.Buckets) != len(FeatureAgeBuckets) {
+		t.Fatalf("expect the feature age buckets, got %+v", h)
+	}
+	for q, expected := range map[float64]float64{0.2: 10, 0.5: 10 + 50*0.6, 0.8: 300, 1: 7 * 86400} {
+		if v := h.Quantile(q); v != expected {
+			t.Fatalf("quantile %v: expect %v, got %v", q, expected, v)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusCollector keeps the metrics in memory and exposes them in the Prometheus text format,
+// serve it on the metrics path of the scrape config
+type PrometheusCollector struct {
+	*InMemoryCollector
+}
+
+// NewPrometheusCollector creates a collector with the histogram buckets, DefaultBuckets if none is given
+func NewPrometheusCollector(buckets ...float64) *PrometheusCollector {
+	return &PrometheusCollector{InMemoryCollector: NewInMemoryCollector(buckets...)}
+}
+
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (c *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	counters := make(map[series]float64, len(c.counters))
+	for key, value := range c.counters {
+		counters[key] = value
+	}
+	histograms := make(map[series]Histogram, len(c.histograms))
+	for key, h := range c.histograms {
+		histograms[key] = h.copy()
+	}
+	c.mu.Unlock()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+
+	lastName := ""
+	for _, key := range sortedSeries(counters) {
+		if key.name != lastName {
+			cw.writeString("# TYPE " + key.name + " counter\n")
+			lastName = key.name
+		}
+		cw.writeString(key.name + formatLabels(key.labels, "") + " " + formatFloat(counters[key]) + "\n")
+	}
+
+	lastName = ""
+	for _, key := range sortedSeries(histograms) {
+		if key.name != lastName {
+			cw.writeString("# TYPE " + key.name + " histogram\n")
+			lastName = key.name
+		}
+		h := histograms[key]
+		for i, bound := range h.Buckets {
+			cw.writeString(key.name + "_bucket" + formatLabels(key.labels, formatFloat(bound)) + " " + strconv.FormatUint(h.Counts[i], 10) + "\n")
+		}
+		cw.writeString(key.name + "_bucket" + formatLabels(key.labels, "+Inf") + " " + strconv.FormatUint(h.Count, 10) + "\n")
+		cw.writeString(key.name + "_sum" + formatLabels(key.labels, "") + " " + formatFloat(h.Sum) + "\n")
+		cw.writeString(key.name + "_count" + formatLabels(key.labels, "") + " " + strconv.FormatUint(h.Count, 10) + "\n")
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+
+	return cw.n, cw.err
+}
+
+func sortedSeries[V any](m map[series]V) []series {
+	keys := make([]series, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return formatLabels(keys[i].labels, "") < formatLabels(keys[j].labels, "")
+	})
+
+	return keys
+}
+
+// formatLabels formats the non empty labels, le is the bucket bound of a histogram
+func formatLabels(labels Labels, le string) string {
+	var pairs []string
+	for _, label := range [][2]string{
+		{"project", labels.Project},
+		{"feature_view", labels.FeatureView},
+		{"model", labels.Model},
+		{"datasource_type", labels.DatasourceType},
+		{"operation", labels.Operation},
+		{"le", le},
+	} {
+		if label[1] != "" {
+			pairs = append(pairs, label[0]+"=\""+escapeLabelValue(label[1])+"\"")
+		}
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countWriter) writeString(s string) {
+	if w.err != nil {
+		return
+	}
+
+	n, err := w.w.WriteString(s)
+	w.n += int64(n)
+	w.err = err
+}
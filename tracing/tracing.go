@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+)
+
+// Attribute keys of the spans
+const (
+	AttrProject        = "featurestore.project"
+	AttrModel          = "featurestore.model"
+	AttrFeatureView    = "featurestore.feature_view"
+	AttrFeatureEntity  = "featurestore.feature_entity"
+	AttrJoinId         = "featurestore.join_id"
+	AttrDatasourceType = "featurestore.datasource_type"
+	AttrTable          = "featurestore.table"
+	AttrKeyCount       = "featurestore.key_count"
+	AttrResultCount    = "featurestore.result_count"
+	AttrCallerCount    = "featurestore.caller_count"
+	AttrStage          = "featurestore.stage"
+	AttrAttempt        = "featurestore.attempt"
+	AttrHedged         = "featurestore.hedged"
+	AttrServerAddress  = "server.address"
+	AttrHTTPStatusCode = "http.response.status_code"
+)
+
+// Attribute is a key value pair of a span, the value is a string, an int, a bool or a float64
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer opens the spans of the reads. It follows the OpenTelemetry tracer, Start takes the parent span from ctx
+// and returns a context carrying the new span, so an OpenTelemetry tracer is adapted by converting the attributes
+type Tracer interface {
+	Start(ctx context.Context, spanName string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an open span, End must be called once the traced work is done
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Nop returns a tracer opening no span
+func Nop() Tracer {
+	return nopTracer{}
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, spanName string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(attrs ...Attribute) {}
+func (nopSpan) RecordError(err error)            {}
+func (nopSpan) End()                             {}
+
+// End records err if it is not nil and ends the span
+func End(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}